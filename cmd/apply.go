@@ -2,32 +2,54 @@ package cmd
 
 import (
 	"bufio"
+	"context"
 	"fmt"
 	"os"
 	"strings"
+	"time"
 
+	"github.com/Litchi-group/unipm/internal/config"
 	"github.com/Litchi-group/unipm/internal/detector"
 	"github.com/Litchi-group/unipm/internal/planner"
 	"github.com/Litchi-group/unipm/internal/registry"
+	"github.com/Litchi-group/unipm/internal/render"
 	"github.com/spf13/cobra"
 )
 
 var (
-	dryRun  bool
-	yes     bool
-	profile string
+	dryRun     bool
+	yes        bool
+	profile    string
+	maxPlanAge time.Duration
 )
 
 var applyCmd = &cobra.Command{
-	Use:   "apply",
+	Use:   "apply [planfile]",
 	Short: "Apply the installation plan",
 	Long: `Executes the installation plan by invoking native package managers.
 Skips packages that are already installed.
 
+Given a plan saved with 'unipm plan --out', runs exactly that plan instead of
+reading devpack.yaml. It must have been made for this OS, be no older than
+--max-age, and the registry definitions and installed state of its packages
+must not have changed since.
+
 By default, prompts for confirmation before executing.
-Use --yes to skip confirmation.`,
+Use --yes to skip confirmation; it is required with --output json or yaml,
+unless --dry-run is set.`,
+	Example: `  unipm apply
+  unipm apply --profile backend --yes
+  unipm plan --out plan.json && unipm apply plan.json`,
+	Args:        cobra.MaximumNArgs(1),
+	Annotations: structuredOutput,
 	RunE: func(cmd *cobra.Command, args []string) error {
-		return runApply()
+		if len(args) == 1 {
+			if profile != "" {
+				return fmt.Errorf("--profile cannot be used with a plan file")
+			}
+			return runApplyPlanFile(cmd.Context(), args[0])
+		}
+		return runApply(cmd.Context())
 	},
 }
 
@@ -37,9 +59,15 @@ func init() {
 	applyCmd.Flags().BoolVar(&dryRun, "dry-run", false, "Show what would be done without executing")
 	applyCmd.Flags().BoolVarP(&yes, "yes", "y", false, "Skip confirmation prompt")
 	applyCmd.Flags().StringVarP(&profile, "profile", "p", "", "Use a specific profile from devpack.yaml")
+	applyCmd.Flags().DurationVar(&maxPlanAge, "max-age", planner.DefaultMaxPlanAge, "Refuse plan files older than this (0 for no limit)")
+	addRefreshFlags(applyCmd)
 }
 
-func runApply() error {
+func runApply(ctx context.Context) error {
+	if render.Structured() && !dryRun && !yes {
+		return fmt.Errorf("--output %s cannot prompt for confirmation; use --yes or --dry-run", render.Format())
+	}
+
 	// Load devpack.yaml
 	devpack, err := loadDevpackWithPrompt()
 	if err != nil {
@@ -51,7 +79,7 @@ func runApply() error {
 
 	apps := devpack.GetApps(profile)
 
-	if len(apps) == 0 {
+	if len(apps) == 0 && !render.Structured() {
 		if profile != "" {
 			fmt.Printf("Profile '%s' not found or empty in devpack.yaml\n", profile)
 		} else {
@@ -60,37 +88,107 @@ func runApply() error {
 		return nil
 	}
 
-	if profile != "" {
+	if profile != "" && !render.Structured() {
 		fmt.Printf("Using profile: %s\n\n", profile)
 	}
 
 	// Detect OS
 	osInfo := detector.DetectOS()
 
+	setRefreshPolicy()
+
 	// Create planner
 	reg := registry.NewRegistry()
 	plnr := planner.NewPlanner(reg, osInfo)
+	plnr.SetOverrides(devpack.Overrides)
 
 	// Create plan
-	plan, err := plnr.CreatePlan(apps)
+	plan, err := plnr.CreatePlan(ctx, apps)
 	if err != nil {
 		return handleError(err)
 	}
 
+	return executeApply(ctx, plan)
+}
+
+// runApplyPlanFile runs a plan saved by 'unipm plan --out' after checking it
+// still holds on this system
+func runApplyPlanFile(ctx context.Context, path string) error {
+	if render.Structured() && !dryRun && !yes {
+		return fmt.Errorf("--output %s cannot prompt for confirmation; use --yes or --dry-run", render.Format())
+	}
+
+	saved, err := planner.ReadSavedPlan(path)
+	if err != nil {
+		return handleError(err)
+	}
+
+	osInfo := detector.DetectOS()
+	reg := registry.NewRegistry()
+	if err := saved.Check(osInfo, reg, maxPlanAge, time.Now()); err != nil {
+		return handleError(fmt.Errorf("cannot apply %s: %w", path, err))
+	}
+
+	if !render.Structured() {
+		fmt.Printf("Using plan %s, generated %s\n\n", path, saved.GeneratedAt.Local().Format(time.RFC1123))
+	}
+
+	setRefreshPolicy()
+
+	// Packages are resolved again as 'unipm plan' did, with the overrides of
+	// devpack.yaml if there is one
+	plnr := planner.NewPlanner(reg, osInfo)
+	if _, err := os.Stat("devpack.yaml"); err == nil {
+		devpack, err := config.Load("devpack.yaml")
+		if err != nil {
+			return handleError(err)
+		}
+		plnr.SetOverrides(devpack.Overrides)
+	}
+
+	plan, err := saved.Plan(ctx, plnr)
+	if err != nil {
+		return handleError(fmt.Errorf("cannot apply %s: %w", path, err))
+	}
+
+	return executeApply(ctx, plan)
+}
+
+// executeApply shows a plan, asks for confirmation and runs it
+func executeApply(ctx context.Context, plan *planner.Plan) error {
+	plan.TaskTimeout = taskTimeout
+	recorder := setReporter(plan, planner.ActionInstall, dryRun)
+	if recorder != nil {
+		if dryRun {
+			return finishRun(recorder, plan, plan.Execute(ctx, dryRun))
+		}
+		finish := attachRunLog(plan, "apply")
+		return finishRun(recorder, plan, finish(plan.Execute(ctx, dryRun)))
+	}
+
 	// Show plan summary
-	fmt.Printf("Plan for %s:\n\n", osInfo.String())
+	fmt.Printf("Plan for %s:\n\n", plan.OSInfo.String())
+	render.PrintSteps(os.Stdout, plan)
 
 	newInstalls := 0
 	for _, task := range plan.Tasks {
 		if !task.Installed {
 			newInstalls++
-			fmt.Printf("  %s → %s\n", task.PackageID, task.Provider.InstallCommand(*task.Spec))
+			warning := ""
+			if task.RunsScript() {
+				warning = " ⚠️  SHELL SCRIPT"
+			}
+			if task.Drift != "" {
+				warning = fmt.Sprintf(" (installed but %s)", task.Drift) + warning
+			}
+			fmt.Printf("  %s → %s%s\n", task.Label(), task.Provider.InstallCommand(*task.Spec), warning)
 		} else {
-			fmt.Printf("  %s (already installed)\n", task.PackageID)
+			fmt.Printf("  %s (already installed)\n", task.Label())
 		}
 	}
 
 	fmt.Println()
+	render.PrintScriptWarning(os.Stdout, plan)
 
 	if newInstalls == 0 {
 		fmt.Println("All packages are already installed.")
@@ -120,7 +218,9 @@ func runApply() error {
 	if dryRun {
 		fmt.Println("Dry run mode enabled. Nothing will be executed.")
 		fmt.Println()
+		return handleError(plan.Execute(ctx, dryRun))
 	}
 
-	return plan.Execute(dryRun)
+	finish := attachRunLog(plan, "apply")
+	return finish(handleError(plan.Execute(ctx, dryRun)))
 }
@@ -0,0 +1,241 @@
+package cmd
+
+import (
+	"encoding/xml"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/Litchi-group/unipm/internal/config"
+	"github.com/Litchi-group/unipm/internal/registry"
+	"github.com/Litchi-group/unipm/internal/render"
+	"github.com/spf13/cobra"
+)
+
+var checkCmd = &cobra.Command{
+	Use:   "check",
+	Short: "Check that devpack.yaml can be installed on every platform",
+	Long: `Resolves every package in devpack.yaml, including dependencies, for macOS,
+Windows and the main Linux distributions (ubuntu, debian, fedora, arch),
+without installing anything.
+
+Errors:   packages with no provider for a platform, or only providers that
+          don't support it (e.g., apt on fedora), and dependency cycles
+Warnings: packages installed with a provider that is not installed by
+          default (e.g., npm or cargo)
+
+Exits with a non-zero status if there are errors, or warnings with --strict.`,
+	Example: `  unipm check
+  unipm check --format junit --out unipm-check.xml
+  unipm check --profile work --format json --strict`,
+	// A failed check is a result, not a usage error; Execute prints it
+	SilenceUsage:  true,
+	SilenceErrors: true,
+	Annotations:   structuredOutput,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		// --output json|yaml applies unless another format is asked for
+		if !cmd.Flags().Changed("format") && render.Structured() {
+			checkFormat = render.Format()
+		}
+		return runCheck()
+	},
+}
+
+var (
+	checkProfile string
+	checkFormat  string
+	checkOut     string
+	checkStrict  bool
+)
+
+func init() {
+	rootCmd.AddCommand(checkCmd)
+
+	checkCmd.Flags().StringVarP(&checkProfile, "profile", "p", "", "Check a specific profile from devpack.yaml")
+	checkCmd.Flags().StringVar(&checkFormat, "format", "text", "Report format: text, json, yaml or junit")
+	checkCmd.Flags().StringVarP(&checkOut, "out", "o", "", "File to write the report to (default: standard output)")
+	checkCmd.Flags().BoolVar(&checkStrict, "strict", false, "Fail on warnings too")
+}
+
+func runCheck() error {
+	switch checkFormat {
+	case "text", "json", "yaml", "junit":
+	default:
+		return fmt.Errorf("unknown format %q (expected text, json, yaml or junit)", checkFormat)
+	}
+
+	devpack, err := loadDevpackWithPrompt()
+	if err != nil {
+		return handleError(err)
+	}
+	if devpack == nil {
+		return nil // File not found, already printed help message
+	}
+
+	apps := devpack.GetApps(checkProfile)
+	if len(apps) == 0 {
+		if checkProfile != "" {
+			return fmt.Errorf("profile '%s' not found or empty in devpack.yaml", checkProfile)
+		}
+		return fmt.Errorf("no packages specified in devpack.yaml")
+	}
+
+	ids := make([]string, 0, len(apps))
+	for _, app := range apps {
+		ids = append(ids, config.ParsePackageSpec(app).Name)
+	}
+
+	report := registry.Check(registry.NewRegistry(), ids, registry.CheckTargets())
+
+	if checkOut == "" {
+		err = writeCheckReport(os.Stdout, report)
+	} else {
+		err = writeCheckFile(checkOut, report)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to write report: %w", err)
+	}
+
+	errs, warnings := report.Count(registry.SeverityError), report.Count(registry.SeverityWarning)
+	if errs > 0 || (checkStrict && warnings > 0) {
+		err := fmt.Errorf("check failed: %d error(s), %d warning(s)", errs, warnings)
+		if render.Structured() && checkOut == "" {
+			return render.Reported(err) // The report on stdout says why
+		}
+		return err
+	}
+	return nil
+}
+
+// writeCheckReport writes a check report in the --format format
+func writeCheckReport(w io.Writer, report *registry.CheckReport) error {
+	switch checkFormat {
+	case "json", "yaml":
+		return render.EncodeAs(w, checkFormat, report)
+	case "junit":
+		return writeCheckJUnit(w, report)
+	default:
+		printCheckReport(w, report)
+		return nil
+	}
+}
+
+// writeCheckFile writes a check report to a file, such as a JUnit report
+// for CI, reporting errors closing it
+func writeCheckFile(path string, report *registry.CheckReport) error {
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+
+	if err := writeCheckReport(f, report); err != nil {
+		_ = f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+// printCheckReport prints a check report for people
+func printCheckReport(w io.Writer, report *registry.CheckReport) {
+	fmt.Fprintf(w, "Checked %d packages on %s\n\n", len(report.Packages), strings.Join(report.Targets, ", "))
+
+	for _, f := range report.Findings {
+		icon := "❌"
+		if f.Severity == registry.SeverityWarning {
+			icon = "⚠️ "
+		}
+		if f.Target == "" {
+			fmt.Fprintf(w, "%s %s: %s\n", icon, f.PackageID, f.Message)
+		} else {
+			fmt.Fprintf(w, "%s %s on %s: %s\n", icon, f.PackageID, f.Target, f.Message)
+		}
+	}
+
+	errs, warnings := report.Count(registry.SeverityError), report.Count(registry.SeverityWarning)
+	if errs == 0 && warnings == 0 {
+		fmt.Fprintln(w, "✅ Every package can be installed on every platform")
+		return
+	}
+	fmt.Fprintf(w, "\n%d error(s), %d warning(s)\n", errs, warnings)
+}
+
+// JUnit XML elements, as read by CI systems
+type junitSuites struct {
+	XMLName  xml.Name     `xml:"testsuites"`
+	Name     string       `xml:"name,attr"`
+	Tests    int          `xml:"tests,attr"`
+	Failures int          `xml:"failures,attr"`
+	Suites   []junitSuite `xml:"testsuite"`
+}
+
+type junitSuite struct {
+	Name     string      `xml:"name,attr"`
+	Tests    int         `xml:"tests,attr"`
+	Failures int         `xml:"failures,attr"`
+	Cases    []junitCase `xml:"testcase"`
+}
+
+type junitCase struct {
+	Name      string        `xml:"name,attr"`
+	ClassName string        `xml:"classname,attr"`
+	Failure   *junitFailure `xml:"failure,omitempty"`
+	SystemOut string        `xml:"system-out,omitempty"`
+}
+
+type junitFailure struct {
+	Type    string `xml:"type,attr"`
+	Message string `xml:"message,attr"`
+}
+
+// writeCheckJUnit writes a check report as JUnit XML: a suite per target
+// with a case per package, plus a dependencies suite. Errors are failures;
+// warnings are failures only with --strict and otherwise go to system-out.
+func writeCheckJUnit(w io.Writer, report *registry.CheckReport) error {
+	suites := junitSuites{Name: "unipm check"}
+
+	addSuite := func(name string, target string) {
+		suite := junitSuite{Name: name}
+		for _, pkgID := range report.Packages {
+			tc := junitCase{Name: pkgID, ClassName: name}
+			for _, f := range report.Findings {
+				if f.PackageID != pkgID || f.Target != target {
+					continue
+				}
+				if f.Severity == registry.SeverityError || checkStrict {
+					if tc.Failure == nil {
+						tc.Failure = &junitFailure{Type: f.Kind, Message: f.Message}
+					} else {
+						tc.Failure.Message += "; " + f.Message
+					}
+				} else {
+					tc.SystemOut = strings.TrimPrefix(tc.SystemOut+"\n"+f.Severity+": "+f.Message, "\n")
+				}
+			}
+			if tc.Failure != nil {
+				suite.Failures++
+			}
+			suite.Cases = append(suite.Cases, tc)
+		}
+		suite.Tests = len(suite.Cases)
+		suites.Tests += suite.Tests
+		suites.Failures += suite.Failures
+		suites.Suites = append(suites.Suites, suite)
+	}
+
+	addSuite("dependencies", "")
+	for _, target := range report.Targets {
+		addSuite(target, target)
+	}
+
+	if _, err := io.WriteString(w, xml.Header); err != nil {
+		return err
+	}
+	enc := xml.NewEncoder(w)
+	enc.Indent("", "  ")
+	if err := enc.Encode(suites); err != nil {
+		return err
+	}
+	_, err := io.WriteString(w, "\n")
+	return err
+}
@@ -0,0 +1,87 @@
+package cmd
+
+import (
+	"fmt"
+	"io"
+	"strings"
+)
+
+// diffContext is the number of unchanged lines shown around each change
+const diffContext = 2
+
+// printDiff prints the line changes between two versions of a file
+func printDiff(w io.Writer, name string, before, after []byte) {
+	fmt.Fprintf(w, "--- %s\n+++ %s\n", name, name)
+
+	skipped := false
+	lines := diffLines(splitLines(string(before)), splitLines(string(after)))
+	for i, line := range lines {
+		if !nearChange(lines, i) {
+			skipped = true
+			continue
+		}
+		if skipped {
+			fmt.Fprintln(w, "  …")
+			skipped = false
+		}
+		fmt.Fprintln(w, line)
+	}
+	if skipped {
+		fmt.Fprintln(w, "  …")
+	}
+}
+
+// diffLines returns the lines of before and after, prefixed with "-" if
+// removed, "+" if added or " " if unchanged, using a longest common subsequence
+func diffLines(before, after []string) []string {
+	// lcs[i][j] is the common subsequence length of before[i:] and after[j:]
+	lcs := make([][]int, len(before)+1)
+	for i := range lcs {
+		lcs[i] = make([]int, len(after)+1)
+	}
+	for i := len(before) - 1; i >= 0; i-- {
+		for j := len(after) - 1; j >= 0; j-- {
+			if before[i] == after[j] {
+				lcs[i][j] = lcs[i+1][j+1] + 1
+			} else {
+				lcs[i][j] = max(lcs[i+1][j], lcs[i][j+1])
+			}
+		}
+	}
+
+	var lines []string
+	i, j := 0, 0
+	for i < len(before) || j < len(after) {
+		switch {
+		case i < len(before) && j < len(after) && before[i] == after[j]:
+			lines = append(lines, " "+before[i])
+			i++
+			j++
+		case j < len(after) && (i == len(before) || lcs[i][j+1] >= lcs[i+1][j]):
+			lines = append(lines, "+"+after[j])
+			j++
+		default:
+			lines = append(lines, "-"+before[i])
+			i++
+		}
+	}
+	return lines
+}
+
+// nearChange reports whether line i is a change or within diffContext lines of one
+func nearChange(lines []string, i int) bool {
+	for j := max(0, i-diffContext); j <= min(len(lines)-1, i+diffContext); j++ {
+		if lines[j][0] != ' ' {
+			return true
+		}
+	}
+	return false
+}
+
+// splitLines splits text into lines without the trailing newline
+func splitLines(text string) []string {
+	if text == "" {
+		return nil
+	}
+	return strings.Split(strings.TrimSuffix(text, "\n"), "\n")
+}
@@ -6,6 +6,7 @@ import (
 
 	"github.com/Litchi-group/unipm/internal/detector"
 	"github.com/Litchi-group/unipm/internal/provider"
+	"github.com/Litchi-group/unipm/internal/render"
 	"github.com/spf13/cobra"
 )
 
@@ -14,6 +15,7 @@ var doctorCmd = &cobra.Command{
 	Short: "Check system requirements and package manager availability",
 	Long: `Verifies that required package managers are installed and available
 on the current system. Provides actionable guidance if tools are missing.`,
+	Annotations: structuredOutput,
 	RunE: func(cmd *cobra.Command, args []string) error {
 		return runDoctor()
 	},
@@ -23,34 +25,55 @@ func init() {
 	rootCmd.AddCommand(doctorCmd)
 }
 
+// doctorDocument is the structured output of doctor
+type doctorDocument struct {
+	OS        render.OS        `json:"os" yaml:"os"`
+	Privilege string           `json:"privilege,omitempty" yaml:"privilege,omitempty"` // Escalation method (Linux)
+	OK        bool             `json:"ok" yaml:"ok"`                                   // A default provider is available, or none is needed
+	Providers []doctorProvider `json:"providers" yaml:"providers"`
+}
+
+// doctorProvider is a provider checked by doctor
+type doctorProvider struct {
+	Name      string `json:"name" yaml:"name"`
+	Kind      string `json:"kind" yaml:"kind"` // "default", "optional" or "plugin"
+	Available bool   `json:"available" yaml:"available"`
+	Version   string `json:"version,omitempty" yaml:"version,omitempty"` // Plugins only
+	Path      string `json:"path,omitempty" yaml:"path,omitempty"`       // Plugins only
+}
+
 func runDoctor() error {
 	// Detect OS
 	osInfo := detector.DetectOS()
 
+	if render.Structured() {
+		return printDoctorDocument(osInfo)
+	}
+
 	fmt.Println("🏥 unipm System Check")
 	fmt.Println("=" + strings.Repeat("=", 50))
 	fmt.Println()
 
 	fmt.Printf("OS: %s\n", osInfo.String())
 	fmt.Printf("Architecture: %s\n", osInfo.Arch)
+	if osInfo.IsLinux() {
+		fmt.Printf("Privilege escalation: %s\n", provider.PrivilegeMethod())
+	}
 	fmt.Println()
 
-	// Check providers based on OS
+	// Default providers are the native package managers of this OS;
+	// everything else registered for the OS is optional
 	var providers []provider.Provider
-
-	switch {
-	case osInfo.IsMacOS():
-		providers = []provider.Provider{
-			provider.NewBrewProvider(),
-		}
-	case osInfo.IsWindows():
-		providers = []provider.Provider{
-			provider.NewWinGetProvider(),
-		}
-	case osInfo.IsLinux():
-		providers = []provider.Provider{
-			provider.NewAptProvider(),
-			provider.NewSnapProvider(),
+	var optional []provider.Provider
+
+	for _, reg := range provider.RegistrationsForOS(osInfo) {
+		switch {
+		case reg.Plugin:
+			continue
+		case reg.Default:
+			providers = append(providers, reg.New())
+		default:
+			optional = append(optional, reg.New())
 		}
 	}
 
@@ -58,6 +81,10 @@ func runDoctor() error {
 	fmt.Println("-" + strings.Repeat("-", 50))
 	fmt.Println()
 
+	if len(providers) == 0 {
+		fmt.Printf("➖ No built-in package manager for %s\n", osInfo.String())
+	}
+
 	// Check each provider
 	allAvailable := true
 	missingProviders := []provider.Provider{}
@@ -74,6 +101,41 @@ func runDoctor() error {
 		}
 	}
 
+	fmt.Println()
+	fmt.Println("Optional Providers:")
+	fmt.Println("-" + strings.Repeat("-", 50))
+	fmt.Println()
+
+	for _, p := range optional {
+		if p.IsAvailable() {
+			fmt.Printf("✅ %s: available\n", p.Name())
+		} else {
+			fmt.Printf("➖ %s: not found\n", p.Name())
+		}
+	}
+
+	// External provider plugins
+	if plugins := provider.GetPlugins(); len(plugins) > 0 {
+		fmt.Println()
+		fmt.Println("Provider Plugins:")
+		fmt.Println("-" + strings.Repeat("-", 50))
+		fmt.Println()
+
+		for _, p := range plugins {
+			version, err := p.Version()
+			if err != nil {
+				version = "unknown version"
+			}
+
+			if p.IsAvailable() {
+				fmt.Printf("✅ %s (%s): available\n", p.Name(), version)
+			} else {
+				fmt.Printf("➖ %s (%s): not available\n", p.Name(), version)
+			}
+			fmt.Printf("   %s\n", p.Path())
+		}
+	}
+
 	fmt.Println()
 	fmt.Println("=" + strings.Repeat("=", 50))
 	fmt.Println()
@@ -86,8 +148,8 @@ func runDoctor() error {
 		fmt.Println("  • Run 'unipm search <package>' to find packages")
 		fmt.Println("  • Run 'unipm --help' for more commands")
 	} else {
-		// For Linux, if at least one package manager is available, it's OK
-		if osInfo.IsLinux() && availableCount > 0 {
+		// If at least one package manager is available, it's OK
+		if availableCount > 0 {
 			fmt.Println("✅ System check passed!")
 			fmt.Println()
 			fmt.Printf("You have %d/%d package managers available.\n", availableCount, len(providers))
@@ -119,3 +181,46 @@ func runDoctor() error {
 
 	return nil
 }
+
+// printDoctorDocument checks the providers of an OS and prints the result
+// as a document, failing like runDoctor when no default provider is available
+func printDoctorDocument(osInfo *detector.OSInfo) error {
+	doc := doctorDocument{OS: render.NewOS(osInfo), Providers: []doctorProvider{}}
+	if osInfo.IsLinux() {
+		doc.Privilege = provider.PrivilegeMethod()
+	}
+
+	defaults, available := 0, 0
+	for _, reg := range provider.RegistrationsForOS(osInfo) {
+		if reg.Plugin {
+			continue
+		}
+
+		p := doctorProvider{Name: reg.Name, Kind: "optional", Available: reg.New().IsAvailable()}
+		if reg.Default {
+			p.Kind = "default"
+			defaults++
+			if p.Available {
+				available++
+			}
+		}
+		doc.Providers = append(doc.Providers, p)
+	}
+
+	for _, plugin := range provider.GetPlugins() {
+		p := doctorProvider{Name: plugin.Name(), Kind: "plugin", Available: plugin.IsAvailable(), Path: plugin.Path()}
+		if version, err := plugin.Version(); err == nil {
+			p.Version = version
+		}
+		doc.Providers = append(doc.Providers, p)
+	}
+
+	doc.OK = defaults == 0 || available > 0
+	if err := render.Print(doc); err != nil {
+		return err
+	}
+	if !doc.OK {
+		return render.Reported(fmt.Errorf("missing required package managers"))
+	}
+	return nil
+}
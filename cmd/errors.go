@@ -3,8 +3,10 @@ package cmd
 import (
 	goerrors "errors"
 	"fmt"
+	"strings"
 
 	"github.com/Litchi-group/unipm/internal/errors"
+	"github.com/Litchi-group/unipm/internal/render"
 )
 
 // handleError provides user-friendly error messages based on error type
@@ -13,6 +15,11 @@ func handleError(err error) error {
 		return nil
 	}
 
+	// Structured output describes errors by type instead
+	if render.Structured() {
+		return err
+	}
+
 	// Check for specific error types
 	var notFoundErr *errors.NotFoundError
 	if goerrors.As(err, &notFoundErr) {
@@ -46,9 +53,40 @@ func handleError(err error) error {
 
 	var installErr *errors.InstallError
 	if goerrors.As(err, &installErr) {
-		return fmt.Errorf("❌ Failed to install '%s' via %s:\n\n%s", installErr.PackageID, installErr.Provider, installErr.Message)
+		if installErr.Command == "" {
+			return fmt.Errorf("❌ Failed to install '%s' via %s:\n\n%v", installErr.PackageID, installErr.Provider, installErr.Cause)
+		}
+
+		msg := fmt.Sprintf("❌ Failed to install '%s' via %s:\n\n%s", installErr.PackageID, installErr.Provider,
+			formatCommandFailure(installErr.Command, installErr.ExitCode, installErr.Output))
+		if installErr.Hint != "" {
+			msg += fmt.Sprintf("\n\n💡 %s", installErr.Hint)
+		}
+		return goerrors.New(msg)
+	}
+
+	var cmdErr *errors.CommandError
+	if goerrors.As(err, &cmdErr) {
+		return fmt.Errorf("❌ %v\n\n%s", err, formatCommandFailure(cmdErr.Command, cmdErr.ExitCode, cmdErr.Output))
 	}
 
 	// Default error
 	return err
 }
+
+// formatCommandFailure describes a failed command with the tail of its output
+func formatCommandFailure(command string, exitCode int, output string) string {
+	msg := fmt.Sprintf("Command: %s", command)
+	if exitCode >= 0 {
+		msg += fmt.Sprintf("\nExit code: %d", exitCode)
+	}
+	if output != "" {
+		msg += "\n\nOutput:\n" + indent(output, "  ")
+	}
+	return msg
+}
+
+// indent prefixes every line of s
+func indent(s, prefix string) string {
+	return prefix + strings.ReplaceAll(s, "\n", "\n"+prefix)
+}
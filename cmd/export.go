@@ -1,14 +1,16 @@
 package cmd
 
 import (
+	"context"
 	"fmt"
-	"os"
+	"io"
+	"sort"
 
-	"github.com/Litchi-group/unipm/internal/config"
 	"github.com/Litchi-group/unipm/internal/detector"
 	"github.com/Litchi-group/unipm/internal/provider"
+	"github.com/Litchi-group/unipm/internal/registry"
+	"github.com/Litchi-group/unipm/internal/render"
 	"github.com/spf13/cobra"
-	"gopkg.in/yaml.v3"
 )
 
 var exportCmd = &cobra.Command{
@@ -16,113 +18,196 @@ var exportCmd = &cobra.Command{
 	Short: "Export currently installed packages to devpack.yaml",
 	Long: `Scans the system for installed packages and exports them to a devpack.yaml file.
 
+Native package names are mapped back to registry package IDs. Only packages
+in the registry that were installed manually are exported; dependencies and
+packages missing from the registry are reported separately.
+
+An existing file is merged rather than replaced: new packages are appended to
+apps (or to the profile given with --profile), keeping comments, ordering and
+other profiles. Use --diff to preview the change and --force to overwrite.
+
 This creates a portable configuration that can be used to recreate the environment.`,
-	Args: cobra.MaximumNArgs(1),
+	Args:        cobra.MaximumNArgs(1),
+	Annotations: structuredOutput,
 	RunE: func(cmd *cobra.Command, args []string) error {
 		outputFile := "devpack.yaml"
 		if len(args) > 0 {
 			outputFile = args[0]
 		}
-		return runExport(outputFile)
+		return runExport(cmd.Context(), outputFile)
 	},
 }
 
+var (
+	exportProfile string
+	exportDiff    bool
+	exportForce   bool
+)
+
 func init() {
 	rootCmd.AddCommand(exportCmd)
+
+	exportCmd.Flags().StringVarP(&exportProfile, "profile", "p", "", "Add the packages to a profile instead of apps")
+	exportCmd.Flags().BoolVar(&exportDiff, "diff", false, "Show the changes to the file without writing it")
+	exportCmd.Flags().BoolVar(&exportForce, "force", false, "Overwrite the file instead of merging into it")
+}
+
+// maxReported bounds the per-section lists of the export report
+const maxReported = 20
+
+// exportDocument is the structured output of export
+type exportDocument struct {
+	OS           render.OS        `json:"os" yaml:"os"`
+	Providers    []exportProvider `json:"providers" yaml:"providers"`
+	Change       *devpackChange   `json:"change" yaml:"change"`             // null if no registry packages were found
+	Dependencies []string         `json:"dependencies" yaml:"dependencies"` // Installed only as dependencies, not exported
+	Unmapped     []string         `json:"unmapped" yaml:"unmapped"`         // "<provider>: <name>" of packages not in the registry
+	Skipped      []string         `json:"skipped" yaml:"skipped"`           // "<id>: <error>" of registry packages that failed to load
+}
+
+// exportProvider is a provider scanned by export
+type exportProvider struct {
+	Name   string `json:"name" yaml:"name"`
+	Found  int    `json:"found" yaml:"found"`   // Installed packages
+	Mapped int    `json:"mapped" yaml:"mapped"` // Of which in the registry
+	Error  string `json:"error,omitempty" yaml:"error,omitempty"`
 }
 
-func runExport(outputFile string) error {
+func runExport(ctx context.Context, outputFile string) error {
 	// Detect OS
 	osInfo := detector.DetectOS()
+	messages := render.Messages()
 
-	fmt.Printf("Scanning installed packages on %s...\n\n", osInfo.String())
+	fmt.Fprintf(messages, "Scanning installed packages on %s...\n\n", osInfo.String())
 
 	// Get available providers
-	providers := getAvailableProviders(osInfo)
+	providers := provider.GetAvailableProvidersForOS(osInfo)
 
 	if len(providers) == 0 {
 		return fmt.Errorf("no package managers found on this system")
 	}
 
-	// Collect installed packages
-	allPackages := make(map[string]bool)
+	// Map native package names back to registry IDs
+	index, err := registry.BuildReverseIndex(registry.NewRegistry())
+	if err != nil {
+		return handleError(err)
+	}
+
+	doc := exportDocument{OS: render.NewOS(osInfo), Providers: []exportProvider{}, Skipped: skippedPackages(index)}
+	warnSkipped(messages, doc.Skipped)
+
+	// Registry packages found, and whether any provider has them installed manually
+	explicit := make(map[string]bool)
+	unmapped := []string{}
 
 	for _, p := range providers {
-		fmt.Printf("Scanning %s packages...\n", p.Name())
+		fmt.Fprintf(messages, "Scanning %s packages...\n", p.Name())
+		scanned := exportProvider{Name: p.Name()}
 
-		packages, err := p.ListInstalled()
+		packages, err := p.ListInstalled(ctx)
 		if err != nil {
-			fmt.Printf("  Warning: failed to list %s packages: %v\n", p.Name(), err)
+			fmt.Fprintf(messages, "  Warning: failed to list %s packages: %v\n", p.Name(), err)
+			scanned.Error = err.Error()
+			doc.Providers = append(doc.Providers, scanned)
 			continue
 		}
 
-		fmt.Printf("  Found %d packages\n", len(packages))
-
 		for _, pkg := range packages {
-			allPackages[pkg] = true
+			id, ok := index.Lookup(p.Name(), pkg.Name)
+			if !ok {
+				// Unmapped dependencies are expected and not worth reporting
+				if pkg.Explicit {
+					unmapped = append(unmapped, p.Name()+": "+pkg.Name)
+				}
+				continue
+			}
+
+			scanned.Mapped++
+			explicit[id] = explicit[id] || pkg.Explicit
 		}
-	}
 
-	if len(allPackages) == 0 {
-		fmt.Println("\nNo packages found.")
-		return nil
+		scanned.Found = len(packages)
+		doc.Providers = append(doc.Providers, scanned)
+		fmt.Fprintf(messages, "  Found %d packages, %d in the registry\n", scanned.Found, scanned.Mapped)
 	}
 
-	// Convert to sorted list
-	var packageList []string
-	for pkg := range allPackages {
-		packageList = append(packageList, pkg)
+	// Packages installed only as dependencies of others are left out
+	packageList, dependencies := []string{}, []string{}
+	for id, manual := range explicit {
+		if manual {
+			packageList = append(packageList, id)
+		} else {
+			dependencies = append(dependencies, id)
+		}
 	}
-
-	// Create devpack
-	devpack := config.DevPack{
-		Apps: packageList,
+	sort.Strings(packageList)
+	sort.Strings(dependencies)
+	sort.Strings(unmapped)
+	doc.Dependencies, doc.Unmapped = dependencies, unmapped
+
+	if len(packageList) > 0 {
+		if doc.Change, err = mergeDevpack(outputFile, exportProfile, packageList, exportDiff, exportForce); err != nil {
+			return err
+		}
 	}
 
-	// Marshal to YAML
-	data, err := yaml.Marshal(&devpack)
-	if err != nil {
-		return fmt.Errorf("failed to generate YAML: %w", err)
+	if render.Structured() {
+		return render.Print(doc)
 	}
 
-	// Write to file
-	if err := os.WriteFile(outputFile, data, 0644); err != nil {
-		return fmt.Errorf("failed to write file: %w", err)
+	if doc.Change == nil {
+		fmt.Println("\nNo installed packages found in the registry.")
+	} else {
+		fmt.Println()
+		printDevpackChange(doc.Change)
 	}
-
-	fmt.Printf("\n✅ Exported %d packages to %s\n", len(packageList), outputFile)
+	printExportReport(dependencies, unmapped, doc.Skipped)
 
 	return nil
 }
 
-func getAvailableProviders(osInfo *detector.OSInfo) []provider.Provider {
-	var providers []provider.Provider
+// skippedPackages describes the registry packages a reverse index left out
+func skippedPackages(index *registry.ReverseIndex) []string {
+	skipped := []string{}
+	for _, s := range index.Skipped {
+		skipped = append(skipped, fmt.Sprintf("%s: %v", s.ID, s.Err))
+	}
+	return skipped
+}
 
-	if osInfo.IsMacOS() {
-		p := provider.NewBrewProvider()
-		if p.IsAvailable() {
-			providers = append(providers, p)
-		}
+// warnSkipped warns that registry packages failed to load, so installed
+// packages they map cannot be recognized
+func warnSkipped(w io.Writer, skipped []string) {
+	if len(skipped) > 0 {
+		fmt.Fprintf(w, "Warning: %d registry packages failed to load and are not recognized\n\n", len(skipped))
 	}
+}
 
-	if osInfo.IsWindows() {
-		p := provider.NewWinGetProvider()
-		if p.IsAvailable() {
-			providers = append(providers, p)
-		}
+// printExportReport lists the installed packages that were not exported
+func printExportReport(dependencies, unmapped, skipped []string) {
+	if len(dependencies) > 0 {
+		fmt.Printf("\nInstalled only as dependencies, not exported (%d):\n", len(dependencies))
+		printReported(dependencies)
 	}
 
-	if osInfo.IsLinux() {
-		apt := provider.NewAptProvider()
-		if apt.IsAvailable() {
-			providers = append(providers, apt)
-		}
+	if len(unmapped) > 0 {
+		fmt.Printf("\nNot in the registry, not exported (%d):\n", len(unmapped))
+		printReported(unmapped)
+	}
 
-		snap := provider.NewSnapProvider()
-		if snap.IsAvailable() {
-			providers = append(providers, snap)
-		}
+	if len(skipped) > 0 {
+		fmt.Printf("\nRegistry packages that failed to load (%d):\n", len(skipped))
+		printReported(skipped)
 	}
+}
 
-	return providers
+// printReported prints up to maxReported items of a report section
+func printReported(items []string) {
+	for i, item := range items {
+		if i == maxReported {
+			fmt.Printf("  … and %d more\n", len(items)-maxReported)
+			break
+		}
+		fmt.Printf("  %s\n", item)
+	}
 }
@@ -0,0 +1,129 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"runtime"
+	"strings"
+
+	"github.com/Litchi-group/unipm/internal/detector"
+	"github.com/Litchi-group/unipm/internal/generate"
+	"github.com/Litchi-group/unipm/internal/planner"
+	"github.com/Litchi-group/unipm/internal/registry"
+	"github.com/spf13/cobra"
+)
+
+var generateCmd = &cobra.Command{
+	Use:   "generate <format>",
+	Short: "Generate a Brewfile, script or Dockerfile from devpack.yaml",
+	Long: `Plans devpack.yaml for a target system and writes the plan in another
+tool's format, for machines and images that don't run unipm.
+
+Formats:
+  brewfile    Homebrew Brewfile for 'brew bundle' (targets macos)
+  sh          bash script that skips installed packages (targets this OS)
+  dockerfile  Dockerfile installing apt packages in grouped layers (targets linux)
+  powershell  PowerShell script using winget (targets windows)
+
+The plan is made without checking this machine, so --os, --distro and
+--arch can describe any system. Packages the format cannot install are
+listed as comments.`,
+	Example: `  unipm generate brewfile -o Brewfile
+  unipm generate sh --os linux --distro debian > setup.sh
+  unipm generate dockerfile --base ubuntu:24.04 -o Dockerfile`,
+	Args:      cobra.ExactArgs(1),
+	ValidArgs: generate.Formats,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return runGenerate(cmd.Context(), args[0])
+	},
+}
+
+var (
+	generateOS      string
+	generateDistro  string
+	generateArch    string
+	generateProfile string
+	generateOut     string
+	generateBase    string
+)
+
+func init() {
+	rootCmd.AddCommand(generateCmd)
+
+	generateCmd.Flags().StringVar(&generateOS, "os", "", "Target OS: macos, linux or windows (default: depends on the format)")
+	generateCmd.Flags().StringVar(&generateDistro, "distro", "", "Target Linux distribution (default: ubuntu)")
+	generateCmd.Flags().StringVar(&generateArch, "arch", "", "Target architecture (default: this machine's)")
+	generateCmd.Flags().StringVarP(&generateProfile, "profile", "p", "", "Use a specific profile from devpack.yaml")
+	generateCmd.Flags().StringVarP(&generateOut, "out", "o", "", "File to write (default: standard output)")
+	generateCmd.Flags().StringVar(&generateBase, "base", "", "Base image for dockerfile (default: from --distro)")
+}
+
+func runGenerate(ctx context.Context, format string) error {
+	format = strings.ToLower(format)
+	if err := generate.CheckFormat(format); err != nil {
+		return err
+	}
+
+	devpack, err := loadDevpackWithPrompt()
+	if err != nil {
+		return handleError(err)
+	}
+	if devpack == nil {
+		return nil // File not found, already printed help message
+	}
+
+	apps := devpack.GetApps(generateProfile)
+	if len(apps) == 0 {
+		if generateProfile != "" {
+			return fmt.Errorf("profile '%s' not found or empty in devpack.yaml", generateProfile)
+		}
+		return fmt.Errorf("no packages specified in devpack.yaml")
+	}
+
+	platform := generateOS
+	if platform == "" {
+		platform = generate.DefaultOS(format)
+	}
+	if platform == "" {
+		platform = runtime.GOOS
+		if platform == "windows" {
+			platform = "linux"
+		}
+	}
+
+	osInfo, err := detector.TargetOS(platform, generateDistro, generateArch)
+	if err != nil {
+		return err
+	}
+
+	plnr := planner.NewPlanner(registry.NewRegistry(), osInfo)
+	plnr.SetOverrides(devpack.Overrides)
+	plnr.SetOffline(true)
+
+	plan, err := plnr.CreatePlan(ctx, apps)
+	if err != nil {
+		return handleError(err)
+	}
+
+	out, err := generate.Render(format, plan, generate.Options{BaseImage: generateBase})
+	if err != nil {
+		return err
+	}
+
+	if generateOut == "" {
+		fmt.Print(out)
+		return nil
+	}
+
+	mode := os.FileMode(0644)
+	if format == generate.FormatShell {
+		mode = 0755
+	}
+	if err := os.WriteFile(generateOut, []byte(out), mode); err != nil {
+		return fmt.Errorf("failed to write %s: %w", generateOut, err)
+	}
+	fmt.Fprintf(os.Stderr, "Wrote %d packages to %s (%s for %s)\n", len(plan.Tasks), generateOut, format, osInfo.String())
+
+	return nil
+}
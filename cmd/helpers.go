@@ -1,11 +1,14 @@
 package cmd
 
 import (
+	"bytes"
 	"fmt"
 	"os"
 	"strings"
 
 	"github.com/Litchi-group/unipm/internal/config"
+	"github.com/Litchi-group/unipm/internal/errors"
+	"github.com/Litchi-group/unipm/internal/render"
 )
 
 // loadDevpackWithPrompt loads devpack.yaml and shows a helpful message if not found
@@ -14,6 +17,9 @@ func loadDevpackWithPrompt() (*config.DevPack, error) {
 	if err != nil {
 		// Check if file doesn't exist
 		if os.IsNotExist(err) || strings.Contains(err.Error(), "no such file or directory") {
+			if render.Structured() {
+				return nil, errors.NewConfigError("devpack.yaml", "file not found; run 'unipm init' to create one", err)
+			}
 			fmt.Println("📦 No devpack.yaml found in current directory")
 			fmt.Println("")
 			fmt.Println("To get started:")
@@ -26,3 +32,84 @@ func loadDevpackWithPrompt() (*config.DevPack, error) {
 	}
 	return devpack, nil
 }
+
+// Outcomes of mergeDevpack
+const (
+	devpackUpToDate = "up_to_date" // Every package was already listed
+	devpackWritten  = "written"    // A new file was written, or an existing one overwritten
+	devpackMerged   = "merged"     // Packages were added to an existing file
+	devpackDiff     = "diff"       // The change was computed but not written
+)
+
+// devpackChange describes a change made by mergeDevpack
+type devpackChange struct {
+	File    string   `json:"file" yaml:"file"`
+	Profile string   `json:"profile,omitempty" yaml:"profile,omitempty"` // Empty for apps
+	Status  string   `json:"status" yaml:"status"`
+	Added   []string `json:"added" yaml:"added"`
+	Diff    string   `json:"diff,omitempty" yaml:"diff,omitempty"` // With --diff
+}
+
+// mergeDevpack adds package IDs to a devpack file, or to one of its profiles,
+// keeping its existing content unless force is set. With diff, the change is
+// computed instead of written.
+func mergeDevpack(path, profile string, ids []string, diff, force bool) (*devpackChange, error) {
+	existing, err := os.ReadFile(path)
+	if err != nil && !os.IsNotExist(err) {
+		return nil, fmt.Errorf("failed to read %s: %w", path, err)
+	}
+	base := existing
+	if force {
+		base = nil
+	}
+
+	data, added, err := config.MergeApps(base, profile, ids)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+
+	change := &devpackChange{File: path, Profile: profile, Added: added}
+	if change.Added == nil {
+		change.Added = []string{}
+	}
+
+	switch {
+	case len(added) == 0 && base != nil, bytes.Equal(data, existing):
+		change.Status = devpackUpToDate
+	case diff:
+		var buf bytes.Buffer
+		printDiff(&buf, path, existing, data)
+		change.Status = devpackDiff
+		change.Diff = buf.String()
+	default:
+		if err := os.WriteFile(path, data, 0644); err != nil {
+			return nil, fmt.Errorf("failed to write file: %w", err)
+		}
+
+		change.Status = devpackMerged
+		if base == nil {
+			change.Status = devpackWritten
+		}
+	}
+
+	return change, nil
+}
+
+// printDevpackChange prints a change made by mergeDevpack
+func printDevpackChange(change *devpackChange) {
+	target := "apps"
+	if change.Profile != "" {
+		target = "profile " + change.Profile
+	}
+
+	switch change.Status {
+	case devpackUpToDate:
+		fmt.Printf("✅ %s is up to date\n", change.File)
+	case devpackDiff:
+		fmt.Print(change.Diff)
+	case devpackWritten:
+		fmt.Printf("✅ Wrote %d packages to %s (%s)\n", len(change.Added), change.File, target)
+	default:
+		fmt.Printf("✅ Added %d new packages to %s (%s)\n", len(change.Added), change.File, target)
+	}
+}
@@ -1,27 +1,123 @@
 package cmd
 
 import (
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/Litchi-group/unipm/internal/manifest"
+	"github.com/Litchi-group/unipm/internal/registry"
 	"github.com/spf13/cobra"
 )
 
 var importCmd = &cobra.Command{
-	Use:   "import [file]",
-	Short: "Import and install packages from devpack.yaml",
-	Long: `Imports a devpack.yaml file and installs the specified packages.
+	Use:   "import <file>",
+	Short: "Convert another package manager's manifest to devpack.yaml",
+	Long: `Reads a package list from another package manager and adds its packages to
+devpack.yaml, mapping native names to registry package IDs.
+
+Supported formats (detected from the file, or set with --format):
+  brewfile  Homebrew Brewfile, e.g. from 'brew bundle dump'
+  winget    JSON written by 'winget export'
+  apt       apt package names, one per line ('apt-mark showmanual',
+            'apt list --installed' and 'dpkg --get-selections' output also work)
+  snap      'snap list' output
 
-This is an alias for 'unipm apply' and accepts the same flags.`,
+An existing devpack.yaml is merged rather than replaced, as with export.`,
+	Example: `  unipm import Brewfile
+  unipm import winget.json --profile windows
+  apt-mark showmanual > apt.txt && unipm import apt.txt --diff`,
+	Args: cobra.ExactArgs(1),
 	RunE: func(cmd *cobra.Command, args []string) error {
-		// Import is just an alias for apply
-		// In the future, we could add file path support
-		return runApply()
+		return runImport(args[0])
 	},
 }
 
+var (
+	importFormat  string
+	importOut     string
+	importProfile string
+	importDiff    bool
+	importForce   bool
+)
+
 func init() {
 	rootCmd.AddCommand(importCmd)
 
-	// Share flags with apply
-	importCmd.Flags().BoolVar(&dryRun, "dry-run", false, "Show what would be done without executing")
-	importCmd.Flags().BoolVarP(&yes, "yes", "y", false, "Skip confirmation prompt")
-	importCmd.Flags().StringVarP(&profile, "profile", "p", "", "Use a specific profile from devpack.yaml")
+	importCmd.Flags().StringVar(&importFormat, "format", "", "Manifest format: "+strings.Join(manifest.Formats, ", ")+" (default: detected)")
+	importCmd.Flags().StringVarP(&importOut, "out", "o", "devpack.yaml", "devpack file to write or merge into")
+	importCmd.Flags().StringVarP(&importProfile, "profile", "p", "", "Add the packages to a profile instead of apps")
+	importCmd.Flags().BoolVar(&importDiff, "diff", false, "Show the changes to the file without writing it")
+	importCmd.Flags().BoolVar(&importForce, "force", false, "Overwrite the file instead of merging into it")
+}
+
+func runImport(path string) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("failed to read %s: %w", path, err)
+	}
+
+	format := importFormat
+	if format == "" {
+		format = manifest.DetectFormat(path, data)
+	}
+
+	entries, skipped, err := manifest.Parse(format, data)
+	if err != nil {
+		return fmt.Errorf("failed to parse %s: %w", path, err)
+	}
+
+	fmt.Printf("Read %d packages from %s (%s)\n", len(entries), path, format)
+
+	// Map native package names to registry IDs
+	index, err := registry.BuildReverseIndex(registry.NewRegistry())
+	if err != nil {
+		return err
+	}
+	failed := skippedPackages(index)
+	warnSkipped(os.Stdout, failed)
+
+	seen := make(map[string]bool)
+	var packageList []string
+	var unmapped []string
+	for _, entry := range entries {
+		id, ok := index.Lookup(entry.Provider, entry.Name)
+		if !ok {
+			unmapped = append(unmapped, entry.Provider+": "+entry.Name)
+			continue
+		}
+		if !seen[id] {
+			seen[id] = true
+			packageList = append(packageList, id)
+		}
+	}
+	sort.Strings(packageList)
+
+	fmt.Println()
+	if len(packageList) == 0 {
+		fmt.Println("No packages found in the registry.")
+	} else {
+		change, err := mergeDevpack(importOut, importProfile, packageList, importDiff, importForce)
+		if err != nil {
+			return err
+		}
+		printDevpackChange(change)
+	}
+
+	if len(unmapped) > 0 {
+		sort.Strings(unmapped)
+		fmt.Printf("\nNot in the registry, not imported (%d):\n", len(unmapped))
+		printReported(unmapped)
+	}
+	if len(skipped) > 0 {
+		fmt.Printf("\nUnsupported entries, not imported (%d):\n", len(skipped))
+		printReported(skipped)
+	}
+	if len(failed) > 0 {
+		fmt.Printf("\nRegistry packages that failed to load (%d):\n", len(failed))
+		printReported(failed)
+	}
+
+	return nil
 }
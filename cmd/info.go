@@ -6,14 +6,16 @@ import (
 
 	"github.com/Litchi-group/unipm/internal/detector"
 	"github.com/Litchi-group/unipm/internal/registry"
+	"github.com/Litchi-group/unipm/internal/render"
 	"github.com/spf13/cobra"
 )
 
 var infoCmd = &cobra.Command{
-	Use:   "info <package>",
-	Short: "Show detailed information about a package",
-	Long:  `Displays detailed information about a package including available providers and dependencies.`,
-	Args:  cobra.ExactArgs(1),
+	Use:         "info <package>",
+	Short:       "Show detailed information about a package",
+	Long:        `Displays detailed information about a package including available providers and dependencies.`,
+	Args:        cobra.ExactArgs(1),
+	Annotations: structuredOutput,
 	RunE: func(cmd *cobra.Command, args []string) error {
 		return runInfo(args[0])
 	},
@@ -23,6 +25,18 @@ func init() {
 	rootCmd.AddCommand(infoCmd)
 }
 
+// infoDocument is the structured output of info
+type infoDocument struct {
+	ID           string                                `json:"id" yaml:"id"`
+	Name         string                                `json:"name" yaml:"name"`
+	Homepage     string                                `json:"homepage,omitempty" yaml:"homepage,omitempty"`
+	Dependencies []string                              `json:"dependencies" yaml:"dependencies"`
+	Verified     bool                                  `json:"verified" yaml:"verified"`
+	Digest       string                                `json:"digest" yaml:"digest"` // Checksum to pin in devpack.yaml
+	Providers    map[string][]registry.ProviderMapping `json:"providers" yaml:"providers"`
+	Selected     *registry.ProviderMapping             `json:"selected" yaml:"selected"` // Mapping used on this system; null if none
+}
+
 func runInfo(packageID string) error {
 	reg := registry.NewRegistry()
 
@@ -32,6 +46,25 @@ func runInfo(packageID string) error {
 		return handleError(err)
 	}
 
+	if render.Structured() {
+		doc := infoDocument{
+			ID:           pkg.ID,
+			Name:         pkg.Name,
+			Homepage:     pkg.Homepage,
+			Dependencies: pkg.Dependencies,
+			Verified:     pkg.Verified,
+			Digest:       pkg.Digest,
+			Providers:    pkg.Providers,
+		}
+		if doc.Dependencies == nil {
+			doc.Dependencies = []string{}
+		}
+		if mapping, ok := registry.SelectMapping(pkg, detector.DetectOS()); ok {
+			doc.Selected = &mapping
+		}
+		return render.Print(doc)
+	}
+
 	// Display information
 	fmt.Printf("Package: %s\n", pkg.Name)
 	fmt.Printf("ID: %s\n", pkg.ID)
@@ -48,6 +81,14 @@ func runInfo(packageID string) error {
 		}
 	}
 
+	if pkg.Verified {
+		fmt.Printf("Checksum: %s (matches the definition's checksum)\n", pkg.Digest)
+	} else {
+		fmt.Printf("Checksum: %s (the definition has no checksum)\n", pkg.Digest)
+	}
+	fmt.Printf("  After reviewing this definition, pin it in devpack.yaml:\n")
+	fmt.Printf("    overrides:\n      %s:\n        checksum: %s\n", pkg.ID, pkg.Digest)
+
 	// Providers
 	fmt.Printf("\nProviders:\n")
 
@@ -58,8 +99,16 @@ func runInfo(packageID string) error {
 		for _, p := range providers {
 			fmt.Printf("    - %s: %s\n", p.Type, p.Name)
 
+			// Show script contents so they can be reviewed before running
+			if p.Script != nil {
+				printScript("install", p.Script.Install)
+				printScript("remove", p.Script.Remove)
+				printScript("check", p.Script.Check)
+				printScript("version", p.Script.Version)
+			}
+
 			// Highlight if this is the current OS
-			if strings.Contains(osInfo.String(), osKey) {
+			if osKey == registry.AllOSKey || strings.Contains(osInfo.String(), osKey) {
 				fmt.Printf("      (available on your system)\n")
 			}
 		}
@@ -67,3 +116,15 @@ func runInfo(packageID string) error {
 
 	return nil
 }
+
+// printScript prints an indented script snippet
+func printScript(label, script string) {
+	if script == "" {
+		return
+	}
+
+	fmt.Printf("      %s:\n", label)
+	for _, line := range strings.Split(strings.TrimRight(script, "\n"), "\n") {
+		fmt.Printf("        %s\n", line)
+	}
+}
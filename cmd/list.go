@@ -3,13 +3,16 @@ package cmd
 import (
 	"fmt"
 
+	"github.com/Litchi-group/unipm/internal/render"
+
 	"github.com/spf13/cobra"
 )
 
 var listCmd = &cobra.Command{
-	Use:   "list",
-	Short: "List packages in devpack.yaml",
-	Long:  `Shows all packages defined in your devpack.yaml file.`,
+	Use:         "list",
+	Short:       "List packages in devpack.yaml",
+	Long:        `Shows all packages defined in your devpack.yaml file.`,
+	Annotations: structuredOutput,
 	RunE: func(cmd *cobra.Command, args []string) error {
 		return runList()
 	},
@@ -19,6 +22,12 @@ func init() {
 	rootCmd.AddCommand(listCmd)
 }
 
+// listDocument is the structured output of list
+type listDocument struct {
+	Apps     []string            `json:"apps" yaml:"apps"`
+	Profiles map[string][]string `json:"profiles" yaml:"profiles"`
+}
+
 func runList() error {
 	// Load devpack.yaml
 	devpack, err := loadDevpackWithPrompt()
@@ -29,6 +38,17 @@ func runList() error {
 		return nil // File not found, already printed help message
 	}
 
+	if render.Structured() {
+		doc := listDocument{Apps: devpack.Apps, Profiles: devpack.Profiles}
+		if doc.Apps == nil {
+			doc.Apps = []string{}
+		}
+		if doc.Profiles == nil {
+			doc.Profiles = map[string][]string{}
+		}
+		return render.Print(doc)
+	}
+
 	if len(devpack.Apps) == 0 {
 		fmt.Println("No packages defined in devpack.yaml")
 		return nil
@@ -0,0 +1,46 @@
+package cmd
+
+import (
+	"os"
+
+	"github.com/Litchi-group/unipm/internal/planner"
+	"github.com/Litchi-group/unipm/internal/render"
+)
+
+// annotationStructured marks commands that can print their results as JSON
+// or YAML with --output
+const annotationStructured = "unipm/structured-output"
+
+// structuredOutput is the Annotations of commands supporting --output
+var structuredOutput = map[string]string{annotationStructured: "true"}
+
+// setReporter sets how a plan reports progress: printed as text, or
+// recorded for a structured result, with command output sent to stderr
+func setReporter(plan *planner.Plan, action string, dryRun bool) *render.Recorder {
+	if !render.Structured() {
+		plan.Reporter = render.NewTextReporter(os.Stdout)
+		return nil
+	}
+
+	recorder := render.NewRecorder(plan, action, dryRun)
+	plan.Reporter = recorder
+	plan.Out = os.Stderr
+	return recorder
+}
+
+// finishRun prints the structured result of a run recorded by setReporter,
+// if any, and returns the error the run ended with
+func finishRun(recorder *render.Recorder, plan *planner.Plan, err error) error {
+	if recorder == nil {
+		return err
+	}
+
+	doc := recorder.Document(err)
+	if f, ok := plan.Log.(*os.File); ok {
+		doc.Log = f.Name()
+	}
+	if printErr := render.Print(doc); printErr != nil {
+		return printErr
+	}
+	return render.Reported(err)
+}
@@ -1,16 +1,27 @@
 package cmd
 
 import (
+	"context"
 	"fmt"
+	"os"
+	"time"
 
 	"github.com/Litchi-group/unipm/internal/detector"
 	"github.com/Litchi-group/unipm/internal/planner"
+	"github.com/Litchi-group/unipm/internal/provider"
 	"github.com/Litchi-group/unipm/internal/registry"
+	"github.com/Litchi-group/unipm/internal/render"
 	"github.com/spf13/cobra"
 )
 
 var (
 	planProfile string
+	planOS      string
+	planDistro  string
+	planArch    string
+	planOut     string
+	refresh     bool
+	noRefresh   bool
 )
 
 var planCmd = &cobra.Command{
@@ -19,18 +30,54 @@ var planCmd = &cobra.Command{
 	Long: `Reads devpack.yaml and generates a detailed installation plan
 showing what would be installed on the current OS.
 
-This is a non-destructive operation that only displays the plan.`,
+This is a non-destructive operation that only displays the plan.
+
+With --os, --distro or --arch the plan is made for another system, such as
+the Windows plan from a Linux machine. Nothing is checked on this machine,
+so provider availability and installed state are unknown.
+
+With --out the plan is also saved as JSON, to be reviewed and then run
+exactly as planned with 'unipm apply <planfile>'.`,
+	Example: `  unipm plan
+  unipm plan --os windows
+  unipm plan --os linux --distro fedora --arch arm64
+  unipm plan --os windows --output json
+  unipm plan --out plan.json && unipm apply plan.json`,
+	Annotations: structuredOutput,
 	RunE: func(cmd *cobra.Command, args []string) error {
-		return runPlan()
+		return runPlan(cmd.Context())
 	},
 }
 
 func init() {
 	rootCmd.AddCommand(planCmd)
 	planCmd.Flags().StringVarP(&planProfile, "profile", "p", "", "Use a specific profile from devpack.yaml")
+	planCmd.Flags().StringVar(&planOS, "os", "", "Plan for another OS: macos, linux or windows")
+	planCmd.Flags().StringVar(&planDistro, "distro", "", "Plan for a Linux distribution (e.g., ubuntu, fedora)")
+	planCmd.Flags().StringVar(&planArch, "arch", "", "Plan for an architecture (e.g., amd64, arm64)")
+	planCmd.Flags().StringVarP(&planOut, "out", "o", "", "Save the plan to a file for 'unipm apply <planfile>'")
+	addRefreshFlags(planCmd)
 }
 
-func runPlan() error {
+// addRefreshFlags adds --refresh and --no-refresh to a command
+func addRefreshFlags(cmd *cobra.Command) {
+	cmd.Flags().BoolVar(&refresh, "refresh", false, "Refresh package indexes (e.g., apt-get update) before installing")
+	cmd.Flags().BoolVar(&noRefresh, "no-refresh", false, "Never refresh package indexes, even if they are stale")
+	cmd.MarkFlagsMutuallyExclusive("refresh", "no-refresh")
+}
+
+// setRefreshPolicy sets the package index refresh policy from the flags
+func setRefreshPolicy() {
+	policy := provider.RefreshAuto
+	if refresh {
+		policy = provider.RefreshAlways
+	} else if noRefresh {
+		policy = provider.RefreshNever
+	}
+	_ = provider.SetRefreshPolicy(policy)
+}
+
+func runPlan(ctx context.Context) error {
 	// Load devpack.yaml
 	devpack, err := loadDevpackWithPrompt()
 	if err != nil {
@@ -42,7 +89,7 @@ func runPlan() error {
 
 	apps := devpack.GetApps(planProfile)
 
-	if len(apps) == 0 {
+	if len(apps) == 0 && !render.Structured() {
 		if planProfile != "" {
 			fmt.Printf("Profile '%s' not found or empty in devpack.yaml\n", planProfile)
 		} else {
@@ -51,25 +98,52 @@ func runPlan() error {
 		return nil
 	}
 
-	if planProfile != "" {
+	if planProfile != "" && !render.Structured() {
 		fmt.Printf("Using profile: %s\n\n", planProfile)
 	}
 
-	// Detect OS
+	// Detect OS, or describe the target system
 	osInfo := detector.DetectOS()
+	offline := planOS != "" || planDistro != "" || planArch != ""
+	if offline {
+		platform := planOS
+		if platform == "" {
+			platform = osInfo.Platform
+		}
+		if osInfo, err = detector.TargetOS(platform, planDistro, planArch); err != nil {
+			return err
+		}
+	}
+
+	setRefreshPolicy()
 
 	// Create planner
 	reg := registry.NewRegistry()
 	plnr := planner.NewPlanner(reg, osInfo)
+	plnr.SetOverrides(devpack.Overrides)
+	plnr.SetOffline(offline)
 
 	// Create plan
-	plan, err := plnr.CreatePlan(apps)
+	plan, err := plnr.CreatePlan(ctx, apps)
 	if err != nil {
 		return handleError(err)
 	}
 
-	// Print plan
-	plan.Print()
+	if planOut != "" {
+		if err := planner.NewSavedPlan(plan, planProfile, time.Now()).Write(planOut); err != nil {
+			return err
+		}
+	}
+
+	if render.Structured() {
+		return render.Print(render.NewPlanDocument(plan))
+	}
+
+	render.PrintPlan(os.Stdout, plan)
+
+	if planOut != "" {
+		fmt.Printf("\nSaved plan to %s. Review it, then run 'unipm apply %s'.\n", planOut, planOut)
+	}
 
 	return nil
 }
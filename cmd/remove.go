@@ -2,6 +2,7 @@ package cmd
 
 import (
 	"bufio"
+	"context"
 	"fmt"
 	"os"
 	"strings"
@@ -9,39 +10,57 @@ import (
 	"github.com/Litchi-group/unipm/internal/config"
 	"github.com/Litchi-group/unipm/internal/detector"
 	"github.com/Litchi-group/unipm/internal/planner"
+	"github.com/Litchi-group/unipm/internal/provider"
 	"github.com/Litchi-group/unipm/internal/registry"
+	"github.com/Litchi-group/unipm/internal/render"
 	"github.com/spf13/cobra"
 )
 
-var removeYes bool
+var (
+	removeYes   bool
+	removeRepos bool
+)
 
 var removeCmd = &cobra.Command{
 	Use:   "remove <package...>",
 	Short: "Remove packages",
 	Long: `Removes specified packages using the native package manager.
-Prompts for confirmation before removing unless --yes is specified.`,
-	Args: cobra.MinimumNArgs(1),
+Prompts for confirmation before removing unless --yes is specified;
+--yes is required with --output json or yaml.`,
+	Args:        cobra.MinimumNArgs(1),
+	Annotations: structuredOutput,
 	RunE: func(cmd *cobra.Command, args []string) error {
-		return runRemove(args)
+		return runRemove(cmd.Context(), args)
 	},
 }
 
 func init() {
 	rootCmd.AddCommand(removeCmd)
 	removeCmd.Flags().BoolVarP(&removeYes, "yes", "y", false, "Skip confirmation prompt")
+	removeCmd.Flags().BoolVar(&removeRepos, "remove-repos", false, "Also remove third-party apt repositories added for these packages")
 }
 
-func runRemove(packageIDs []string) error {
-	// Load devpack.yaml to verify packages (optional)
+func runRemove(ctx context.Context, packageIDs []string) error {
+	if render.Structured() && !removeYes {
+		return fmt.Errorf("--output %s cannot prompt for confirmation; use --yes", render.Format())
+	}
+
+	provider.SetCleanupRepositories(removeRepos)
+	messages := render.Messages()
+
+	// Load devpack.yaml to verify packages and apply its overrides (optional)
+	var overrides map[string]config.Override
 	devpack, err := config.Load("devpack.yaml")
 	if err != nil {
 		// devpack.yaml is optional for remove command
 		if !os.IsNotExist(err) && !strings.Contains(err.Error(), "no such file or directory") {
 			return fmt.Errorf("failed to load devpack.yaml: %w", err)
 		}
-		fmt.Println("Note: No devpack.yaml found (not required for remove)")
-		fmt.Println()
+		fmt.Fprintln(messages, "Note: No devpack.yaml found (not required for remove)")
+		fmt.Fprintln(messages)
 	} else {
+		overrides = devpack.Overrides
+
 		// Check if packages are in devpack.yaml
 		for _, pkg := range packageIDs {
 			found := false
@@ -52,10 +71,10 @@ func runRemove(packageIDs []string) error {
 				}
 			}
 			if !found {
-				fmt.Printf("Warning: %s is not in devpack.yaml\n", pkg)
+				fmt.Fprintf(messages, "Warning: %s is not in devpack.yaml\n", pkg)
 			}
 		}
-		fmt.Println()
+		fmt.Fprintln(messages)
 	}
 
 	// Detect OS
@@ -64,22 +83,28 @@ func runRemove(packageIDs []string) error {
 	// Create planner
 	reg := registry.NewRegistry()
 	plnr := planner.NewPlanner(reg, osInfo)
+	plnr.SetOverrides(overrides)
 
 	// Create plan
-	plan, err := plnr.CreatePlan(packageIDs)
+	plan, err := plnr.CreatePlan(ctx, packageIDs)
 	if err != nil {
-		return err
+		return handleError(err)
+	}
+
+	recorder := setReporter(plan, planner.ActionRemove, false)
+	if recorder != nil {
+		return finishRun(recorder, plan, removePlan(ctx, plan))
 	}
 
 	fmt.Printf("Packages to remove:\n\n")
 
 	toRemove := 0
 	for _, task := range plan.Tasks {
-		if task.Installed {
-			fmt.Printf("  %s → %s\n", task.PackageID, task.Provider.RemoveCommand(*task.Spec))
+		if task.Present() {
+			fmt.Printf("  %s → %s\n", task.Label(), task.Provider.RemoveCommand(*task.Spec))
 			toRemove++
 		} else {
-			fmt.Printf("  %s (not installed)\n", task.PackageID)
+			fmt.Printf("  %s (not installed)\n", task.Label())
 		}
 	}
 
@@ -109,30 +134,54 @@ func runRemove(packageIDs []string) error {
 		fmt.Println()
 	}
 
-	// Execute removals
-	removedCount := 0
-	notInstalledCount := 0
+	return removePlan(ctx, plan)
+}
 
-	for _, task := range plan.Tasks {
-		if !task.Installed {
-			fmt.Printf("Removing %s...\n", task.PackageID)
-			fmt.Printf("  ⊙ Not installed\n")
-			notInstalledCount++
-			continue
+// removePlan checks privileges and removes the installed packages of a
+// plan, saving a run log
+func removePlan(ctx context.Context, plan *planner.Plan) error {
+	installed := func(t *planner.InstallTask) bool { return t.Present() }
+	if err := plan.CheckPrivilege(installed); err != nil {
+		return handleError(err)
+	}
+
+	plan.TaskTimeout = taskTimeout
+
+	finish := attachRunLog(plan, "remove")
+	return finish(executeRemoval(ctx, plan))
+}
+
+// executeRemoval removes the installed packages of a plan
+func executeRemoval(ctx context.Context, plan *planner.Plan) error {
+	report := plan.Report()
+
+	for i, task := range plan.Tasks {
+		if ctx.Err() != nil {
+			report.Interrupted(plan, i, false)
+			return fmt.Errorf("stopped before removing %s: %w", task.Label(), ctx.Err())
 		}
 
-		fmt.Printf("Removing %s...\n", task.PackageID)
+		report.TaskStarted(task, planner.ActionRemove)
 
-		if err := task.Provider.Remove(*task.Spec); err != nil {
-			return fmt.Errorf("failed to remove %s: %w", task.PackageID, err)
+		if !task.Present() {
+			report.TaskFinished(task, planner.ActionRemove, planner.StatusSkipped, nil)
+			continue
 		}
 
-		fmt.Printf("  ✓ Removed\n")
-		removedCount++
+		if err := plan.RunTask(ctx, task, task.Provider.Remove); err != nil {
+			if ctx.Err() != nil {
+				report.Interrupted(plan, i, true)
+				return fmt.Errorf("interrupted while removing %s: %w", task.Label(), ctx.Err())
+			}
+			removeErr := fmt.Errorf("failed to remove %s: %w", task.Label(), err)
+			report.TaskFinished(task, planner.ActionRemove, planner.StatusFailed, removeErr)
+			return handleError(removeErr)
+		}
+
+		report.TaskFinished(task, planner.ActionRemove, planner.StatusDone, nil)
 	}
 
-	fmt.Println()
-	fmt.Printf("Done! %d removed, %d not installed.\n", removedCount, notInstalledCount)
+	report.Done(planner.ActionRemove, false)
 
 	return nil
 }
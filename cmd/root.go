@@ -1,15 +1,33 @@
 package cmd
 
 import (
+	"context"
 	"fmt"
 	"os"
+	"os/signal"
+	"syscall"
+	"time"
 
+	"github.com/Litchi-group/unipm/internal/config"
+	"github.com/Litchi-group/unipm/internal/errors"
 	"github.com/Litchi-group/unipm/internal/logger"
+	"github.com/Litchi-group/unipm/internal/progress"
+	"github.com/Litchi-group/unipm/internal/provider"
+	"github.com/Litchi-group/unipm/internal/render"
 	"github.com/spf13/cobra"
 )
 
 var (
-	verbose bool
+	verbose       bool
+	trustRegistry bool
+	progressMode  string
+	outputFormat  string
+
+	// taskTimeout limits each install or remove (timeouts.task)
+	taskTimeout time.Duration
+
+	// cancelTotal releases the overall timeout (timeouts.total), if set
+	cancelTotal context.CancelFunc
 )
 
 var rootCmd = &cobra.Command{
@@ -22,17 +40,83 @@ to provide a unified, declarative interface for cross-platform dev environment s
 
 Write once. Set up anywhere.`,
 	Version: "0.1.3",
-	PersistentPreRun: func(cmd *cobra.Command, args []string) {
+	PersistentPreRunE: func(cmd *cobra.Command, args []string) error {
 		if verbose {
 			logger.SetLevel(logger.LevelDebug)
 		}
+
+		if err := render.SetFormat(outputFormat); err != nil {
+			return err
+		}
+		if render.Structured() {
+			// Errors are printed as documents by Execute
+			cmd.Root().SilenceErrors = true
+			cmd.Root().SilenceUsage = true
+
+			if cmd.Annotations[annotationStructured] == "" {
+				return fmt.Errorf("%s does not support --output %s", cmd.CommandPath(), outputFormat)
+			}
+		}
+
+		globalConfig, _ := config.LoadGlobalConfig()
+		provider.SetTrustRegistry(trustRegistry || globalConfig.Registry.Trusted)
+
+		switch progressMode {
+		case progress.ModeStream:
+		case progress.ModeSpinner:
+			if !progress.IsTerminal(os.Stdout) {
+				progressMode = progress.ModeStream
+			}
+		default:
+			return fmt.Errorf("invalid --progress %q: expected stream or spinner", progressMode)
+		}
+
+		if err := provider.SetPrivilegeMethod(globalConfig.Privilege.Method); err != nil {
+			return errors.NewConfigError("~/.unipm/config.yaml", err.Error(), nil)
+		}
+
+		var err error
+		if taskTimeout, err = globalConfig.Timeouts.TaskTimeout(); err != nil {
+			return errors.NewConfigError("~/.unipm/config.yaml", err.Error(), nil)
+		}
+
+		total, err := globalConfig.Timeouts.TotalTimeout()
+		if err != nil {
+			return errors.NewConfigError("~/.unipm/config.yaml", err.Error(), nil)
+		}
+		if total > 0 {
+			ctx, cancel := context.WithTimeout(cmd.Context(), total)
+			cmd.SetContext(ctx)
+			cancelTotal = cancel
+		}
+
+		return nil
 	},
 }
 
-// Execute runs the root command
+// Execute runs the root command.
+// Ctrl-C or SIGTERM cancels the command context, which stops the running
+// provider command gracefully; a second Ctrl-C exits immediately.
 func Execute() {
-	if err := rootCmd.Execute(); err != nil {
-		fmt.Fprintln(os.Stderr, err)
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	go func() {
+		<-ctx.Done()
+		stop()
+	}()
+
+	err := rootCmd.ExecuteContext(ctx)
+
+	if cancelTotal != nil {
+		cancelTotal()
+	}
+	stop()
+
+	if err != nil {
+		if render.Structured() {
+			_ = render.PrintError(err)
+		} else {
+			fmt.Fprintln(os.Stderr, err)
+		}
 		os.Exit(1)
 	}
 }
@@ -40,4 +124,7 @@ func Execute() {
 func init() {
 	// Global flags
 	rootCmd.PersistentFlags().BoolVarP(&verbose, "verbose", "v", false, "Enable verbose logging")
+	rootCmd.PersistentFlags().StringVar(&progressMode, "progress", progress.ModeStream, "Show command output as prefixed lines (stream) or a one-line spinner (spinner)")
+	rootCmd.PersistentFlags().StringVar(&outputFormat, "output", render.FormatText, "Print results as text, json or yaml")
+	rootCmd.PersistentFlags().BoolVar(&trustRegistry, "trust-registry", false, "Run install scripts from packages not pinned in devpack.yaml")
 }
@@ -0,0 +1,85 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/Litchi-group/unipm/internal/logger"
+	"github.com/Litchi-group/unipm/internal/planner"
+	"github.com/Litchi-group/unipm/internal/render"
+)
+
+// maxRunLogs is the number of run logs kept in ~/.unipm/logs
+const maxRunLogs = 20
+
+// attachRunLog sets the plan's progress display and saves its output to a
+// new run log. The returned function closes the log and, if the run failed,
+// adds the log path to the error (structured results carry it instead).
+func attachRunLog(plan *planner.Plan, command string) func(err error) error {
+	plan.Progress = progressMode
+
+	logFile, err := openRunLog(command)
+	if err != nil {
+		logger.Warn("Failed to create run log: %v", err)
+		return func(err error) error { return err }
+	}
+
+	plan.Log = logFile
+	return func(runErr error) error {
+		_ = logFile.Close()
+		if runErr != nil && !render.Structured() {
+			return fmt.Errorf("%w\n\n📄 Full output: %s", runErr, logFile.Name())
+		}
+		return runErr
+	}
+}
+
+// openRunLog creates a log file for this run, removing the oldest logs
+func openRunLog(command string) (*os.File, error) {
+	homeDir, err := os.UserHomeDir()
+	if err != nil {
+		return nil, err
+	}
+
+	logDir := filepath.Join(homeDir, ".unipm", "logs")
+	if err := os.MkdirAll(logDir, 0755); err != nil {
+		return nil, err
+	}
+
+	pruneRunLogs(logDir, maxRunLogs-1)
+
+	name := fmt.Sprintf("%s-%s.log", time.Now().Format("20060102-150405"), command)
+	logFile, err := os.Create(filepath.Join(logDir, name))
+	if err != nil {
+		return nil, err
+	}
+
+	_, _ = fmt.Fprintf(logFile, "unipm %s (%s)\n\n", command, time.Now().Format(time.RFC3339))
+	return logFile, nil
+}
+
+// pruneRunLogs removes all but the newest keep logs in dir
+func pruneRunLogs(dir string, keep int) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return
+	}
+
+	var logs []string
+	for _, entry := range entries {
+		if !entry.IsDir() && strings.HasSuffix(entry.Name(), ".log") {
+			logs = append(logs, entry.Name())
+		}
+	}
+
+	// Names start with a timestamp, so they sort oldest first
+	sort.Strings(logs)
+	for len(logs) > keep {
+		_ = os.Remove(filepath.Join(dir, logs[0]))
+		logs = logs[1:]
+	}
+}
@@ -5,14 +5,16 @@ import (
 	"strings"
 
 	"github.com/Litchi-group/unipm/internal/registry"
+	"github.com/Litchi-group/unipm/internal/render"
 	"github.com/spf13/cobra"
 )
 
 var searchCmd = &cobra.Command{
-	Use:   "search [query]",
-	Short: "Search for packages in the registry",
-	Long:  `Searches the package registry for packages matching the query.`,
-	Args:  cobra.MinimumNArgs(1),
+	Use:         "search [query]",
+	Short:       "Search for packages in the registry",
+	Long:        `Searches the package registry for packages matching the query.`,
+	Args:        cobra.MinimumNArgs(1),
+	Annotations: structuredOutput,
 	RunE: func(cmd *cobra.Command, args []string) error {
 		query := strings.Join(args, " ")
 		return runSearch(query)
@@ -23,13 +25,19 @@ func init() {
 	rootCmd.AddCommand(searchCmd)
 }
 
+// searchDocument is the structured output of search
+type searchDocument struct {
+	Query    string                 `json:"query" yaml:"query"`
+	Packages []registry.PackageInfo `json:"packages" yaml:"packages"`
+}
+
 func runSearch(query string) error {
 	reg := registry.NewRegistry()
 
 	// Load package index
 	packages, err := reg.LoadIndex()
 	if err != nil {
-		return fmt.Errorf("failed to load package index: %w", err)
+		return handleError(fmt.Errorf("failed to load package index: %w", err))
 	}
 
 	// Filter packages by query
@@ -43,6 +51,10 @@ func runSearch(query string) error {
 		}
 	}
 
+	if render.Structured() {
+		return render.Print(searchDocument{Query: query, Packages: matches})
+	}
+
 	if len(matches) == 0 {
 		fmt.Printf("No packages found matching '%s'\n", query)
 		return nil
@@ -1,11 +1,14 @@
 package cmd
 
 import (
+	"context"
 	"fmt"
 
 	"github.com/Litchi-group/unipm/internal/detector"
 	"github.com/Litchi-group/unipm/internal/planner"
+	"github.com/Litchi-group/unipm/internal/provider"
 	"github.com/Litchi-group/unipm/internal/registry"
+	"github.com/Litchi-group/unipm/internal/render"
 	"github.com/spf13/cobra"
 )
 
@@ -14,8 +17,9 @@ var updateCmd = &cobra.Command{
 	Short: "Update packages",
 	Long: `Updates all packages in devpack.yaml, or specific packages if provided.
 Uses the native package manager's update command.`,
+	Annotations: structuredOutput,
 	RunE: func(cmd *cobra.Command, args []string) error {
-		return runUpdate(args)
+		return runUpdate(cmd.Context(), args)
 	},
 }
 
@@ -23,7 +27,7 @@ func init() {
 	rootCmd.AddCommand(updateCmd)
 }
 
-func runUpdate(packageIDs []string) error {
+func runUpdate(ctx context.Context, packageIDs []string) error {
 	// Load devpack.yaml
 	devpack, err := loadDevpackWithPrompt()
 	if err != nil {
@@ -38,7 +42,7 @@ func runUpdate(packageIDs []string) error {
 		packageIDs = devpack.Apps
 	}
 
-	if len(packageIDs) == 0 {
+	if len(packageIDs) == 0 && !render.Structured() {
 		fmt.Println("No packages to update")
 		return nil
 	}
@@ -49,40 +53,65 @@ func runUpdate(packageIDs []string) error {
 	// Create planner
 	reg := registry.NewRegistry()
 	plnr := planner.NewPlanner(reg, osInfo)
+	plnr.SetOverrides(devpack.Overrides)
 
 	// Create plan
-	plan, err := plnr.CreatePlan(packageIDs)
+	plan, err := plnr.CreatePlan(ctx, packageIDs)
 	if err != nil {
-		return err
+		return handleError(err)
 	}
 
-	fmt.Printf("Updating %d package(s)...\n\n", len(plan.Tasks))
+	recorder := setReporter(plan, planner.ActionUpdate, false)
 
-	updatedCount := 0
-	notInstalledCount := 0
+	installed := func(t *planner.InstallTask) bool { return t.Present() }
+	if err := plan.CheckPrivilege(installed); err != nil {
+		return finishRun(recorder, plan, handleError(err))
+	}
 
-	for _, task := range plan.Tasks {
-		if !task.Installed {
-			fmt.Printf("Updating %s...\n", task.PackageID)
-			fmt.Printf("  ⊙ Not installed (use 'unipm apply' to install)\n")
-			notInstalledCount++
-			continue
+	if recorder == nil {
+		fmt.Printf("Updating %d package(s)...\n\n", len(plan.Tasks))
+	}
+
+	plan.TaskTimeout = taskTimeout
+
+	finish := attachRunLog(plan, "update")
+	return finishRun(recorder, plan, finish(executeUpdate(ctx, plan)))
+}
+
+// executeUpdate reinstalls the installed packages of a plan, which makes
+// package managers upgrade them
+func executeUpdate(ctx context.Context, plan *planner.Plan) error {
+	report := plan.Report()
+
+	for i, task := range plan.Tasks {
+		if ctx.Err() != nil {
+			report.Interrupted(plan, i, false)
+			return fmt.Errorf("stopped before updating %s: %w", task.Label(), ctx.Err())
 		}
 
-		fmt.Printf("Updating %s...\n", task.PackageID)
+		report.TaskStarted(task, planner.ActionUpdate)
+
+		if !task.Present() {
+			report.TaskFinished(task, planner.ActionUpdate, planner.StatusSkipped, nil)
+			continue
+		}
 
 		// Get update command (same as install for most package managers)
 		// They handle updates when package is already installed
-		if err := task.Provider.Install(*task.Spec); err != nil {
-			return fmt.Errorf("failed to update %s: %w", task.PackageID, err)
+		if err := plan.RunTask(ctx, task, task.Provider.Install); err != nil {
+			if ctx.Err() != nil {
+				report.Interrupted(plan, i, true)
+				return fmt.Errorf("interrupted while updating %s: %w", task.Label(), ctx.Err())
+			}
+			installErr := provider.NewInstallError(task.Label(), task.Provider, err)
+			report.TaskFinished(task, planner.ActionUpdate, planner.StatusFailed, installErr)
+			return handleError(installErr)
 		}
 
-		fmt.Printf("  ✓ Updated\n")
-		updatedCount++
+		report.TaskFinished(task, planner.ActionUpdate, planner.StatusDone, nil)
 	}
 
-	fmt.Println()
-	fmt.Printf("Done! %d updated, %d not installed.\n", updatedCount, notInstalledCount)
+	report.Done(planner.ActionUpdate, false)
 
 	return nil
 }
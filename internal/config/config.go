@@ -10,8 +10,19 @@ import (
 
 // DevPack represents the devpack.yaml configuration
 type DevPack struct {
-	Apps     []string            `yaml:"apps"`
-	Profiles map[string][]string `yaml:"profiles,omitempty"`
+	Apps      []string            `yaml:"apps"`
+	Profiles  map[string][]string `yaml:"profiles,omitempty"`
+	Overrides map[string]Override `yaml:"overrides,omitempty"` // Keyed by package ID
+}
+
+// Override adjusts how a package is installed on this machine, taking
+// precedence over the registry mapping
+type Override struct {
+	Channel  string `yaml:"channel,omitempty"`  // Snap channel, e.g., "latest/edge" or "1.28/stable"
+	Revision int    `yaml:"revision,omitempty"` // Snap revision to pin
+	Devmode  bool   `yaml:"devmode,omitempty"`  // Install the snap in developer mode
+	Classic  bool   `yaml:"classic,omitempty"`  // Install the snap with classic confinement
+	Checksum string `yaml:"checksum,omitempty"` // SHA256 the package definition must match, as shown by 'unipm info'
 }
 
 // PackageSpec represents a package with optional version
@@ -1,8 +1,10 @@
 package config
 
 import (
+	"fmt"
 	"os"
 	"path/filepath"
+	"time"
 
 	"github.com/Litchi-group/unipm/internal/logger"
 	"gopkg.in/yaml.v3"
@@ -10,14 +12,17 @@ import (
 
 // GlobalConfig represents the ~/.unipm/config.yaml file
 type GlobalConfig struct {
-	Registry RegistryConfig `yaml:"registry"`
-	Log      LogConfig      `yaml:"log"`
+	Registry  RegistryConfig  `yaml:"registry"`
+	Log       LogConfig       `yaml:"log"`
+	Privilege PrivilegeConfig `yaml:"privilege"`
+	Timeouts  TimeoutConfig   `yaml:"timeouts"`
 }
 
 // RegistryConfig contains registry settings
 type RegistryConfig struct {
 	URL      string `yaml:"url"`       // Custom registry URL
 	CacheTTL int    `yaml:"cache_ttl"` // Cache TTL in hours (default: 24)
+	Trusted  bool   `yaml:"trusted"`   // Run scripts from packages not pinned in devpack.yaml
 }
 
 // LogConfig contains logging settings
@@ -25,6 +30,40 @@ type LogConfig struct {
 	Level string `yaml:"level"` // debug, info, warn, error
 }
 
+// PrivilegeConfig contains privilege escalation settings
+type PrivilegeConfig struct {
+	Method string `yaml:"method"` // auto, none, sudo, doas, pkexec (default: auto)
+}
+
+// TimeoutConfig contains command timeouts as Go durations (e.g., "30m", "1h30m")
+type TimeoutConfig struct {
+	Task  string `yaml:"task"`  // Limit for one install or remove (default: 30m, "0" for none)
+	Total string `yaml:"total"` // Limit for a whole run (default: none)
+}
+
+// TaskTimeout returns the per-task timeout, or 0 for none
+func (c TimeoutConfig) TaskTimeout() (time.Duration, error) {
+	return parseTimeout("timeouts.task", c.Task)
+}
+
+// TotalTimeout returns the overall timeout, or 0 for none
+func (c TimeoutConfig) TotalTimeout() (time.Duration, error) {
+	return parseTimeout("timeouts.total", c.Total)
+}
+
+// parseTimeout parses an optional duration setting
+func parseTimeout(key, value string) (time.Duration, error) {
+	if value == "" || value == "0" {
+		return 0, nil
+	}
+
+	d, err := time.ParseDuration(value)
+	if err != nil || d < 0 {
+		return 0, fmt.Errorf("invalid %s %q: expected a duration like 30m", key, value)
+	}
+	return d, nil
+}
+
 // DefaultGlobalConfig returns the default configuration
 func DefaultGlobalConfig() *GlobalConfig {
 	return &GlobalConfig{
@@ -35,6 +74,12 @@ func DefaultGlobalConfig() *GlobalConfig {
 		Log: LogConfig{
 			Level: "info",
 		},
+		Privilege: PrivilegeConfig{
+			Method: "auto",
+		},
+		Timeouts: TimeoutConfig{
+			Task: "30m",
+		},
 	}
 }
 
@@ -78,6 +123,12 @@ func LoadGlobalConfig() (*GlobalConfig, error) {
 	if config.Log.Level == "" {
 		config.Log.Level = DefaultGlobalConfig().Log.Level
 	}
+	if config.Privilege.Method == "" {
+		config.Privilege.Method = DefaultGlobalConfig().Privilege.Method
+	}
+	if config.Timeouts.Task == "" {
+		config.Timeouts.Task = DefaultGlobalConfig().Timeouts.Task
+	}
 
 	logger.Debug("Loaded config from %s", configPath)
 	return &config, nil
@@ -0,0 +1,300 @@
+package config
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// MergeApps adds package IDs to a devpack.yaml document, to apps or to the
+// given profile. The new items are inserted as text after the last item of
+// the list, so every other byte of the document, including comments, blank
+// lines, indentation and flow style, is kept. IDs already listed, with or
+// without a version, are skipped. It returns the new document and the IDs
+// that were added.
+func MergeApps(data []byte, profile string, ids []string) ([]byte, []string, error) {
+	var doc yaml.Node
+	if err := yaml.Unmarshal(data, &doc); err != nil {
+		return nil, nil, fmt.Errorf("failed to parse devpack.yaml: %w", err)
+	}
+
+	var root *yaml.Node
+	if doc.Kind != 0 {
+		root = doc.Content[0]
+		if root.Kind != yaml.MappingNode {
+			return nil, nil, fmt.Errorf("devpack.yaml must be a mapping")
+		}
+		if root.Style&yaml.FlowStyle != 0 {
+			return nil, nil, fmt.Errorf("devpack.yaml: cannot add packages to a flow-style mapping")
+		}
+	}
+
+	// Find the list and the mapping holding it; missing ones are nil
+	parent, parentKey := root, (*yaml.Node)(nil)
+	if profile != "" {
+		parentKey, parent = mappingEntry(root, "profiles")
+		if parent != nil && !isNull(parent) {
+			if parent.Kind != yaml.MappingNode {
+				return nil, nil, fmt.Errorf("devpack.yaml: profiles must be a mapping")
+			}
+			if parent.Style&yaml.FlowStyle != 0 {
+				return nil, nil, fmt.Errorf("devpack.yaml: cannot add packages to a flow-style profiles mapping")
+			}
+		}
+	}
+	key, list := mappingEntry(parent, listKey(profile))
+	if list != nil && !isNull(list) && list.Kind != yaml.SequenceNode {
+		return nil, nil, fmt.Errorf("devpack.yaml: %s must be a list", listName(profile))
+	}
+
+	present := make(map[string]bool)
+	if list != nil {
+		for _, item := range list.Content {
+			present[ParsePackageSpec(item.Value).Name] = true
+		}
+	}
+
+	var added []string
+	for _, id := range ids {
+		if present[ParsePackageSpec(id).Name] {
+			continue
+		}
+		present[ParsePackageSpec(id).Name] = true
+		added = append(added, id)
+	}
+	if len(added) == 0 {
+		return data, nil, nil
+	}
+
+	e := newTextEditor(data)
+	switch {
+	case list != nil && list.Kind == yaml.SequenceNode && list.Style&yaml.FlowStyle != 0:
+		if err := e.appendToFlowSequence(list, added); err != nil {
+			return nil, nil, err
+		}
+
+	case list != nil && list.Kind == yaml.SequenceNode:
+		// Continue the list with the prefix ("  - ") of its last item
+		last := list.Content[len(list.Content)-1]
+		prefix := e.prefix(last)
+		if strings.Trim(prefix, " -") != "" || !strings.Contains(prefix, "-") {
+			prefix = strings.Repeat(" ", list.Column-1) + "- "
+		}
+		e.insertAfter(lastLine(last), itemLines(prefix, added))
+
+	case list != nil:
+		// A null list ("apps:" or "apps: ~") gets its items below the key
+		e.removeToken(list)
+		e.insertAfter(key.Line, itemLines(strings.Repeat(" ", key.Column+1)+"- ", added))
+
+	case parent != nil && !isNull(parent) && len(parent.Content) > 0:
+		// A new key in an existing mapping, aligned with its other keys
+		indent := parent.Content[0].Column - 1
+		e.insertAfter(lastLine(parent), keyLines(indent, listKey(profile), added))
+
+	case parent != nil && parentKey != nil:
+		// A new key in a null profiles mapping
+		e.removeToken(parent)
+		e.insertAfter(parentKey.Line, keyLines(parentKey.Column+1, profile, added))
+
+	case profile != "":
+		e.appendLines(append([]string{"profiles:"}, keyLines(2, profile, added)...))
+
+	default:
+		e.appendLines(keyLines(0, "apps", added))
+	}
+
+	return e.bytes(), added, nil
+}
+
+// mappingEntry returns the key and value nodes of key in a mapping node, or
+// nils if the mapping is nil or has no such key
+func mappingEntry(mapping *yaml.Node, key string) (*yaml.Node, *yaml.Node) {
+	if mapping == nil || mapping.Kind != yaml.MappingNode {
+		return nil, nil
+	}
+	for i := 0; i+1 < len(mapping.Content); i += 2 {
+		if mapping.Content[i].Value == key {
+			return mapping.Content[i], mapping.Content[i+1]
+		}
+	}
+	return nil, nil
+}
+
+// isNull reports whether a node is an empty or explicit null value
+func isNull(node *yaml.Node) bool {
+	return node.Kind == yaml.ScalarNode && node.Tag == "!!null"
+}
+
+// lastLine returns the last line a node or any of its children starts on
+func lastLine(node *yaml.Node) int {
+	line := node.Line
+	for _, child := range node.Content {
+		if l := lastLine(child); l > line {
+			line = l
+		}
+	}
+	return line
+}
+
+// itemLines returns the lines of sequence items with a prefix such as "  - "
+func itemLines(prefix string, ids []string) []string {
+	lines := make([]string, 0, len(ids))
+	for _, id := range ids {
+		lines = append(lines, prefix+id)
+	}
+	return lines
+}
+
+// keyLines returns the lines of a mapping key holding a list of ids
+func keyLines(indent int, key string, ids []string) []string {
+	lines := []string{strings.Repeat(" ", indent) + key + ":"}
+	return append(lines, itemLines(strings.Repeat(" ", indent+2)+"- ", ids)...)
+}
+
+// listKey returns the key of the package list written by MergeApps
+func listKey(profile string) string {
+	if profile == "" {
+		return "apps"
+	}
+	return profile
+}
+
+// listName names the package list written by MergeApps in errors
+func listName(profile string) string {
+	if profile == "" {
+		return "apps"
+	}
+	return "profiles." + profile
+}
+
+// textEditor edits a YAML document line by line at node positions
+type textEditor struct {
+	lines []string // Without "\n"; a trailing "\r" is kept
+	eol   string   // "\r" if the document uses CRLF line endings
+}
+
+func newTextEditor(data []byte) *textEditor {
+	e := &textEditor{}
+	if len(data) > 0 {
+		e.lines = strings.Split(string(data), "\n")
+	}
+	if bytes.Contains(data, []byte("\r\n")) {
+		e.eol = "\r"
+	}
+	return e
+}
+
+// prefix returns the text of a node's line before the node
+func (e *textEditor) prefix(node *yaml.Node) string {
+	line := []rune(e.lines[node.Line-1])
+	return string(line[:node.Column-1])
+}
+
+// insertAfter inserts lines after a 1-based line number
+func (e *textEditor) insertAfter(line int, lines []string) {
+	inserted := make([]string, 0, len(e.lines)+len(lines))
+	inserted = append(inserted, e.lines[:line]...)
+	for _, l := range lines {
+		inserted = append(inserted, l+e.eol)
+	}
+	e.lines = append(inserted, e.lines[line:]...)
+}
+
+// appendLines adds lines at the end of the document
+func (e *textEditor) appendLines(lines []string) {
+	// Drop the empty string after a final newline, or end the last line
+	if n := len(e.lines); n > 0 && e.lines[n-1] == "" {
+		e.lines = e.lines[:n-1]
+	}
+	for _, l := range lines {
+		e.lines = append(e.lines, l+e.eol)
+	}
+	e.lines = append(e.lines, "")
+}
+
+// removeToken removes the text of an explicit scalar, such as "~"
+func (e *textEditor) removeToken(node *yaml.Node) {
+	if node.Value == "" {
+		return
+	}
+	line := []rune(e.lines[node.Line-1])
+	start := node.Column - 1
+	end := start + len([]rune(node.Value))
+	if end > len(line) || string(line[start:end]) != node.Value {
+		return
+	}
+	e.lines[node.Line-1] = strings.TrimRight(string(line[:start]), " ") + string(line[end:])
+}
+
+// appendToFlowSequence adds items before the closing bracket of a flow
+// sequence such as "[git, node]"
+func (e *textEditor) appendToFlowSequence(list *yaml.Node, ids []string) error {
+	// Scan from the opening bracket to the matching closing one
+	line, col := list.Line-1, list.Column-1
+	depth, quote := 0, rune(0)
+	for ; line < len(e.lines); line, col = line+1, 0 {
+		runes := []rune(e.lines[line])
+		for ; col < len(runes); col++ {
+			r := runes[col]
+			switch {
+			case quote != 0:
+				if r == quote {
+					quote = 0
+				}
+			case r == '"' || r == '\'':
+				quote = r
+			case r == '#' && (col == 0 || runes[col-1] == ' '):
+				col = len(runes) // Comment to end of line
+			case r == '[':
+				depth++
+			case r == ']':
+				depth--
+				if depth == 0 {
+					e.insertBeforeBracket(line, col, len(list.Content) > 0, ids)
+					return nil
+				}
+			}
+		}
+	}
+	return fmt.Errorf("devpack.yaml: unterminated flow sequence on line %d", list.Line)
+}
+
+// insertBeforeBracket inserts ids before the closing bracket at line and col,
+// after the last item if there is one
+func (e *textEditor) insertBeforeBracket(line, col int, hasItems bool, ids []string) {
+	text := strings.Join(ids, ", ")
+	if !hasItems {
+		runes := []rune(e.lines[line])
+		e.lines[line] = string(runes[:col]) + text + string(runes[col:])
+		return
+	}
+
+	// Insert right after the last item, which may be on an earlier line
+	for l := line; l >= 0; l-- {
+		runes := []rune(e.lines[l])
+		i := len([]rune(strings.TrimRight(e.lines[l], "\r")))
+		if l == line {
+			i = col
+		}
+		for i > 0 && (runes[i-1] == ' ' || runes[i-1] == '\t') {
+			i--
+		}
+		if i == 0 {
+			continue
+		}
+		sep := ", "
+		if runes[i-1] == ',' {
+			sep = " "
+		}
+		e.lines[l] = string(runes[:i]) + sep + text + string(runes[i:])
+		return
+	}
+}
+
+// bytes returns the edited document
+func (e *textEditor) bytes() []byte {
+	return []byte(strings.Join(e.lines, "\n"))
+}
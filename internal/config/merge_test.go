@@ -0,0 +1,138 @@
+package config
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gopkg.in/yaml.v3"
+)
+
+const mergeInput = `# Team tools
+apps:
+  - git # version control
+  - node@18
+
+profiles:
+  web:
+    - node
+`
+
+func TestMergeApps(t *testing.T) {
+	data, added, err := MergeApps([]byte(mergeInput), "", []string{"git", "node", "jq"})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"jq"}, added)
+	assert.Equal(t, `# Team tools
+apps:
+  - git # version control
+  - node@18
+  - jq
+
+profiles:
+  web:
+    - node
+`, string(data))
+}
+
+func TestMergeApps_Profile(t *testing.T) {
+	data, added, err := MergeApps([]byte(mergeInput), "ops", []string{"kubectl"})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"kubectl"}, added)
+	assert.Equal(t, mergeInput+"  ops:\n    - kubectl\n", string(data))
+
+	var devpack DevPack
+	require.NoError(t, yaml.Unmarshal(data, &devpack))
+	assert.Equal(t, []string{"git", "node@18"}, devpack.Apps)
+	assert.Equal(t, []string{"node"}, devpack.Profiles["web"])
+	assert.Equal(t, []string{"kubectl"}, devpack.Profiles["ops"])
+}
+
+func TestMergeApps_Empty(t *testing.T) {
+	data, added, err := MergeApps(nil, "", []string{"git"})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"git"}, added)
+	assert.Equal(t, "apps:\n  - git\n", string(data))
+}
+
+func TestMergeApps_Formatting(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    string
+		profile  string
+		expected string
+	}{
+		{
+			name:     "four space indent",
+			input:    "apps:\n    -   git\n\n# end\n",
+			expected: "apps:\n    -   git\n    -   jq\n\n# end\n",
+		},
+		{
+			name:     "flow list",
+			input:    "apps: [git, node] # tools\nprofiles: {}\n",
+			expected: "apps: [git, node, jq] # tools\nprofiles: {}\n",
+		},
+		{
+			name:     "multi-line flow list",
+			input:    "apps: [\n  git,\n  node,\n]\n",
+			expected: "apps: [\n  git,\n  node, jq\n]\n",
+		},
+		{
+			name:     "empty flow list",
+			input:    "apps: []\n",
+			expected: "apps: [jq]\n",
+		},
+		{
+			name:     "null list",
+			input:    "apps: ~ # none yet\nprofiles:\n  web: [node]\n",
+			expected: "apps: # none yet\n  - jq\nprofiles:\n  web: [node]\n",
+		},
+		{
+			name:     "missing list",
+			input:    "# devpack\nprofiles:\n  web:\n    - node",
+			expected: "# devpack\nprofiles:\n  web:\n    - node\napps:\n  - jq",
+		},
+		{
+			name:     "crlf",
+			input:    "apps:\r\n  - git\r\n",
+			expected: "apps:\r\n  - git\r\n  - jq\r\n",
+		},
+		{
+			name:     "null profiles",
+			input:    "apps:\n  - git\nprofiles:\n",
+			profile:  "ops",
+			expected: "apps:\n  - git\nprofiles:\n  ops:\n    - jq\n",
+		},
+		{
+			name:     "missing profiles",
+			input:    "apps:\n  - git\n\n",
+			profile:  "ops",
+			expected: "apps:\n  - git\n\nprofiles:\n  ops:\n    - jq\n",
+		},
+		{
+			name:     "existing profile",
+			input:    "profiles:\n  ops:\n  - git # pinned\n  web:\n  - node\n",
+			profile:  "ops",
+			expected: "profiles:\n  ops:\n  - git # pinned\n  - jq\n  web:\n  - node\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			data, added, err := MergeApps([]byte(tt.input), tt.profile, []string{"jq"})
+			require.NoError(t, err)
+			assert.Equal(t, []string{"jq"}, added)
+			assert.Equal(t, tt.expected, string(data))
+
+			var devpack DevPack
+			require.NoError(t, yaml.Unmarshal(data, &devpack))
+		})
+	}
+}
+
+func TestMergeApps_Invalid(t *testing.T) {
+	_, _, err := MergeApps([]byte("apps: git\n"), "", []string{"jq"})
+	assert.ErrorContains(t, err, "apps must be a list")
+
+	_, _, err = MergeApps([]byte("{apps: [git]}\n"), "", []string{"jq"})
+	assert.ErrorContains(t, err, "flow-style mapping")
+}
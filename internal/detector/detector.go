@@ -1,6 +1,7 @@
 package detector
 
 import (
+	"fmt"
 	"os"
 	"runtime"
 	"strings"
@@ -8,9 +9,37 @@ import (
 
 // OSInfo contains information about the current operating system
 type OSInfo struct {
-	Platform string // "darwin", "windows", "linux"
-	Distro   string // "ubuntu", "debian", etc. (Linux only)
-	Arch     string // "amd64", "arm64", etc.
+	Platform string `json:"platform"`           // "darwin", "windows", "linux"
+	Distro   string `json:"distro,omitempty"`   // "ubuntu", "debian", etc. (Linux only)
+	Family   string `json:"family,omitempty"`   // "debian", "rhel", "arch", "suse", etc. (Linux only)
+	Codename string `json:"codename,omitempty"` // Release codename, e.g., "jammy", "bookworm" (Linux only, may be empty)
+	Arch     string `json:"arch"`               // "amd64", "arm64", etc.
+}
+
+// distroFamilies maps well-known distribution IDs to their family
+var distroFamilies = map[string]string{
+	"debian":              "debian",
+	"ubuntu":              "debian",
+	"linuxmint":           "debian",
+	"pop":                 "debian",
+	"elementary":          "debian",
+	"raspbian":            "debian",
+	"kali":                "debian",
+	"zorin":               "debian",
+	"fedora":              "rhel",
+	"rhel":                "rhel",
+	"centos":              "rhel",
+	"rocky":               "rhel",
+	"almalinux":           "rhel",
+	"ol":                  "rhel",
+	"amzn":                "rhel",
+	"arch":                "arch",
+	"manjaro":             "arch",
+	"endeavouros":         "arch",
+	"opensuse-leap":       "suse",
+	"opensuse-tumbleweed": "suse",
+	"sles":                "suse",
+	"alpine":              "alpine",
 }
 
 // DetectOS detects the current operating system
@@ -23,11 +52,103 @@ func DetectOS() *OSInfo {
 	// Detect Linux distribution if applicable
 	if info.Platform == "linux" {
 		info.Distro = detectLinuxDistro()
+		info.Family = DistroFamily(info.Distro)
+		if info.Family == "" {
+			info.Family = detectLinuxFamily()
+		}
+		info.Codename = detectLinuxCodename()
 	}
 
 	return info
 }
 
+// platformNames maps the OS names accepted by TargetOS to platforms
+var platformNames = map[string]string{
+	"macos":   "darwin",
+	"darwin":  "darwin",
+	"windows": "windows",
+	"linux":   "linux",
+}
+
+// TargetOS describes a system other than the current one, for planning
+// without installing. The architecture defaults to the current one, and a
+// Linux distribution to ubuntu. The codename is left unknown.
+func TargetOS(platform, distro, arch string) (*OSInfo, error) {
+	name, ok := platformNames[strings.ToLower(platform)]
+	if !ok {
+		return nil, fmt.Errorf("unknown OS %q (expected macos, linux or windows)", platform)
+	}
+
+	info := &OSInfo{Platform: name, Arch: arch}
+	if info.Arch == "" {
+		info.Arch = runtime.GOARCH
+	}
+
+	if name == "linux" {
+		info.Distro = strings.ToLower(distro)
+		if info.Distro == "" {
+			info.Distro = "ubuntu"
+		}
+		info.Family = DistroFamily(info.Distro)
+	} else if distro != "" {
+		return nil, fmt.Errorf("a distribution can only be set for linux")
+	}
+
+	return info, nil
+}
+
+// DistroFamily returns the family of a well-known distribution ID, or ""
+func DistroFamily(distro string) string {
+	return distroFamilies[strings.ToLower(distro)]
+}
+
+// detectLinuxFamily derives the family from ID_LIKE in /etc/os-release
+func detectLinuxFamily() string {
+	data, err := os.ReadFile("/etc/os-release")
+	if err != nil {
+		return ""
+	}
+
+	for _, line := range strings.Split(string(data), "\n") {
+		if !strings.HasPrefix(line, "ID_LIKE=") {
+			continue
+		}
+
+		like := strings.Trim(strings.TrimPrefix(line, "ID_LIKE="), `"`)
+		for _, id := range strings.Fields(like) {
+			if family := DistroFamily(id); family != "" {
+				return family
+			}
+		}
+	}
+
+	return ""
+}
+
+// detectLinuxCodename reads the release codename from /etc/os-release,
+// falling back to /etc/lsb-release
+func detectLinuxCodename() string {
+	if data, err := os.ReadFile("/etc/os-release"); err == nil {
+		for _, key := range []string{"VERSION_CODENAME=", "UBUNTU_CODENAME="} {
+			for _, line := range strings.Split(string(data), "\n") {
+				if codename := strings.Trim(strings.TrimPrefix(line, key), `"`); strings.HasPrefix(line, key) && codename != "" {
+					return codename
+				}
+			}
+		}
+	}
+
+	if data, err := os.ReadFile("/etc/lsb-release"); err == nil {
+		for _, line := range strings.Split(string(data), "\n") {
+			if strings.HasPrefix(line, "DISTRIB_CODENAME=") {
+				return strings.Trim(strings.TrimPrefix(line, "DISTRIB_CODENAME="), `"`)
+			}
+		}
+	}
+
+	return ""
+}
+
 // detectLinuxDistro attempts to detect the Linux distribution
 func detectLinuxDistro() string {
 	// Try /etc/os-release (most modern distros)
@@ -85,6 +206,12 @@ func (o *OSInfo) IsDebian() bool {
 	return o.IsLinux() && o.Distro == "debian"
 }
 
+// InFamily returns true if running on a distribution of the given family
+// (e.g., "debian" for Ubuntu)
+func (o *OSInfo) InFamily(family string) bool {
+	return o.IsLinux() && (o.Distro == family || o.Family == family)
+}
+
 // String returns a human-readable string representation
 func (o *OSInfo) String() string {
 	if o.IsLinux() && o.Distro != "" && o.Distro != "unknown" {
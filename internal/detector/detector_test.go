@@ -159,3 +159,65 @@ func TestOSInfo_Equality(t *testing.T) {
 	assert.Equal(t, os1.Platform, os2.Platform)
 	assert.NotEqual(t, os1.Platform, os3.Platform)
 }
+
+func TestOSInfo_InFamily(t *testing.T) {
+	tests := []struct {
+		name     string
+		osInfo   *OSInfo
+		family   string
+		expected bool
+	}{
+		{
+			name:     "Ubuntu is Debian family",
+			osInfo:   &OSInfo{Platform: "linux", Distro: "ubuntu", Family: DistroFamily("ubuntu")},
+			family:   "debian",
+			expected: true,
+		},
+		{
+			name:     "Debian is its own family",
+			osInfo:   &OSInfo{Platform: "linux", Distro: "debian"},
+			family:   "debian",
+			expected: true,
+		},
+		{
+			name:     "Fedora is not Debian family",
+			osInfo:   &OSInfo{Platform: "linux", Distro: "fedora", Family: DistroFamily("fedora")},
+			family:   "debian",
+			expected: false,
+		},
+		{
+			name:     "macOS has no family",
+			osInfo:   &OSInfo{Platform: "darwin"},
+			family:   "debian",
+			expected: false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expected, tt.osInfo.InFamily(tt.family))
+		})
+	}
+}
+
+func TestTargetOS(t *testing.T) {
+	info, err := TargetOS("macos", "", "arm64")
+	assert.NoError(t, err)
+	assert.Equal(t, &OSInfo{Platform: "darwin", Arch: "arm64"}, info)
+
+	info, err = TargetOS("linux", "Fedora", "")
+	assert.NoError(t, err)
+	assert.Equal(t, "fedora", info.Distro)
+	assert.Equal(t, "rhel", info.Family)
+	assert.Equal(t, runtime.GOARCH, info.Arch)
+
+	info, err = TargetOS("linux", "", "")
+	assert.NoError(t, err)
+	assert.Equal(t, "ubuntu", info.Distro)
+
+	_, err = TargetOS("windows", "ubuntu", "")
+	assert.Error(t, err)
+
+	_, err = TargetOS("plan9", "", "")
+	assert.Error(t, err)
+}
@@ -143,6 +143,10 @@ type InstallError struct {
 	PackageID string
 	Provider  string
 	Message   string
+	Command   string // Command that failed, if any
+	ExitCode  int    // Exit code of the failed command
+	Output    string // Tail of the command output
+	Hint      string // Suggested fix for a recognized failure
 	Cause     error
 }
 
@@ -166,3 +170,32 @@ func NewInstallError(packageID, provider, message string, cause error) *InstallE
 		Cause:     cause,
 	}
 }
+
+// CommandError indicates an external command exited unsuccessfully
+type CommandError struct {
+	Command  string
+	ExitCode int    // -1 if the command did not exit normally
+	Output   string // Tail of the combined stdout/stderr
+	Cause    error
+}
+
+func (e *CommandError) Error() string {
+	if e.ExitCode >= 0 {
+		return fmt.Sprintf("command '%s' exited with status %d", e.Command, e.ExitCode)
+	}
+	return fmt.Sprintf("command '%s' failed: %v", e.Command, e.Cause)
+}
+
+func (e *CommandError) Unwrap() error {
+	return e.Cause
+}
+
+// NewCommandError creates a new CommandError
+func NewCommandError(command string, exitCode int, output string, cause error) *CommandError {
+	return &CommandError{
+		Command:  command,
+		ExitCode: exitCode,
+		Output:   output,
+		Cause:    cause,
+	}
+}
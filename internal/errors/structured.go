@@ -0,0 +1,89 @@
+package errors
+
+import goerrors "errors"
+
+// Error types in machine-readable output
+const (
+	TypeNotFound            = "not_found"
+	TypeProviderUnavailable = "provider_unavailable"
+	TypeCircularDependency  = "circular_dependency"
+	TypeDependency          = "dependency"
+	TypeConfig              = "config"
+	TypeNetwork             = "network"
+	TypeInstall             = "install"
+	TypeCommand             = "command"
+	TypeGeneric             = "error" // Any other error
+)
+
+// Structured is the machine-readable form of an error. Fields other than
+// Type and Message are set when the error type carries them.
+type Structured struct {
+	Type      string   `json:"type" yaml:"type"`
+	Message   string   `json:"message" yaml:"message"`
+	PackageID string   `json:"package,omitempty" yaml:"package,omitempty"`
+	Provider  string   `json:"provider,omitempty" yaml:"provider,omitempty"`
+	Cycle     []string `json:"cycle,omitempty" yaml:"cycle,omitempty"`
+	File      string   `json:"file,omitempty" yaml:"file,omitempty"`
+	URL       string   `json:"url,omitempty" yaml:"url,omitempty"`
+	Command   string   `json:"command,omitempty" yaml:"command,omitempty"`
+	ExitCode  *int     `json:"exit_code,omitempty" yaml:"exit_code,omitempty"`
+	Output    string   `json:"output,omitempty" yaml:"output,omitempty"`
+	Hint      string   `json:"hint,omitempty" yaml:"hint,omitempty"`
+}
+
+// ToStructured describes an error by the outermost error type of this
+// package in its chain. The message is that of the whole error.
+func ToStructured(err error) *Structured {
+	s := &Structured{Type: TypeGeneric, Message: err.Error()}
+
+	for e := err; e != nil; e = goerrors.Unwrap(e) {
+		switch e := e.(type) {
+		case *NotFoundError:
+			s.Type = TypeNotFound
+			s.PackageID = e.PackageID
+		case *ProviderUnavailableError:
+			s.Type = TypeProviderUnavailable
+			s.Provider = e.ProviderName
+		case *CircularDependencyError:
+			s.Type = TypeCircularDependency
+			s.Cycle = e.Cycle
+		case *DependencyError:
+			s.Type = TypeDependency
+			s.PackageID = e.PackageID
+		case *ConfigError:
+			s.Type = TypeConfig
+			s.File = e.FilePath
+		case *NetworkError:
+			s.Type = TypeNetwork
+			s.URL = e.URL
+		case *InstallError:
+			s.Type = TypeInstall
+			s.PackageID = e.PackageID
+			s.Provider = e.Provider
+			s.Hint = e.Hint
+			if e.Command != "" {
+				s.Command = e.Command
+				s.ExitCode = exitCode(e.ExitCode)
+				s.Output = e.Output
+			}
+		case *CommandError:
+			s.Type = TypeCommand
+			s.Command = e.Command
+			s.ExitCode = exitCode(e.ExitCode)
+			s.Output = e.Output
+		default:
+			continue
+		}
+		return s
+	}
+
+	return s
+}
+
+// exitCode returns a pointer to a known exit code, or nil for -1
+func exitCode(code int) *int {
+	if code < 0 {
+		return nil
+	}
+	return &code
+}
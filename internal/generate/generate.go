@@ -0,0 +1,423 @@
+// Package generate renders installation plans as files for systems that
+// don't run unipm, such as a Brewfile, a shell script or a Dockerfile
+package generate
+
+import (
+	"fmt"
+	"path"
+	"strings"
+
+	"github.com/Litchi-group/unipm/internal/planner"
+	"github.com/Litchi-group/unipm/internal/provider"
+)
+
+// Output formats
+const (
+	FormatBrewfile   = "brewfile"   // Homebrew Brewfile for brew bundle
+	FormatShell      = "sh"         // Idempotent bash script
+	FormatDockerfile = "dockerfile" // Dockerfile with apt installs grouped into layers
+	FormatPowerShell = "powershell" // PowerShell script for Windows
+)
+
+// Formats lists the supported output formats
+var Formats = []string{FormatBrewfile, FormatShell, FormatDockerfile, FormatPowerShell}
+
+// DefaultOS returns the target OS a format is usually generated for, or ""
+// to use the current one
+func DefaultOS(format string) string {
+	switch format {
+	case FormatBrewfile:
+		return "macos"
+	case FormatDockerfile:
+		return "linux"
+	case FormatPowerShell:
+		return "windows"
+	default:
+		return ""
+	}
+}
+
+// CheckFormat returns an error unless format is supported
+func CheckFormat(format string) error {
+	for _, f := range Formats {
+		if f == format {
+			return nil
+		}
+	}
+	return fmt.Errorf("unknown format %q (expected %s)", format, strings.Join(Formats, ", "))
+}
+
+// Options adjust the generated output
+type Options struct {
+	BaseImage string // Dockerfile FROM image; defaults to the target distribution
+}
+
+// Render renders a plan in the given format
+func Render(format string, plan *planner.Plan, opts Options) (string, error) {
+	if err := CheckFormat(format); err != nil {
+		return "", err
+	}
+
+	var w writer
+	var err error
+
+	switch format {
+	case FormatBrewfile:
+		renderBrewfile(&w, plan)
+	case FormatShell:
+		err = renderShell(&w, plan)
+	case FormatDockerfile:
+		err = renderDockerfile(&w, plan, opts)
+	case FormatPowerShell:
+		err = renderPowerShell(&w, plan)
+	}
+	if err != nil {
+		return "", err
+	}
+
+	return w.String(), nil
+}
+
+// writer accumulates generated lines
+type writer struct {
+	strings.Builder
+}
+
+// line writes a formatted line
+func (w *writer) line(format string, args ...interface{}) {
+	fmt.Fprintf(w, format+"\n", args...)
+}
+
+// header returns the comment line identifying generated files
+func header(plan *planner.Plan) string {
+	return fmt.Sprintf("Generated by unipm for %s on %s", plan.OSInfo.String(), plan.OSInfo.Arch)
+}
+
+// requireOS fails unless the plan targets one of the given platforms
+func requireOS(format string, plan *planner.Plan, platforms ...string) error {
+	for _, platform := range platforms {
+		if plan.OSInfo.Platform == platform {
+			return nil
+		}
+	}
+	return fmt.Errorf("%s output cannot target %s", format, plan.OSInfo.String())
+}
+
+// renderBrewfile writes a Brewfile of the brew packages; others are listed
+// as comments
+func renderBrewfile(w *writer, plan *planner.Plan) {
+	w.line("# %s", header(plan))
+
+	taps := make(map[string]bool)
+	for _, task := range plan.Tasks {
+		spec := task.Spec
+		if spec.Type != "brew" && spec.Type != "brew_cask" {
+			continue
+		}
+
+		if tap := provider.BrewTap(*spec); tap != "" && !taps[tap] {
+			taps[tap] = true
+			if spec.TapURL != "" {
+				w.line("tap %q, %q", tap, spec.TapURL)
+			} else {
+				w.line("tap %q", tap)
+			}
+		}
+	}
+
+	for _, task := range plan.Tasks {
+		spec := task.Spec
+		switch spec.Type {
+		case "brew":
+			w.line("brew %q", provider.NativeName(*spec))
+		case "brew_cask":
+			w.line("cask %q", provider.NativeName(*spec))
+		default:
+			w.line("# %s: installed with %s, not Homebrew", task.Label(), spec.Type)
+		}
+	}
+}
+
+// renderShell writes a bash script that skips installed packages
+func renderShell(w *writer, plan *planner.Plan) error {
+	if err := requireOS(FormatShell, plan, "linux", "darwin"); err != nil {
+		return err
+	}
+
+	w.line("#!/usr/bin/env bash")
+	w.line("# %s.", header(plan))
+	w.line("# Installed packages are skipped, so the script can be re-run.")
+	w.line("set -euo pipefail")
+	w.line("")
+	w.line(`SUDO=""`)
+	w.line(`if [ "$(id -u)" -ne 0 ]; then SUDO="sudo"; fi`)
+
+	if hasType(plan, "apt") {
+		// Refresh the package lists once, before the first missing package
+		w.line("")
+		w.line("APT_UPDATED=\"\"")
+		w.line("apt_install() {")
+		w.line(`  if [ -z "$APT_UPDATED" ]; then $SUDO apt-get update; APT_UPDATED=1; fi`)
+		w.line(`  $SUDO apt-get install -y "$@"`)
+		w.line("}")
+	}
+
+	for _, task := range plan.Tasks {
+		spec := task.Spec
+		w.line("")
+		w.line("# %s", task.Label())
+
+		switch spec.Type {
+		case "apt":
+			if spec.Repo != nil {
+				w.line("if [ ! -f %s ]; then", provider.AptSourcesPath(provider.AptEtcDir, spec.Repo))
+				for _, cmd := range repoCommands(spec.Repo, "$SUDO ") {
+					w.line("  %s", cmd)
+				}
+				w.line("  APT_UPDATED=\"\"")
+				w.line("fi")
+			}
+			w.line("if ! dpkg -s %s >/dev/null 2>&1; then", spec.Name)
+			w.line("  apt_install %s", spec.Name)
+			w.line("fi")
+		case "snap":
+			w.line("if ! snap list %s >/dev/null 2>&1; then", spec.Name)
+			w.line("  $SUDO snap %s", strings.Join(provider.SnapArgs("install", *spec), " "))
+			if spec.Revision != 0 {
+				w.line("  $SUDO snap refresh --hold %s", spec.Name)
+			}
+			w.line("fi")
+		case "brew", "brew_cask":
+			kind := "--formula"
+			if spec.Type == "brew_cask" {
+				kind = "--cask"
+			}
+			w.line("if ! brew list %s %s >/dev/null 2>&1; then", kind, provider.NativeName(*spec))
+			if tap := provider.BrewTap(*spec); tap != "" {
+				w.line("  brew tap %s", strings.TrimSpace(tap+" "+spec.TapURL))
+			}
+			w.line("  %s", task.Provider.InstallCommand(*spec))
+			w.line("fi")
+		case "script":
+			writeScript(w, spec, "sh")
+		case "binary":
+			w.line("# Download %s manually; binary packages are not scripted", provider.ExpandURLTemplate(spec.URL, plan.OSInfo, spec.Version))
+		default:
+			// npm, pipx, cargo, go, mise and asdf skip installed packages themselves
+			w.line("%s", task.Provider.InstallCommand(*spec))
+		}
+	}
+
+	return nil
+}
+
+// hasType reports whether any task of the plan uses the given provider
+func hasType(plan *planner.Plan, providerType string) bool {
+	for _, task := range plan.Tasks {
+		if task.Spec.Type == providerType {
+			return true
+		}
+	}
+	return false
+}
+
+// writeScript writes a registry script guarded by its check script
+func writeScript(w *writer, spec *provider.ProviderSpec, shell string) {
+	if spec.Script == nil {
+		w.line("# No install script in the package definition")
+		return
+	}
+	if !spec.Pinned {
+		w.line("# The package definition is not pinned in devpack.yaml; review this script")
+	}
+
+	install := strings.TrimSpace(spec.Script.Install)
+	check := strings.TrimSpace(spec.Script.Check)
+	if shell == "powershell" {
+		if check == "" {
+			w.line("%s", install)
+			return
+		}
+		w.line("try { %s; $installed = $? } catch { $installed = $false }", check)
+		w.line("if (-not $installed) {")
+		w.line("%s", install)
+		w.line("}")
+		return
+	}
+
+	if check == "" {
+		w.line("(\n%s\n)", install)
+		return
+	}
+	w.line("if ! (\n%s\n) >/dev/null 2>&1; then", check)
+	w.line("(\n%s\n)", install)
+	w.line("fi")
+}
+
+// renderDockerfile writes a Dockerfile that installs consecutive apt
+// packages in one layer
+func renderDockerfile(w *writer, plan *planner.Plan, opts Options) error {
+	if err := requireOS(FormatDockerfile, plan, "linux"); err != nil {
+		return err
+	}
+
+	image := opts.BaseImage
+	if image == "" {
+		image = baseImage(plan.OSInfo.Distro)
+	}
+
+	w.line("# syntax=docker/dockerfile:1")
+	w.line("# %s", header(plan))
+	w.line("FROM %s", image)
+	w.line("ENV DEBIAN_FRONTEND=noninteractive")
+
+	for i := 0; i < len(plan.Tasks); i++ {
+		spec := plan.Tasks[i].Spec
+		w.line("")
+
+		if spec.Type != "apt" {
+			writeDockerTask(w, plan, plan.Tasks[i])
+			continue
+		}
+
+		// Group this and the following apt tasks into one layer
+		var names []string
+		var repos []*provider.AptRepository
+		for ; i < len(plan.Tasks) && plan.Tasks[i].Spec.Type == "apt"; i++ {
+			names = append(names, plan.Tasks[i].Spec.Name)
+			if repo := plan.Tasks[i].Spec.Repo; repo != nil {
+				repos = append(repos, repo)
+			}
+		}
+		i--
+
+		if len(repos) > 0 {
+			w.line("RUN apt-get update && apt-get install -y --no-install-recommends ca-certificates curl")
+			for _, repo := range repos {
+				w.line("RUN %s", strings.Join(repoCommands(repo, ""), " \\\n    && "))
+			}
+		}
+		w.line("RUN apt-get update && apt-get install -y --no-install-recommends \\")
+		for _, name := range names {
+			w.line("      %s \\", name)
+		}
+		w.line("    && rm -rf /var/lib/apt/lists/*")
+	}
+
+	return nil
+}
+
+// writeDockerTask writes the layer of a task not installed with apt
+func writeDockerTask(w *writer, plan *planner.Plan, task *planner.InstallTask) {
+	spec := task.Spec
+	switch spec.Type {
+	case "snap":
+		w.line("# %s: snaps cannot be installed in containers", task.Label())
+	case "brew", "brew_cask", "winget":
+		w.line("# %s: %s is not available in this image", task.Label(), spec.Type)
+	case "binary":
+		w.line("# %s: download %s manually; binary packages are not scripted", task.Label(),
+			provider.ExpandURLTemplate(spec.URL, plan.OSInfo, spec.Version))
+	case "script":
+		w.line("# %s", task.Label())
+		if spec.Script == nil {
+			w.line("# No install script in the package definition")
+			return
+		}
+		if !spec.Pinned {
+			w.line("# The package definition is not pinned in devpack.yaml; review this script")
+		}
+		w.line("RUN <<'EOF'")
+		w.line("set -e")
+		w.line("%s", strings.TrimSpace(spec.Script.Install))
+		w.line("EOF")
+	default:
+		w.line("RUN %s", task.Provider.InstallCommand(*spec))
+	}
+}
+
+// baseImage returns the Docker image of a distribution
+func baseImage(distro string) string {
+	switch distro {
+	case "", "unknown", "ubuntu":
+		return "ubuntu:latest"
+	case "debian":
+		return "debian:stable-slim"
+	default:
+		return distro + ":latest"
+	}
+}
+
+// renderPowerShell writes a PowerShell script that skips installed packages
+func renderPowerShell(w *writer, plan *planner.Plan) error {
+	if err := requireOS(FormatPowerShell, plan, "windows"); err != nil {
+		return err
+	}
+
+	w.line("# %s.", header(plan))
+	w.line("# Installed packages are skipped, so the script can be re-run.")
+	w.line("$ErrorActionPreference = 'Stop'")
+	w.line("")
+	w.line("function Invoke-Native([scriptblock]$Command) {")
+	w.line("    & $Command")
+	w.line("    if ($LASTEXITCODE -ne 0) { throw \"Command failed with exit code ${LASTEXITCODE}: $Command\" }")
+	w.line("}")
+
+	for _, task := range plan.Tasks {
+		spec := task.Spec
+		w.line("")
+		w.line("# %s", task.Label())
+
+		switch spec.Type {
+		case "winget":
+			id := provider.NativeName(*spec)
+			w.line("winget list --id %s --exact --accept-source-agreements *> $null", id)
+			w.line("if ($LASTEXITCODE -ne 0) {")
+			w.line("    Invoke-Native { winget install --id %s --exact --silent --accept-package-agreements --accept-source-agreements }", id)
+			w.line("}")
+		case "script":
+			writeScript(w, spec, "powershell")
+		case "binary":
+			w.line("# Download %s manually; binary packages are not scripted", provider.ExpandURLTemplate(spec.URL, plan.OSInfo, spec.Version))
+		default:
+			w.line("Invoke-Native { %s }", task.Provider.InstallCommand(*spec))
+		}
+	}
+
+	return nil
+}
+
+// shellRepoVars are shell expansions of the repository placeholders,
+// evaluated on the target system
+var shellRepoVars = provider.AptRepoVars{
+	Distro:   `$(. /etc/os-release && echo "$ID")`,
+	Codename: `$(. /etc/os-release && echo "$VERSION_CODENAME")`,
+	Arch:     "$(dpkg --print-architecture)",
+}
+
+// repoCommands returns shell commands adding an apt repository, expanding
+// its placeholders on the target system. Keys go where unipm puts them:
+// armored keys in .asc files and binary keys in .gpg files.
+func repoCommands(repo *provider.AptRepository, sudo string) []string {
+	keyPaths := provider.AptKeyPaths(provider.AptEtcDir, repo)
+	commands := []string{sudo + "install -d -m 0755 " + path.Dir(keyPaths[0])}
+
+	keyPath := "$KEYRING"
+	if repo.Key != "" {
+		keyPath = provider.AptKeyPath(provider.AptEtcDir, repo, []byte(repo.Key))
+		commands = append(commands, fmt.Sprintf("printf '%%s\\n' '%s' | %stee %s >/dev/null", strings.TrimSpace(repo.Key), sudo, keyPath))
+	} else {
+		// The key format is only known once downloaded
+		download := "/tmp/unipm-" + repo.Name + ".key"
+		commands = append(commands,
+			fmt.Sprintf(`curl -fsSL "%s" -o %s`, provider.ExpandAptRepo(repo.KeyURL, shellRepoVars), download),
+			fmt.Sprintf(`if grep -q -- "%s" %s; then KEYRING=%s; else KEYRING=%s; fi`,
+				provider.ArmoredKeyHeader, download, keyPaths[0], keyPaths[1]),
+			fmt.Sprintf(`%sinstall -m 0644 %s "$KEYRING"`, sudo, download),
+			"rm -f "+download,
+		)
+	}
+
+	return append(commands, fmt.Sprintf(`echo "%s" | %stee %s >/dev/null`,
+		provider.AptSourcesLine(repo, keyPath, shellRepoVars), sudo, provider.AptSourcesPath(provider.AptEtcDir, repo)))
+}
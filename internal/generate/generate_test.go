@@ -0,0 +1,205 @@
+package generate
+
+import (
+	"testing"
+
+	"github.com/Litchi-group/unipm/internal/detector"
+	"github.com/Litchi-group/unipm/internal/planner"
+	"github.com/Litchi-group/unipm/internal/provider"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func task(id string, prov provider.Provider, spec provider.ProviderSpec) *planner.InstallTask {
+	return &planner.InstallTask{PackageID: id, Spec: &spec, Provider: prov}
+}
+
+func linuxPlan() *planner.Plan {
+	return &planner.Plan{
+		OSInfo: &detector.OSInfo{Platform: "linux", Distro: "debian", Family: "debian", Arch: "amd64"},
+		Tasks: []*planner.InstallTask{
+			task("git", provider.NewAptProvider(), provider.ProviderSpec{Type: "apt", Name: "git"}),
+			task("curl", provider.NewAptProvider(), provider.ProviderSpec{Type: "apt", Name: "curl"}),
+			task("code", provider.NewSnapProvider(), provider.ProviderSpec{Type: "snap", Name: "code", Classic: true}),
+			task("typescript", provider.NewNpmProvider(), provider.ProviderSpec{Type: "npm", Name: "typescript"}),
+			task("jq", provider.NewAptProvider(), provider.ProviderSpec{Type: "apt", Name: "jq"}),
+		},
+	}
+}
+
+func TestRender_Dockerfile(t *testing.T) {
+	out, err := Render(FormatDockerfile, linuxPlan(), Options{})
+	require.NoError(t, err)
+
+	assert.Contains(t, out, "FROM debian:stable-slim\n")
+	assert.Contains(t, out, "RUN apt-get update && apt-get install -y --no-install-recommends \\\n      git \\\n      curl \\\n    && rm -rf /var/lib/apt/lists/*\n")
+	assert.Contains(t, out, "# code: snaps cannot be installed in containers\n")
+	assert.Contains(t, out, "RUN npm install -g typescript\n")
+	assert.Contains(t, out, "      jq \\\n")
+
+	out, err = Render(FormatDockerfile, linuxPlan(), Options{BaseImage: "ubuntu:24.04"})
+	require.NoError(t, err)
+	assert.Contains(t, out, "FROM ubuntu:24.04\n")
+}
+
+func TestRender_Shell(t *testing.T) {
+	out, err := Render(FormatShell, linuxPlan(), Options{})
+	require.NoError(t, err)
+
+	assert.Contains(t, out, "set -euo pipefail\n")
+	assert.Contains(t, out, "apt_install() {\n")
+	assert.Contains(t, out, "if ! dpkg -s git >/dev/null 2>&1; then\n  apt_install git\nfi\n")
+	assert.Contains(t, out, "if ! dpkg -s curl >/dev/null 2>&1; then\n  apt_install curl\nfi\n")
+	assert.Contains(t, out, "  $SUDO snap install code --classic\n")
+}
+
+func TestRender_Brewfile(t *testing.T) {
+	plan := &planner.Plan{
+		OSInfo: &detector.OSInfo{Platform: "darwin", Arch: "arm64"},
+		Tasks: []*planner.InstallTask{
+			task("git", provider.NewBrewProvider(), provider.ProviderSpec{Type: "brew", Name: "git"}),
+			task("terraform", provider.NewBrewProvider(), provider.ProviderSpec{Type: "brew", Name: "hashicorp/tap/terraform"}),
+			task("vscode", provider.NewBrewProvider(), provider.ProviderSpec{Type: "brew_cask", Name: "visual-studio-code"}),
+			task("typescript", provider.NewNpmProvider(), provider.ProviderSpec{Type: "npm", Name: "typescript"}),
+		},
+	}
+
+	out, err := Render(FormatBrewfile, plan, Options{})
+	require.NoError(t, err)
+
+	assert.Contains(t, out, "tap \"hashicorp/tap\"\n")
+	assert.Contains(t, out, "brew \"git\"\n")
+	assert.Contains(t, out, "cask \"visual-studio-code\"\n")
+	assert.Contains(t, out, "# typescript: installed with npm, not Homebrew\n")
+}
+
+func TestRender_TargetMismatch(t *testing.T) {
+	_, err := Render(FormatPowerShell, linuxPlan(), Options{})
+	assert.Error(t, err)
+
+	_, err = Render("nix", linuxPlan(), Options{})
+	assert.Error(t, err)
+}
+
+func TestRender_PowerShell(t *testing.T) {
+	plan := &planner.Plan{
+		OSInfo: &detector.OSInfo{Platform: "windows", Arch: "amd64"},
+		Tasks: []*planner.InstallTask{
+			task("git", provider.NewWinGetProvider(), provider.ProviderSpec{Type: "winget", ID: "Git.Git"}),
+			task("typescript", provider.NewNpmProvider(), provider.ProviderSpec{Type: "npm", Name: "typescript"}),
+			task("tool", provider.NewBinaryProvider(), provider.ProviderSpec{Type: "binary", Name: "tool", URL: "https://example.com/tool-{{os}}-{{arch}}.zip"}),
+		},
+	}
+
+	out, err := Render(FormatPowerShell, plan, Options{})
+	require.NoError(t, err)
+
+	assert.Contains(t, out, "$ErrorActionPreference = 'Stop'\n")
+	assert.Contains(t, out, "function Invoke-Native([scriptblock]$Command) {\n")
+	assert.Contains(t, out, "# git\nwinget list --id Git.Git --exact --accept-source-agreements *> $null\n"+
+		"if ($LASTEXITCODE -ne 0) {\n"+
+		"    Invoke-Native { winget install --id Git.Git --exact --silent --accept-package-agreements --accept-source-agreements }\n}\n")
+	assert.Contains(t, out, "Invoke-Native { npm install -g typescript }\n")
+	assert.Contains(t, out, "# Download https://example.com/tool-windows-amd64.zip manually; binary packages are not scripted\n")
+}
+
+func TestWriteScript(t *testing.T) {
+	script := &provider.ScriptSpec{
+		Install: "curl -fsSL https://example.com/install.sh | sh\n",
+		Check:   "command -v tool\n",
+	}
+
+	tests := []struct {
+		name     string
+		shell    string
+		spec     provider.ProviderSpec
+		expected string
+	}{
+		{
+			name:  "sh pinned",
+			shell: "sh",
+			spec:  provider.ProviderSpec{Type: "script", Script: script, Pinned: true},
+			expected: "if ! (\ncommand -v tool\n) >/dev/null 2>&1; then\n" +
+				"(\ncurl -fsSL https://example.com/install.sh | sh\n)\nfi\n",
+		},
+		{
+			name:  "sh verified but not pinned",
+			shell: "sh",
+			spec:  provider.ProviderSpec{Type: "script", Script: script, Verified: true},
+			expected: "# The package definition is not pinned in devpack.yaml; review this script\n" +
+				"if ! (\ncommand -v tool\n) >/dev/null 2>&1; then\n" +
+				"(\ncurl -fsSL https://example.com/install.sh | sh\n)\nfi\n",
+		},
+		{
+			name:     "sh without check",
+			shell:    "sh",
+			spec:     provider.ProviderSpec{Type: "script", Script: &provider.ScriptSpec{Install: "make install"}, Pinned: true},
+			expected: "(\nmake install\n)\n",
+		},
+		{
+			name:  "powershell pinned",
+			shell: "powershell",
+			spec:  provider.ProviderSpec{Type: "script", Script: script, Pinned: true},
+			expected: "try { command -v tool; $installed = $? } catch { $installed = $false }\n" +
+				"if (-not $installed) {\ncurl -fsSL https://example.com/install.sh | sh\n}\n",
+		},
+		{
+			name:  "powershell not pinned",
+			shell: "powershell",
+			spec:  provider.ProviderSpec{Type: "script", Script: script},
+			expected: "# The package definition is not pinned in devpack.yaml; review this script\n" +
+				"try { command -v tool; $installed = $? } catch { $installed = $false }\n" +
+				"if (-not $installed) {\ncurl -fsSL https://example.com/install.sh | sh\n}\n",
+		},
+		{
+			name:     "powershell without check",
+			shell:    "powershell",
+			spec:     provider.ProviderSpec{Type: "script", Script: &provider.ScriptSpec{Install: "iwr https://example.com/install.ps1 | iex"}, Pinned: true},
+			expected: "iwr https://example.com/install.ps1 | iex\n",
+		},
+		{
+			name:     "no script",
+			shell:    "sh",
+			spec:     provider.ProviderSpec{Type: "script"},
+			expected: "# No install script in the package definition\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var w writer
+			writeScript(&w, &tt.spec, tt.shell)
+			assert.Equal(t, tt.expected, w.String())
+		})
+	}
+}
+
+func TestRepoCommands(t *testing.T) {
+	repo := &provider.AptRepository{
+		Name:       "docker",
+		Source:     "https://download.docker.com/linux/{{distro}}",
+		Suite:      "{{codename}}",
+		Components: []string{"stable"},
+		KeyURL:     "https://download.docker.com/linux/{{distro}}/gpg",
+	}
+
+	// Downloaded keys are stored as .asc or .gpg depending on their format
+	assert.Equal(t, []string{
+		"$SUDO install -d -m 0755 /etc/apt/keyrings",
+		`curl -fsSL "https://download.docker.com/linux/$(. /etc/os-release && echo "$ID")/gpg" -o /tmp/unipm-docker.key`,
+		`if grep -q -- "-----BEGIN PGP" /tmp/unipm-docker.key; then KEYRING=/etc/apt/keyrings/docker.asc; else KEYRING=/etc/apt/keyrings/docker.gpg; fi`,
+		`$SUDO install -m 0644 /tmp/unipm-docker.key "$KEYRING"`,
+		"rm -f /tmp/unipm-docker.key",
+		`echo "deb [arch=$(dpkg --print-architecture) signed-by=$KEYRING] https://download.docker.com/linux/$(. /etc/os-release && echo "$ID") ` +
+			`$(. /etc/os-release && echo "$VERSION_CODENAME") stable" | $SUDO tee /etc/apt/sources.list.d/docker.list >/dev/null`,
+	}, repoCommands(repo, "$SUDO "))
+
+	// An inline armored key goes to the .asc keyring, as unipm stores it
+	inline := *repo
+	inline.KeyURL = ""
+	inline.Key = "-----BEGIN PGP PUBLIC KEY BLOCK-----\n...\n-----END PGP PUBLIC KEY BLOCK-----\n"
+	commands := repoCommands(&inline, "")
+	require.Len(t, commands, 3)
+	assert.Contains(t, commands[1], "| tee /etc/apt/keyrings/docker.asc >/dev/null")
+	assert.Contains(t, commands[2], "signed-by=/etc/apt/keyrings/docker.asc]")
+}
@@ -0,0 +1,182 @@
+// Package manifest reads package lists written by other package managers,
+// so existing environments can be converted to a devpack
+package manifest
+
+import (
+	"bufio"
+	"fmt"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/Litchi-group/unipm/internal/provider"
+)
+
+// Manifest formats
+const (
+	FormatBrewfile = "brewfile" // Homebrew Brewfile (brew bundle dump)
+	FormatWinGet   = "winget"   // JSON written by winget export
+	FormatApt      = "apt"      // apt package names, apt list or dpkg --get-selections output
+	FormatSnap     = "snap"     // snap list output
+)
+
+// Formats lists the supported manifest formats
+var Formats = []string{FormatBrewfile, FormatWinGet, FormatApt, FormatSnap}
+
+// Entry is a package listed in a manifest
+type Entry struct {
+	Provider string // Provider that installs it, e.g., "brew" for both formulae and casks
+	Name     string // Native package name
+}
+
+// DetectFormat guesses the format of a manifest from its file name and content
+func DetectFormat(path string, data []byte) string {
+	base := strings.ToLower(filepath.Base(path))
+	content := strings.TrimSpace(strings.TrimPrefix(string(data), "\ufeff"))
+
+	switch {
+	case strings.HasPrefix(base, "brewfile"):
+		return FormatBrewfile
+	case strings.HasSuffix(base, ".json") || strings.HasPrefix(content, "{"):
+		return FormatWinGet
+	case isSnapList(content):
+		return FormatSnap
+	default:
+		return FormatApt
+	}
+}
+
+// Parse reads the entries of a manifest in the given format. It also returns
+// the lines it could not convert, such as Mac App Store apps in a Brewfile.
+func Parse(format string, data []byte) ([]Entry, []string, error) {
+	switch format {
+	case FormatBrewfile:
+		entries, skipped := parseBrewfile(string(data))
+		return entries, skipped, nil
+	case FormatWinGet:
+		packages, err := provider.ParseWinGetExport(data)
+		if err != nil {
+			return nil, nil, err
+		}
+		var entries []Entry
+		for _, pkg := range packages {
+			entries = append(entries, Entry{Provider: "winget", Name: pkg.Name})
+		}
+		return entries, nil, nil
+	case FormatApt:
+		return parseAptList(string(data)), nil, nil
+	case FormatSnap:
+		return parseSnapList(string(data)), nil, nil
+	default:
+		return nil, nil, fmt.Errorf("unknown manifest format %q (expected %s)", format, strings.Join(Formats, ", "))
+	}
+}
+
+// parseBrewfile reads the brew and cask entries of a Brewfile. Taps are
+// implied by fully qualified formula names.
+func parseBrewfile(data string) ([]Entry, []string) {
+	var entries []Entry
+	var skipped []string
+
+	scanner := bufio.NewScanner(strings.NewReader(data))
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		// brew "name", args: [...]
+		kind, rest, _ := strings.Cut(line, " ")
+		name := firstString(rest)
+		switch {
+		case kind == "tap":
+		case (kind == "brew" || kind == "cask") && name != "":
+			entries = append(entries, Entry{Provider: "brew", Name: name})
+		default:
+			skipped = append(skipped, line)
+		}
+	}
+
+	return entries, skipped
+}
+
+// firstString returns the first quoted Ruby string of a Brewfile line
+func firstString(s string) string {
+	s = strings.TrimSpace(s)
+	if s == "" || (s[0] != '"' && s[0] != '\'') {
+		return ""
+	}
+
+	end := strings.IndexByte(s[1:], s[0])
+	if end < 0 {
+		return ""
+	}
+	if s[0] == '"' {
+		if unquoted, err := strconv.Unquote(s[:end+2]); err == nil {
+			return unquoted
+		}
+	}
+	return s[1 : end+1]
+}
+
+// parseAptList reads package names, one per line. apt list output
+// (name/suite,... version arch [installed]) and dpkg --get-selections
+// output (name install) are also accepted.
+func parseAptList(data string) []Entry {
+	var entries []Entry
+	for _, line := range strings.Split(data, "\n") {
+		fields := strings.Fields(line)
+		if len(fields) == 0 || strings.HasPrefix(fields[0], "#") || strings.HasSuffix(fields[0], "...") {
+			continue
+		}
+
+		// dpkg --get-selections also lists removed packages
+		if len(fields) == 2 && (fields[1] == "deinstall" || fields[1] == "purge") {
+			continue
+		}
+
+		name, _, _ := strings.Cut(fields[0], "/")
+		entries = append(entries, Entry{Provider: "apt", Name: name})
+	}
+	return entries
+}
+
+// isSnapList reports whether content looks like snap list output
+func isSnapList(content string) bool {
+	header, _, _ := strings.Cut(content, "\n")
+	fields := strings.Fields(header)
+	return len(fields) >= 4 && fields[0] == "Name" && fields[2] == "Rev" && fields[3] == "Tracking"
+}
+
+// parseSnapList reads the snap names of snap list output, skipping bases
+// and snapd, which are installed as dependencies
+func parseSnapList(data string) []Entry {
+	var entries []Entry
+	for i, line := range strings.Split(data, "\n") {
+		fields := strings.Fields(line)
+		if i == 0 || len(fields) == 0 {
+			continue
+		}
+
+		notes := ""
+		if len(fields) >= 6 {
+			notes = fields[5]
+		}
+		if fields[0] == "snapd" || containsNote(notes, "base") || containsNote(notes, "core") {
+			continue
+		}
+
+		entries = append(entries, Entry{Provider: "snap", Name: fields[0]})
+	}
+	return entries
+}
+
+// containsNote reports whether the comma-separated notes of a snap include note
+func containsNote(notes, note string) bool {
+	for _, n := range strings.Split(notes, ",") {
+		if n == note {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,65 @@
+package manifest
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParse_Brewfile(t *testing.T) {
+	data, err := os.ReadFile(filepath.Join("testdata", "Brewfile"))
+	require.NoError(t, err)
+	require.Equal(t, FormatBrewfile, DetectFormat("Brewfile", data))
+
+	entries, skipped, err := Parse(FormatBrewfile, data)
+	require.NoError(t, err)
+	assert.Equal(t, []Entry{
+		{Provider: "brew", Name: "git"},
+		{Provider: "brew", Name: "hashicorp/tap/terraform"},
+		{Provider: "brew", Name: "node@20"},
+		{Provider: "brew", Name: "visual-studio-code"},
+		{Provider: "brew", Name: "firefox"},
+	}, entries)
+	assert.Equal(t, []string{`mas "Xcode", id: 497799835`, `vscode "golang.go"`}, skipped)
+}
+
+func TestParse_Apt(t *testing.T) {
+	for name, data := range map[string]string{
+		"names":      "git\ncurl\n# comment\n",
+		"apt list":   "Listing... Done\ngit/noble,now 1:2.43.0-1ubuntu7 amd64 [installed]\ncurl/noble,now 8.5.0-2ubuntu10 amd64 [installed]\n",
+		"selections": "git\t\t\tinstall\nvim\t\t\tdeinstall\ncurl\t\t\tinstall\n",
+	} {
+		t.Run(name, func(t *testing.T) {
+			require.Equal(t, FormatApt, DetectFormat("apt.txt", []byte(data)))
+
+			entries, _, err := Parse(FormatApt, []byte(data))
+			require.NoError(t, err)
+			assert.Equal(t, []Entry{{Provider: "apt", Name: "git"}, {Provider: "apt", Name: "curl"}}, entries)
+		})
+	}
+}
+
+func TestParse_Snap(t *testing.T) {
+	data := []byte(`Name     Version   Rev    Tracking       Publisher   Notes
+core22   20240111  1122   latest/stable  canonical✓  base
+kubectl  1.29.1    3179   1.29/stable    canonical✓  classic
+snapd    2.61.1    20671  latest/stable  canonical✓  snapd
+`)
+	require.Equal(t, FormatSnap, DetectFormat("snaps.txt", data))
+
+	entries, _, err := Parse(FormatSnap, data)
+	require.NoError(t, err)
+	assert.Equal(t, []Entry{{Provider: "snap", Name: "kubectl"}}, entries)
+}
+
+func TestParse_WinGet(t *testing.T) {
+	data := []byte(`{"Sources": [{"Packages": [{"PackageIdentifier": "Git.Git"}], "SourceDetails": {"Name": "winget"}}]}`)
+	require.Equal(t, FormatWinGet, DetectFormat("packages.json", data))
+
+	entries, _, err := Parse(FormatWinGet, data)
+	require.NoError(t, err)
+	assert.Equal(t, []Entry{{Provider: "winget", Name: "Git.Git"}}, entries)
+}
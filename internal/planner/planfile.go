@@ -0,0 +1,240 @@
+package planner
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/Litchi-group/unipm/internal/detector"
+	"github.com/Litchi-group/unipm/internal/errors"
+	"github.com/Litchi-group/unipm/internal/provider"
+	"github.com/Litchi-group/unipm/internal/registry"
+)
+
+// PlanFileVersion is the format version of saved plan files
+const PlanFileVersion = 1
+
+// DefaultMaxPlanAge is how old a saved plan may be before apply refuses it
+const DefaultMaxPlanAge = 24 * time.Hour
+
+// SavedPlan is a plan written to a file by 'unipm plan --out', so it can be
+// reviewed and then applied exactly as planned
+type SavedPlan struct {
+	Version     int              `json:"version"`
+	GeneratedAt time.Time        `json:"generated_at"`
+	OS          *detector.OSInfo `json:"os"`
+	Offline     bool             `json:"offline"` // Planned for another system; installed state is unknown
+	Profile     string           `json:"profile,omitempty"`
+	Tasks       []SavedTask      `json:"tasks"`
+}
+
+// SavedTask is a task of a saved plan
+type SavedTask struct {
+	Package   string                `json:"package"`
+	Version   string                `json:"version,omitempty"`
+	Command   string                `json:"command"`            // Install command, for reviewers
+	Checksum  string                `json:"checksum,omitempty"` // Registry checksum of the package definition
+	Installed *bool                 `json:"installed"`          // Installed when planned, null if unknown
+	Spec      provider.ProviderSpec `json:"spec"`
+}
+
+// NewSavedPlan returns the saved form of a plan, generated at now
+func NewSavedPlan(plan *Plan, profile string, now time.Time) *SavedPlan {
+	saved := &SavedPlan{
+		Version:     PlanFileVersion,
+		GeneratedAt: now.UTC(),
+		OS:          plan.OSInfo,
+		Offline:     plan.Offline,
+		Profile:     profile,
+		Tasks:       make([]SavedTask, 0, len(plan.Tasks)),
+	}
+
+	for _, task := range plan.Tasks {
+		t := SavedTask{
+			Package:  task.PackageID,
+			Version:  task.Version,
+			Command:  task.Provider.InstallCommand(*task.Spec),
+			Checksum: task.Checksum,
+			Spec:     *task.Spec,
+		}
+		if !plan.Offline {
+			installed := task.Installed
+			t.Installed = &installed
+		}
+		saved.Tasks = append(saved.Tasks, t)
+	}
+
+	return saved
+}
+
+// Write writes the saved plan to path as JSON
+func (s *SavedPlan) Write(path string) error {
+	data, err := json.MarshalIndent(s, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode plan: %w", err)
+	}
+	if err := os.WriteFile(path, append(data, '\n'), 0644); err != nil {
+		return fmt.Errorf("failed to write plan: %w", err)
+	}
+	return nil
+}
+
+// ReadSavedPlan reads a plan written by Write
+func ReadSavedPlan(path string) (*SavedPlan, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, errors.NewConfigError(path, "failed to read plan", err)
+	}
+
+	var saved SavedPlan
+	if err := json.Unmarshal(data, &saved); err != nil {
+		return nil, errors.NewConfigError(path, "invalid plan file", err)
+	}
+	if saved.Version != PlanFileVersion {
+		return nil, errors.NewConfigError(path, fmt.Sprintf("unsupported plan version %d (expected %d)", saved.Version, PlanFileVersion), nil)
+	}
+	if saved.OS == nil || saved.GeneratedAt.IsZero() {
+		return nil, errors.NewConfigError(path, "invalid plan file: missing os or generated_at", nil)
+	}
+	for _, task := range saved.Tasks {
+		if task.Package == "" || task.Spec.Type == "" {
+			return nil, errors.NewConfigError(path, "invalid plan file: task without package or provider", nil)
+		}
+	}
+
+	return &saved, nil
+}
+
+// Check verifies that the saved plan still holds on host: it was made for
+// this OS, is at most maxAge old at now, and the registry definitions of its
+// packages have not changed since. reg may be nil to skip the registry.
+func (s *SavedPlan) Check(host *detector.OSInfo, reg registry.RegistryInterface, maxAge time.Duration, now time.Time) error {
+	if !sameOS(s.OS, host) {
+		return fmt.Errorf("plan was made for %s, but this system is %s", s.OS.String(), host.String())
+	}
+
+	if age := now.Sub(s.GeneratedAt); maxAge > 0 && age > maxAge {
+		return fmt.Errorf("plan is stale: generated %s ago, at %s (limit %s)",
+			age.Round(time.Minute), s.GeneratedAt.Local().Format(time.RFC3339), maxAge)
+	}
+
+	if reg == nil {
+		return nil
+	}
+
+	checked := make(map[string]bool)
+	for _, task := range s.Tasks {
+		if checked[task.Package] {
+			continue
+		}
+		checked[task.Package] = true
+
+		pkg, err := reg.LoadPackage(task.Package)
+		if err != nil {
+			return fmt.Errorf("failed to load %s: %w", task.Package, err)
+		}
+		if pkg.Checksum != task.Checksum {
+			return fmt.Errorf("plan is stale: the registry definition of %s changed since it was generated", task.Package)
+		}
+	}
+
+	return nil
+}
+
+// sameOS reports whether a plan made for planned can run on host. The
+// codename is only compared when both are known.
+func sameOS(planned, host *detector.OSInfo) bool {
+	if planned.Platform != host.Platform || planned.Arch != host.Arch {
+		return false
+	}
+	if !host.IsLinux() {
+		return true
+	}
+	if planned.Distro != host.Distro {
+		return false
+	}
+	return planned.Codename == "" || host.Codename == "" || planned.Codename == host.Codename
+}
+
+// Plan rebuilds the saved plan to run on the planner's system, which Check
+// has accepted. Each package is resolved again from the registry with the
+// planner's overrides, and must resolve to the spec that was saved: the
+// plan file only says what to run, never how. Providers must be available,
+// and each package must still be in the installed state it was planned in;
+// the installed state of an offline plan is checked now.
+func (s *SavedPlan) Plan(ctx context.Context, p *Planner) (*Plan, error) {
+	plan := &Plan{
+		Tasks:  make([]*InstallTask, 0, len(s.Tasks)),
+		OSInfo: p.osInfo,
+	}
+
+	var changed []string
+	for _, saved := range s.Tasks {
+		pkg, spec, prov, err := p.resolve(saved.Package)
+		if err != nil {
+			return nil, err
+		}
+		if saved.Version != "" {
+			spec.Version = saved.Version
+		}
+
+		task := &InstallTask{
+			PackageID: saved.Package,
+			Version:   saved.Version,
+			Spec:      spec,
+			Provider:  prov,
+			Checksum:  pkg.Checksum,
+		}
+		task.Installed, task.Drift = checkInstalled(ctx, prov, spec)
+
+		resolved := *spec
+		if saved.Installed == nil {
+			// Offline plans could not tell whether to refresh instead
+			resolved.Refresh = saved.Spec.Refresh
+		}
+		same, err := sameSpec(saved.Spec, resolved)
+		if err != nil {
+			return nil, err
+		}
+		if !same {
+			return nil, fmt.Errorf("plan is stale: %s now resolves to %q instead of %q",
+				saved.Package, prov.InstallCommand(resolved), saved.Command)
+		}
+
+		if saved.Installed != nil && *saved.Installed != task.Installed {
+			state := "no longer installed"
+			if task.Installed {
+				state = "now installed"
+			}
+			changed = append(changed, fmt.Sprintf("%s is %s", task.Label(), state))
+		}
+
+		plan.Tasks = append(plan.Tasks, task)
+	}
+
+	if len(changed) > 0 {
+		return nil, fmt.Errorf("installed state changed since the plan was generated: %s; run 'unipm plan' again",
+			strings.Join(changed, ", "))
+	}
+
+	plan.Steps = prepareSteps(ctx, plan.Tasks)
+	return plan, nil
+}
+
+// sameSpec reports whether two specs are the same as saved in a plan file,
+// which leaves out whether the package was verified
+func sameSpec(saved, resolved provider.ProviderSpec) (bool, error) {
+	a, err := json.Marshal(saved)
+	if err != nil {
+		return false, fmt.Errorf("failed to encode spec: %w", err)
+	}
+	b, err := json.Marshal(resolved)
+	if err != nil {
+		return false, fmt.Errorf("failed to encode spec: %w", err)
+	}
+	return bytes.Equal(a, b), nil
+}
@@ -0,0 +1,283 @@
+package planner
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/Litchi-group/unipm/internal/config"
+	"github.com/Litchi-group/unipm/internal/detector"
+	"github.com/Litchi-group/unipm/internal/errors"
+	"github.com/Litchi-group/unipm/internal/provider"
+	"github.com/Litchi-group/unipm/internal/registry"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type fakeRegistry map[string]*registry.Package
+
+func (r fakeRegistry) LoadPackage(id string) (*registry.Package, error) {
+	if pkg, ok := r[id]; ok {
+		return pkg, nil
+	}
+	return nil, errors.NewNotFoundError(id)
+}
+
+func (r fakeRegistry) LoadIndex() ([]registry.PackageInfo, error) {
+	return nil, nil
+}
+
+func TestSavedPlan_RoundTrip(t *testing.T) {
+	osInfo := &detector.OSInfo{Platform: "linux", Distro: "ubuntu", Family: "debian", Codename: "jammy", Arch: "amd64"}
+	generated := time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC)
+	plan := &Plan{
+		OSInfo: osInfo,
+		Tasks: []*InstallTask{
+			{PackageID: "git", Spec: &provider.ProviderSpec{Type: "apt", Name: "git"}, Provider: provider.NewAptProvider(), Installed: true, Checksum: "abc"},
+			{PackageID: "typescript", Spec: &provider.ProviderSpec{Type: "npm", Name: "typescript"}, Provider: provider.NewNpmProvider()},
+		},
+	}
+
+	path := filepath.Join(t.TempDir(), "plan.json")
+	require.NoError(t, NewSavedPlan(plan, "web", generated).Write(path))
+
+	saved, err := ReadSavedPlan(path)
+	require.NoError(t, err)
+	assert.Equal(t, generated, saved.GeneratedAt)
+	assert.Equal(t, osInfo, saved.OS)
+	assert.Equal(t, "web", saved.Profile)
+	require.Len(t, saved.Tasks, 2)
+	assert.Equal(t, "abc", saved.Tasks[0].Checksum)
+	assert.Equal(t, true, *saved.Tasks[0].Installed)
+	assert.Equal(t, "npm install -g typescript", saved.Tasks[1].Command)
+	assert.Equal(t, provider.ProviderSpec{Type: "npm", Name: "typescript"}, saved.Tasks[1].Spec)
+
+	reg := fakeRegistry{
+		"git":        {ID: "git", Checksum: "abc"},
+		"typescript": {ID: "typescript"},
+	}
+	now := generated.Add(time.Hour)
+	assert.NoError(t, saved.Check(osInfo, reg, DefaultMaxPlanAge, now))
+
+	noble := *osInfo
+	noble.Codename = "noble"
+	assert.ErrorContains(t, saved.Check(&noble, reg, DefaultMaxPlanAge, now), "plan was made for")
+
+	arm := *osInfo
+	arm.Arch = "arm64"
+	assert.ErrorContains(t, saved.Check(&arm, reg, DefaultMaxPlanAge, now), "plan was made for")
+
+	assert.ErrorContains(t, saved.Check(osInfo, reg, time.Minute, now), "plan is stale")
+	assert.NoError(t, saved.Check(osInfo, reg, 0, now.Add(30*24*time.Hour)))
+
+	reg["git"] = &registry.Package{ID: "git", Checksum: "def"}
+	assert.ErrorContains(t, saved.Check(osInfo, reg, DefaultMaxPlanAge, now), "registry definition of git changed")
+}
+
+func TestReadSavedPlan_Invalid(t *testing.T) {
+	_, err := ReadSavedPlan(filepath.Join(t.TempDir(), "missing.json"))
+	var configErr *errors.ConfigError
+	assert.ErrorAs(t, err, &configErr)
+}
+
+const codePackage = `id: code
+name: Visual Studio Code
+homepage: https://code.visualstudio.com
+providers:
+  linux:
+    - type: snap
+      name: code
+      classic: true
+`
+
+const toolPackage = `id: tool
+name: Tool
+homepage: https://example.com
+providers:
+  linux:
+    - type: script
+      name: tool
+      script:
+        install: curl -fsSL https://example.com/install.sh | sh
+        check: command -v tool
+`
+
+func installed(b bool) *bool {
+	return &b
+}
+
+func TestSavedPlan_Plan(t *testing.T) {
+	osInfo := &detector.OSInfo{Platform: "linux", Distro: "ubuntu", Family: "debian", Arch: "amd64"}
+	typescript := provider.ProviderSpec{Type: "npm", Name: "typescript"}
+	withTypescript := `{"dependencies": {"typescript": {"version": "5.3.3"}}}`
+
+	tests := []struct {
+		name      string
+		tasks     []SavedTask
+		npmLs     string // Output of npm ls; "" for no packages
+		npm       bool   // npm is available
+		expected  string // Error, or "" to succeed
+		installed bool   // Installed state of the task when it succeeds
+	}{
+		{
+			name:      "unchanged",
+			tasks:     []SavedTask{{Package: "typescript", Spec: typescript, Installed: installed(true)}},
+			npmLs:     withTypescript,
+			npm:       true,
+			installed: true,
+		},
+		{
+			name:     "no longer installed",
+			tasks:    []SavedTask{{Package: "typescript", Spec: typescript, Installed: installed(true)}},
+			npm:      true,
+			expected: "typescript is no longer installed",
+		},
+		{
+			name:     "now installed",
+			tasks:    []SavedTask{{Package: "typescript", Spec: typescript, Installed: installed(false)}},
+			npmLs:    withTypescript,
+			npm:      true,
+			expected: "typescript is now installed",
+		},
+		{
+			name:      "offline plan",
+			tasks:     []SavedTask{{Package: "typescript", Spec: typescript}},
+			npmLs:     withTypescript,
+			npm:       true,
+			installed: true,
+		},
+		{
+			name:     "provider unavailable",
+			tasks:    []SavedTask{{Package: "typescript", Spec: typescript, Installed: installed(false)}},
+			expected: "provider npm is not available for typescript",
+		},
+		{
+			name:     "spec changed",
+			tasks:    []SavedTask{{Package: "typescript", Spec: provider.ProviderSpec{Type: "npm", Name: "typescript-evil"}, Installed: installed(false)}},
+			npm:      true,
+			expected: `plan is stale: typescript now resolves to "npm install -g typescript"`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			reg := cachedRegistry(t, map[string]string{"typescript": typescriptPackage})
+
+			npmLs := tt.npmLs
+			if npmLs == "" {
+				npmLs = "{}"
+			}
+			runner := provider.NewMockRunner().
+				On("npm ls -g --depth=0 --json", provider.MockResponse{Stdout: npmLs})
+			runner.SetAvailable("npm", tt.npm)
+			defer provider.SetDefaultRunner(provider.SetDefaultRunner(runner))
+
+			saved := &SavedPlan{Version: PlanFileVersion, OS: osInfo, Tasks: tt.tasks}
+			plan, err := saved.Plan(context.Background(), NewPlanner(reg, osInfo))
+			if tt.expected != "" {
+				assert.ErrorContains(t, err, tt.expected)
+				return
+			}
+
+			require.NoError(t, err)
+			require.Len(t, plan.Tasks, 1)
+			assert.Equal(t, typescript, *plan.Tasks[0].Spec)
+			assert.Equal(t, tt.installed, plan.Tasks[0].Installed)
+		})
+	}
+}
+
+func TestSavedPlan_PlanOverrides(t *testing.T) {
+	osInfo := &detector.OSInfo{Platform: "linux", Distro: "ubuntu", Family: "debian", Arch: "amd64"}
+	reg := cachedRegistry(t, map[string]string{"code": codePackage})
+
+	runner := provider.NewMockRunner()
+	runner.Default = &provider.MockResponse{ExitCode: 1} // Nothing is installed
+	defer provider.SetDefaultRunner(provider.SetDefaultRunner(runner))
+
+	saved := &SavedPlan{Version: PlanFileVersion, OS: osInfo, Tasks: []SavedTask{{
+		Package:   "code",
+		Spec:      provider.ProviderSpec{Type: "snap", Name: "code", Classic: true, Channel: "latest/edge"},
+		Installed: installed(false),
+	}}}
+
+	// Without the override that was planned with, the spec differs
+	_, err := saved.Plan(context.Background(), NewPlanner(reg, osInfo))
+	assert.ErrorContains(t, err, "plan is stale: code now resolves to")
+
+	p := NewPlanner(reg, osInfo)
+	p.SetOverrides(map[string]config.Override{"code": {Channel: "latest/edge"}})
+	plan, err := saved.Plan(context.Background(), p)
+	require.NoError(t, err)
+	assert.Equal(t, "latest/edge", plan.Tasks[0].Spec.Channel)
+}
+
+func TestSavedPlan_PlanVerifiedFromRegistry(t *testing.T) {
+	osInfo := &detector.OSInfo{Platform: "linux", Distro: "ubuntu", Family: "debian", Arch: "amd64"}
+	reg := cachedRegistry(t, map[string]string{"tool": toolPackage})
+	defer provider.SetDefaultRunner(provider.SetDefaultRunner(provider.NewMockRunner()))
+
+	// A plan file cannot mark an unsigned script as verified or pinned
+	path := filepath.Join(t.TempDir(), "plan.json")
+	require.NoError(t, os.WriteFile(path, []byte(`{
+  "version": 1,
+  "generated_at": "2026-01-02T03:04:05Z",
+  "os": {"platform": "linux", "distro": "ubuntu", "family": "debian", "arch": "amd64"},
+  "tasks": [{
+    "package": "tool",
+    "command": "curl -fsSL https://example.com/install.sh | sh",
+    "spec": {
+      "type": "script",
+      "name": "tool",
+      "script": {"install": "curl -fsSL https://example.com/install.sh | sh", "check": "command -v tool"},
+      "verified": true,
+      "pinned": true
+    }
+  }]
+}
+`), 0644))
+
+	saved, err := ReadSavedPlan(path)
+	require.NoError(t, err)
+	assert.False(t, saved.Tasks[0].Spec.Verified)
+	assert.False(t, saved.Tasks[0].Spec.Pinned)
+
+	plan, err := saved.Plan(context.Background(), NewPlanner(reg, osInfo))
+	require.NoError(t, err)
+	assert.False(t, plan.Tasks[0].Spec.Verified)
+	assert.False(t, plan.Tasks[0].Spec.Pinned)
+	assert.True(t, plan.Tasks[0].RunsScript())
+}
+
+func TestSavedPlan_PlanRefresh(t *testing.T) {
+	osInfo := &detector.OSInfo{Platform: "linux", Distro: "ubuntu", Family: "debian", Arch: "amd64"}
+	reg := cachedRegistry(t, map[string]string{"code": codePackage})
+
+	// code is installed from latest/stable, but devpack.yaml asks for latest/edge
+	runner := provider.NewMockRunner().
+		On("snap list code", provider.MockResponse{Stdout: "Name  Version  Rev  Tracking       Publisher  Notes\n" +
+			"code  1.92.0   166  latest/stable  vscode✓    classic\n"})
+	defer provider.SetDefaultRunner(provider.SetDefaultRunner(runner))
+
+	p := NewPlanner(reg, osInfo)
+	p.SetOverrides(map[string]config.Override{"code": {Channel: "latest/edge"}})
+	plan, err := p.CreatePlan(context.Background(), []string{"code"})
+	require.NoError(t, err)
+
+	task := plan.Tasks[0]
+	assert.False(t, task.Installed)
+	assert.True(t, task.Present(), "remove and update still see the snap")
+	assert.Equal(t, "tracks latest/stable instead of latest/edge", task.Drift)
+	command := task.Provider.InstallCommand(*task.Spec)
+	assert.Contains(t, command, "snap refresh code --channel=latest/edge --classic")
+
+	// The plan file and the plan applied from it show the same command
+	saved := NewSavedPlan(plan, "", time.Now())
+	assert.Equal(t, command, saved.Tasks[0].Command)
+
+	applied, err := saved.Plan(context.Background(), p)
+	require.NoError(t, err)
+	assert.Equal(t, command, applied.Tasks[0].Provider.InstallCommand(*applied.Tasks[0].Spec))
+}
@@ -1,9 +1,17 @@
 package planner
 
 import (
+	"context"
 	"fmt"
+	"io"
+	"os"
+	"strings"
+	"time"
 
+	"github.com/Litchi-group/unipm/internal/config"
 	"github.com/Litchi-group/unipm/internal/detector"
+	"github.com/Litchi-group/unipm/internal/logger"
+	"github.com/Litchi-group/unipm/internal/progress"
 	"github.com/Litchi-group/unipm/internal/provider"
 	"github.com/Litchi-group/unipm/internal/registry"
 )
@@ -11,15 +19,60 @@ import (
 // InstallTask represents a single installation task
 type InstallTask struct {
 	PackageID string
+	Version   string // Version requested in devpack.yaml (e.g., "18.x")
 	Spec      *provider.ProviderSpec
 	Provider  provider.Provider
-	Installed bool
+	Installed bool   // Installed as the spec asks
+	Drift     string // How the installed package differs from the spec ("" if it matches or is missing)
+	Checksum  string // Registry checksum of the package definition ("" if unsigned)
+}
+
+// Present reports whether the task's package is installed, even if it
+// differs from the spec
+func (t *InstallTask) Present() bool {
+	return t.Installed || t.Drift != ""
+}
+
+// Label returns the package ID with its requested version, if any
+func (t *InstallTask) Label() string {
+	if t.Version != "" {
+		return t.PackageID + "@" + t.Version
+	}
+	return t.PackageID
+}
+
+// RunsScript reports whether the task executes a shell script from the registry
+func (t *InstallTask) RunsScript() bool {
+	return t.Spec.Type == "script"
+}
+
+// IgnoresVersion reports whether the task requests a version its provider
+// cannot select, so that whatever version it provides is installed
+func (t *InstallTask) IgnoresVersion() bool {
+	if t.Version == "" {
+		return false
+	}
+	reg, ok := provider.Lookup(t.Spec.Type)
+	return ok && !reg.Capabilities.SupportsVersions
+}
+
+// NeedsRoot reports whether the task's provider runs with elevated privileges
+func (t *InstallTask) NeedsRoot() bool {
+	reg, ok := provider.Lookup(t.Spec.Type)
+	return ok && reg.Capabilities.NeedsRoot
 }
 
 // Plan represents an installation plan
 type Plan struct {
-	Tasks  []*InstallTask
-	OSInfo *detector.OSInfo
+	Steps       []provider.Step // Preparatory steps run once before the tasks
+	Tasks       []*InstallTask
+	OSInfo      *detector.OSInfo
+	TaskTimeout time.Duration // Limit for each task in Execute (0 for none)
+	Progress    string        // progress.ModeStream (default) or progress.ModeSpinner
+	Log         io.Writer     // Receives the full output of every task (nil for none)
+	Out         io.Writer     // Receives command output and the spinner (default os.Stdout)
+	Reporter    Reporter      // Displays progress while running (nil for none)
+	Offline     bool          // Planned for another system; installed state is unknown
 }
 
 // Planner generates installation plans
@@ -28,6 +81,8 @@ type Planner struct {
 	resolver    *registry.Resolver
 	depResolver *registry.DependencyResolver
 	osInfo      *detector.OSInfo
+	overrides   map[string]config.Override
+	offline     bool
 }
 
 // NewPlanner creates a new Planner
@@ -40,108 +95,367 @@ func NewPlanner(reg *registry.Registry, osInfo *detector.OSInfo) *Planner {
 	}
 }
 
+// SetOverrides sets the devpack overrides applied on top of the registry mappings
+func (p *Planner) SetOverrides(overrides map[string]config.Override) {
+	p.overrides = overrides
+}
+
+// SetOffline makes plans for osInfo without touching this machine: providers
+// are not checked for availability, installed state is unknown and no
+// preparation steps are collected
+func (p *Planner) SetOffline(offline bool) {
+	p.offline = offline
+}
+
 // CreatePlan creates an installation plan for the given package IDs
-// Resolves dependencies and orders packages correctly
-func (p *Planner) CreatePlan(packageIDs []string) (*Plan, error) {
+// Resolves dependencies and orders packages correctly.
+// IDs may carry a version (e.g., "node@18"); a package requested at several
+// versions gets one task per version.
+func (p *Planner) CreatePlan(ctx context.Context, packageIDs []string) (*Plan, error) {
+	names, versions := splitVersions(packageIDs)
+
 	// Resolve dependencies (returns packages in installation order)
-	orderedIDs, err := p.depResolver.Resolve(packageIDs)
+	orderedIDs, err := p.depResolver.Resolve(names)
 	if err != nil {
 		return nil, fmt.Errorf("dependency resolution failed: %w", err)
 	}
 
 	plan := &Plan{
-		Tasks:  make([]*InstallTask, 0, len(orderedIDs)),
-		OSInfo: p.osInfo,
+		Tasks:   make([]*InstallTask, 0, len(orderedIDs)),
+		OSInfo:  p.osInfo,
+		Offline: p.offline,
 	}
 
 	for _, packageID := range orderedIDs {
-		// Resolve package to provider spec
-		spec, err := p.resolver.Resolve(packageID)
+		pkg, spec, prov, err := p.resolve(packageID)
 		if err != nil {
-			return nil, fmt.Errorf("failed to resolve %s: %w", packageID, err)
+			return nil, err
 		}
 
-		// Get provider instance
-		prov, err := provider.GetProviderByType(spec.Type)
-		if err != nil {
-			return nil, fmt.Errorf("failed to get provider for %s: %w", packageID, err)
+		requested := versions[packageID]
+		if len(requested) == 0 {
+			requested = []string{""}
 		}
 
-		// Check if provider is available
-		if !prov.IsAvailable() {
-			return nil, fmt.Errorf("provider %s is not available for %s", prov.Name(), packageID)
-		}
+		for _, version := range requested {
+			versionSpec := *spec
+			if version != "" {
+				versionSpec.Version = version
+			}
 
-		// Check if already installed
-		installed := prov.IsInstalled(*spec)
+			task := &InstallTask{
+				PackageID: packageID,
+				Version:   version,
+				Spec:      &versionSpec,
+				Provider:  prov,
+				Checksum:  pkg.Checksum,
+			}
 
-		task := &InstallTask{
-			PackageID: packageID,
-			Spec:      spec,
-			Provider:  prov,
-			Installed: installed,
+			if task.IgnoresVersion() {
+				logger.Warn("%s installs %s without choosing its version; %s is ignored",
+					task.Spec.Type, packageID, version)
+			}
+
+			// Check if already installed
+			if !p.offline {
+				task.Installed, task.Drift = checkInstalled(ctx, prov, task.Spec)
+			}
+
+			plan.Tasks = append(plan.Tasks, task)
 		}
+	}
 
-		plan.Tasks = append(plan.Tasks, task)
+	if !p.offline {
+		plan.Steps = prepareSteps(ctx, plan.Tasks)
 	}
 
 	return plan, nil
 }
 
-// Execute executes the installation plan
-func (plan *Plan) Execute(dryRun bool) error {
-	installedCount := 0
-	skippedCount := 0
+// resolve loads a package and resolves it to the provider spec and provider
+// that install it on osInfo, applying devpack overrides. Unless offline, an
+// unavailable provider is replaced by its fallback (e.g., asdf for mise).
+func (p *Planner) resolve(packageID string) (*registry.Package, *provider.ProviderSpec, provider.Provider, error) {
+	pkg, err := p.registry.LoadPackage(packageID)
+	if err != nil {
+		return nil, nil, nil, fmt.Errorf("failed to resolve %s: %w", packageID, err)
+	}
+	spec, err := p.resolver.Resolve(packageID)
+	if err != nil {
+		return nil, nil, nil, fmt.Errorf("failed to resolve %s: %w", packageID, err)
+	}
+	if override, ok := p.overrides[packageID]; ok {
+		applyOverride(spec, override)
+		if err := checkPin(pkg, spec, override); err != nil {
+			return nil, nil, nil, err
+		}
+	}
 
-	for _, task := range plan.Tasks {
-		if task.Installed {
-			fmt.Printf("Installing %s...\n", task.PackageID)
-			fmt.Printf("  ⊙ Already installed\n")
-			skippedCount++
-			continue
+	prov, err := provider.GetProviderForOS(spec.Type, p.osInfo)
+	if err != nil {
+		return nil, nil, nil, fmt.Errorf("failed to get provider for %s: %w", packageID, err)
+	}
+
+	if !p.offline && !prov.IsAvailable() {
+		fallback, fallbackType, ok := provider.GetFallbackProvider(spec.Type, p.osInfo)
+		if !ok {
+			return nil, nil, nil, fmt.Errorf("provider %s is not available for %s", prov.Name(), packageID)
 		}
+		prov, spec.Type = fallback, fallbackType
+	}
 
-		fmt.Printf("Installing %s...\n", task.PackageID)
+	return pkg, spec, prov, nil
+}
 
-		if dryRun {
-			fmt.Printf("  [dry-run] %s\n", task.Provider.InstallCommand(*task.Spec))
-			installedCount++
+// prepareSteps collects the preparatory steps the providers of pending tasks
+// need, running each distinct step once
+func prepareSteps(ctx context.Context, tasks []*InstallTask) []provider.Step {
+	var order []string
+	preparers := make(map[string]provider.Preparer)
+	specs := make(map[string][]provider.ProviderSpec)
+
+	for _, task := range tasks {
+		preparer, ok := task.Provider.(provider.Preparer)
+		if !ok || task.Installed {
 			continue
 		}
 
-		// Execute installation
-		if err := task.Provider.Install(*task.Spec); err != nil {
-			return fmt.Errorf("failed to install %s: %w", task.PackageID, err)
+		name := task.Provider.Name()
+		if _, seen := preparers[name]; !seen {
+			preparers[name] = preparer
+			order = append(order, name)
 		}
+		specs[name] = append(specs[name], *task.Spec)
+	}
 
-		fmt.Printf("  ✓ Installed\n")
-		installedCount++
+	var steps []provider.Step
+	seen := make(map[string]bool)
+	for _, name := range order {
+		for _, step := range preparers[name].PrepareSteps(ctx, specs[name]) {
+			if !seen[step.Key] {
+				seen[step.Key] = true
+				steps = append(steps, step)
+			}
+		}
 	}
 
-	fmt.Println()
+	return steps
+}
 
-	if dryRun {
-		fmt.Printf("Dry run complete. Would install %d, skip %d.\n", installedCount, skippedCount)
-	} else {
-		fmt.Printf("Done! %d installed, %d skipped.\n", installedCount, skippedCount)
+// applyOverride replaces the mapping's settings with those set in a devpack override
+func applyOverride(spec *provider.ProviderSpec, override config.Override) {
+	if override.Channel != "" {
+		spec.Channel = override.Channel
+	}
+	if override.Revision != 0 {
+		spec.Revision = override.Revision
 	}
+	if override.Devmode {
+		spec.Devmode = true
+	}
+	if override.Classic {
+		spec.Classic = true
+	}
+}
+
+// checkInstalled reports whether a package is installed as its spec asks,
+// and otherwise how an installed package differs from it. For providers
+// detecting drift, spec.Refresh records that the package is installed, so
+// that its install command refreshes it.
+func checkInstalled(ctx context.Context, prov provider.Provider, spec *provider.ProviderSpec) (bool, string) {
+	installed := prov.IsInstalled(ctx, *spec)
+	drifts, ok := prov.(provider.DriftDetector)
+	if !installed || !ok {
+		return installed, ""
+	}
+
+	spec.Refresh = true
+	drift := drifts.Drift(ctx, *spec)
+	return drift == "", drift
+}
 
+// checkPin compares a package definition with the checksum pinned in
+// devpack.yaml, if any, and marks the spec pinned when they match. Unlike
+// the checksum embedded in the definition, a pin cannot be recomputed by
+// whoever edits the registry.
+func checkPin(pkg *registry.Package, spec *provider.ProviderSpec, override config.Override) error {
+	if override.Checksum == "" {
+		return nil
+	}
+	if !strings.EqualFold(override.Checksum, pkg.Digest) {
+		return fmt.Errorf("package definition of %s does not match the checksum pinned in devpack.yaml: "+
+			"it is now %s (review it with 'unipm info %s' before updating the pin)", pkg.ID, pkg.Digest, pkg.ID)
+	}
+	spec.Pinned = true
 	return nil
 }
 
-// Print prints the plan without executing
-func (plan *Plan) Print() {
-	fmt.Printf("Plan for %s:\n\n", plan.OSInfo.String())
+// splitVersions splits "name@version" entries into unique package names and
+// the versions requested for each
+func splitVersions(packageIDs []string) ([]string, map[string][]string) {
+	var names []string
+	versions := make(map[string][]string)
+	seen := make(map[string]bool)
 
+	for _, id := range packageIDs {
+		pkgSpec := config.ParsePackageSpec(id)
+
+		if _, ok := versions[pkgSpec.Name]; !ok {
+			names = append(names, pkgSpec.Name)
+			versions[pkgSpec.Name] = nil
+		}
+
+		if pkgSpec.Version != "" && !seen[id] {
+			versions[pkgSpec.Name] = append(versions[pkgSpec.Name], pkgSpec.Version)
+		}
+		seen[id] = true
+	}
+
+	return names, versions
+}
+
+// CheckPrivilege verifies that privileges can be escalated if any task
+// selected by pending needs root, before anything is run
+func (plan *Plan) CheckPrivilege(pending func(*InstallTask) bool) error {
 	for _, task := range plan.Tasks {
-		cmd := task.Provider.InstallCommand(*task.Spec)
-		status := ""
+		if pending(task) && task.NeedsRoot() {
+			return provider.CheckPrivilege()
+		}
+	}
+	return nil
+}
+
+// Execute executes the installation plan, reporting each step and task.
+// If ctx is cancelled, the running command is stopped and the reporter is
+// told which tasks completed, which was interrupted and which never ran.
+func (plan *Plan) Execute(ctx context.Context, dryRun bool) error {
+	report := plan.Report()
+
+	if !dryRun {
+		notInstalled := func(t *InstallTask) bool { return !t.Installed }
+		if err := plan.CheckPrivilege(notInstalled); err != nil {
+			return err
+		}
+	}
+
+	for _, step := range plan.Steps {
+		report.StepStarted(step)
+
+		if dryRun {
+			report.StepFinished(step, StatusDryRun, nil)
+			continue
+		}
+
+		if err := plan.runStep(ctx, step); err != nil {
+			if ctx.Err() != nil {
+				report.StepFinished(step, StatusInterrupted, err)
+				report.Interrupted(plan, 0, false)
+				return fmt.Errorf("interrupted during %s: %w", strings.ToLower(step.Description), ctx.Err())
+			}
+			report.StepFinished(step, StatusFailed, err)
+			return fmt.Errorf("%s failed: %w", step.Description, err)
+		}
+
+		report.StepFinished(step, StatusDone, nil)
+	}
+
+	for i, task := range plan.Tasks {
+		if ctx.Err() != nil {
+			report.Interrupted(plan, i, false)
+			return fmt.Errorf("stopped before installing %s: %w", task.Label(), ctx.Err())
+		}
+
+		report.TaskStarted(task, ActionInstall)
+
 		if task.Installed {
-			status = " (already installed)"
+			report.TaskFinished(task, ActionInstall, StatusSkipped, nil)
+			continue
+		}
+
+		if dryRun {
+			report.TaskFinished(task, ActionInstall, StatusDryRun, nil)
+			continue
 		}
-		fmt.Printf("  %s → %s%s\n", task.PackageID, cmd, status)
+
+		// Execute installation
+		if err := plan.RunTask(ctx, task, task.Provider.Install); err != nil {
+			if ctx.Err() != nil {
+				report.Interrupted(plan, i, true)
+				return fmt.Errorf("interrupted while installing %s: %w", task.Label(), ctx.Err())
+			}
+			installErr := provider.NewInstallError(task.Label(), task.Provider, err)
+			report.TaskFinished(task, ActionInstall, StatusFailed, installErr)
+			return installErr
+		}
+
+		report.TaskFinished(task, ActionInstall, StatusDone, nil)
+	}
+
+	report.Done(ActionInstall, dryRun)
+
+	return nil
+}
+
+// RunTask runs a provider operation for a task within the plan's task timeout
+func (plan *Plan) RunTask(ctx context.Context, task *InstallTask, op func(context.Context, provider.ProviderSpec) error) error {
+	if plan.TaskTimeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, plan.TaskTimeout)
+		defer cancel()
+	}
+
+	ctx, done := plan.output(ctx, task.PackageID, task.Label())
+	err := op(ctx, *task.Spec)
+	done()
+
+	if err != nil && ctx.Err() == context.DeadlineExceeded {
+		return fmt.Errorf("timed out after %s: %w", plan.TaskTimeout, err)
+	}
+	return err
+}
+
+// runStep runs a preparatory step within the plan's task timeout
+func (plan *Plan) runStep(ctx context.Context, step provider.Step) error {
+	if plan.TaskTimeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, plan.TaskTimeout)
+		defer cancel()
+	}
+
+	ctx, done := plan.output(ctx, step.Key, step.Key)
+	defer done()
+
+	return step.Run(ctx)
+}
+
+// output returns a context streaming command output to the display, prefixed
+// with name, and to the run log, prefixed with label, and a function to call
+// when the commands are done
+func (plan *Plan) output(ctx context.Context, name, label string) (context.Context, func()) {
+	var display, status io.Writer
+	var stop func()
+
+	out := plan.Out
+	if out == nil {
+		out = os.Stdout
+	}
+
+	if plan.Progress == progress.ModeSpinner {
+		spinner := progress.NewSpinner(out, label)
+		display, status, stop = spinner, spinner.Status(), spinner.Stop
+	} else {
+		prefixed := progress.NewPrefixWriter(out, fmt.Sprintf("    [%s] ", name))
+		display, status, stop = prefixed, out, func() { _ = prefixed.Flush() }
 	}
 
-	fmt.Println()
-	fmt.Println("To apply this plan, run 'unipm apply'.")
+	if plan.Log == nil {
+		return provider.WithOutput(ctx, display, status), stop
+	}
+
+	logged := progress.NewPrefixWriter(plan.Log, fmt.Sprintf("[%s] ", label))
+	output := io.MultiWriter(display, logged)
+	return provider.WithOutput(ctx, output, io.MultiWriter(status, logged)), func() {
+		stop()
+		_ = logged.Flush()
+	}
 }
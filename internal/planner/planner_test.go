@@ -0,0 +1,163 @@
+package planner
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/Litchi-group/unipm/internal/config"
+	"github.com/Litchi-group/unipm/internal/detector"
+	"github.com/Litchi-group/unipm/internal/provider"
+	"github.com/Litchi-group/unipm/internal/registry"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const typescriptPackage = `id: typescript
+name: TypeScript
+homepage: https://www.typescriptlang.org
+providers:
+  all:
+    - type: npm
+      name: typescript
+`
+
+// cachedRegistry returns a registry whose cache holds the given definitions,
+// so no package is fetched
+func cachedRegistry(t *testing.T, definitions map[string]string) *registry.Registry {
+	t.Helper()
+
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	t.Setenv("USERPROFILE", home)
+
+	cacheDir := filepath.Join(home, registry.CacheDir)
+	require.NoError(t, os.MkdirAll(cacheDir, 0755))
+	for id, definition := range definitions {
+		require.NoError(t, os.WriteFile(filepath.Join(cacheDir, id+".yaml"), []byte(definition), 0644))
+	}
+	return registry.NewRegistry()
+}
+
+func TestPlanner_SetOffline(t *testing.T) {
+	reg := cachedRegistry(t, map[string]string{"typescript": typescriptPackage})
+	osInfo := &detector.OSInfo{Platform: "linux", Distro: "ubuntu", Family: "debian", Arch: "amd64"}
+
+	// npm is missing and nothing may run on this machine
+	runner := provider.NewMockRunner()
+	runner.SetAvailable("npm", false)
+	defer provider.SetDefaultRunner(provider.SetDefaultRunner(runner))
+
+	p := NewPlanner(reg, osInfo)
+	_, err := p.CreatePlan(context.Background(), []string{"typescript"})
+	assert.ErrorContains(t, err, "provider npm is not available for typescript")
+
+	p.SetOffline(true)
+	plan, err := p.CreatePlan(context.Background(), []string{"typescript"})
+	require.NoError(t, err)
+
+	assert.True(t, plan.Offline)
+	assert.Same(t, osInfo, plan.OSInfo)
+	assert.Empty(t, plan.Steps)
+	require.Len(t, plan.Tasks, 1)
+	assert.Equal(t, "typescript", plan.Tasks[0].PackageID)
+	assert.False(t, plan.Tasks[0].Installed)
+	assert.Empty(t, runner.CommandLines(), "offline plans run no commands")
+}
+
+const kubectlPackage = `id: kubectl
+name: kubectl
+homepage: https://kubernetes.io
+providers:
+  all:
+    - type: binary
+      name: kubectl
+      version: 1.30.0
+      url: https://dl.k8s.io/v{{version}}/bin/{{os}}/{{arch}}/kubectl
+      checksums:
+        linux-amd64: 0000000000000000000000000000000000000000000000000000000000000000
+        windows-arm64: 1111111111111111111111111111111111111111111111111111111111111111
+  linux:
+    - type: apt
+      name: kubectl
+`
+
+func TestPlanner_OtherOS(t *testing.T) {
+	reg := cachedRegistry(t, map[string]string{"kubectl": kubectlPackage})
+	defer provider.SetDefaultRunner(provider.SetDefaultRunner(provider.NewMockRunner()))
+
+	// Planned on any host, commands are those of the target system
+	windows := &detector.OSInfo{Platform: "windows", Arch: "arm64"}
+	p := NewPlanner(reg, windows)
+	p.SetOffline(true)
+
+	plan, err := p.CreatePlan(context.Background(), []string{"kubectl"})
+	require.NoError(t, err)
+	require.Len(t, plan.Tasks, 1)
+
+	command := plan.Tasks[0].Provider.InstallCommand(*plan.Tasks[0].Spec)
+	assert.Contains(t, command, "download https://dl.k8s.io/v1.30.0/bin/windows/arm64/kubectl → ")
+	assert.True(t, strings.HasSuffix(command, "kubectl.exe"), command)
+
+	// apt does not support fedora, and nothing else is mapped for linux
+	fedora := &detector.OSInfo{Platform: "linux", Distro: "fedora", Family: "rhel", Arch: "amd64"}
+	p = NewPlanner(reg, fedora)
+	p.SetOffline(true)
+
+	_, err = p.CreatePlan(context.Background(), []string{"kubectl"})
+	assert.ErrorContains(t, err, "no provider available for kubectl on linux (fedora): apt not supported there")
+}
+
+func TestPlanner_Pin(t *testing.T) {
+	reg := cachedRegistry(t, map[string]string{"tool": toolPackage})
+	osInfo := &detector.OSInfo{Platform: "linux", Distro: "ubuntu", Family: "debian", Arch: "amd64"}
+	digest := registry.GenerateChecksum([]byte(toolPackage))
+
+	tests := []struct {
+		name     string
+		checksum string
+		pinned   bool
+		err      string
+	}{
+		{name: "not pinned"},
+		{name: "pinned", checksum: digest, pinned: true},
+		{name: "pinned in upper case", checksum: strings.ToUpper(digest), pinned: true},
+		{name: "definition changed", checksum: strings.Repeat("0", 64), err: "package definition of tool does not match the checksum pinned in devpack.yaml"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := NewPlanner(reg, osInfo)
+			p.SetOffline(true)
+			p.SetOverrides(map[string]config.Override{"tool": {Checksum: tt.checksum}})
+
+			plan, err := p.CreatePlan(context.Background(), []string{"tool"})
+			if tt.err != "" {
+				assert.ErrorContains(t, err, tt.err)
+				assert.ErrorContains(t, err, digest, "the error shows the checksum to review")
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.pinned, plan.Tasks[0].Spec.Pinned)
+		})
+	}
+}
+
+func TestPlanner_IgnoresVersion(t *testing.T) {
+	reg := cachedRegistry(t, map[string]string{"typescript": typescriptPackage, "kubectl": kubectlPackage})
+	windows := &detector.OSInfo{Platform: "windows", Arch: "arm64"}
+
+	p := NewPlanner(reg, windows)
+	p.SetOffline(true)
+	plan, err := p.CreatePlan(context.Background(), []string{"typescript@5", "kubectl@1.29.0"})
+	require.NoError(t, err)
+
+	// npm installs its latest version; binary downloads the requested one
+	ignored := make(map[string]bool)
+	for _, task := range plan.Tasks {
+		ignored[task.Label()] = task.IgnoresVersion()
+	}
+	assert.Equal(t, map[string]bool{"typescript@5": true, "kubectl@1.29.0": false}, ignored)
+}
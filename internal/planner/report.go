@@ -0,0 +1,61 @@
+package planner
+
+import "github.com/Litchi-group/unipm/internal/provider"
+
+// Actions run on the tasks of a plan
+const (
+	ActionInstall = "install"
+	ActionUpdate  = "update"
+	ActionRemove  = "remove"
+)
+
+// Outcomes of steps and tasks
+const (
+	StatusDone        = "done"        // Ran successfully
+	StatusSkipped     = "skipped"     // Nothing to do (already installed, or not installed)
+	StatusDryRun      = "dry_run"     // Would have run
+	StatusFailed      = "failed"      // Ran and failed
+	StatusInterrupted = "interrupted" // Stopped while running; state unknown
+	StatusNotRun      = "not_run"     // Never started because the run stopped
+)
+
+// Reporter displays a plan as it runs. Execute reports to it, as do
+// commands that run tasks themselves (e.g., update and remove).
+type Reporter interface {
+	// StepStarted is called before a preparatory step runs
+	StepStarted(step provider.Step)
+
+	// StepFinished is called with the outcome of a step
+	StepFinished(step provider.Step, status string, err error)
+
+	// TaskStarted is called before an action runs for a task
+	TaskStarted(task *InstallTask, action string)
+
+	// TaskFinished is called with the outcome of a task
+	TaskFinished(task *InstallTask, action, status string, err error)
+
+	// Interrupted is called when the run is cancelled, with the index of
+	// the current task and whether it had started
+	Interrupted(plan *Plan, current int, started bool)
+
+	// Done is called after every task has finished
+	Done(action string, dryRun bool)
+}
+
+// nopReporter discards reports, for plans run without a reporter
+type nopReporter struct{}
+
+func (nopReporter) StepStarted(provider.Step)                        {}
+func (nopReporter) StepFinished(provider.Step, string, error)        {}
+func (nopReporter) TaskStarted(*InstallTask, string)                 {}
+func (nopReporter) TaskFinished(*InstallTask, string, string, error) {}
+func (nopReporter) Interrupted(*Plan, int, bool)                     {}
+func (nopReporter) Done(string, bool)                                {}
+
+// Report returns the plan's reporter, or one that discards reports
+func (plan *Plan) Report() Reporter {
+	if plan.Reporter == nil {
+		return nopReporter{}
+	}
+	return plan.Reporter
+}
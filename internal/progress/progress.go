@@ -0,0 +1,199 @@
+package progress
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Progress display modes
+const (
+	ModeStream  = "stream"  // Print every output line, prefixed with the package
+	ModeSpinner = "spinner" // Collapse output into a spinner showing the last line
+)
+
+// spinnerFrames are drawn in turn while a command runs
+var spinnerFrames = []string{"⠋", "⠙", "⠹", "⠸", "⠼", "⠴", "⠦", "⠧", "⠇", "⠏"}
+
+// spinnerWidth bounds the spinner line so it never wraps
+const spinnerWidth = 78
+
+// IsTerminal reports whether f is an interactive terminal
+func IsTerminal(f *os.File) bool {
+	info, err := f.Stat()
+	if err != nil {
+		return false
+	}
+	return info.Mode()&os.ModeCharDevice != 0
+}
+
+// PrefixWriter writes each line of output to w with a prefix.
+// Carriage returns (used by progress bars) also end a line.
+type PrefixWriter struct {
+	mu     sync.Mutex
+	w      io.Writer
+	prefix string
+	buf    []byte
+}
+
+// NewPrefixWriter creates a PrefixWriter
+func NewPrefixWriter(w io.Writer, prefix string) *PrefixWriter {
+	return &PrefixWriter{w: w, prefix: prefix}
+}
+
+// Write buffers p and writes every complete line
+func (pw *PrefixWriter) Write(p []byte) (int, error) {
+	pw.mu.Lock()
+	defer pw.mu.Unlock()
+
+	pw.buf = append(pw.buf, p...)
+	for {
+		i := bytes.IndexAny(pw.buf, "\r\n")
+		if i < 0 {
+			break
+		}
+		if err := pw.writeLine(pw.buf[:i]); err != nil {
+			return len(p), err
+		}
+		pw.buf = pw.buf[i+1:]
+	}
+
+	return len(p), nil
+}
+
+// Flush writes any incomplete last line
+func (pw *PrefixWriter) Flush() error {
+	pw.mu.Lock()
+	defer pw.mu.Unlock()
+
+	if len(pw.buf) == 0 {
+		return nil
+	}
+	err := pw.writeLine(pw.buf)
+	pw.buf = nil
+	return err
+}
+
+// writeLine writes one prefixed line, skipping blank ones
+func (pw *PrefixWriter) writeLine(line []byte) error {
+	if len(bytes.TrimSpace(line)) == 0 {
+		return nil
+	}
+	_, err := fmt.Fprintf(pw.w, "%s%s\n", pw.prefix, line)
+	return err
+}
+
+// Spinner collapses command output into a single redrawn line showing the
+// label and the last line of output
+type Spinner struct {
+	mu    sync.Mutex
+	w     io.Writer
+	label string
+	last  string
+	buf   []byte
+	frame int
+	done  chan struct{}
+	wg    sync.WaitGroup
+}
+
+// NewSpinner starts a spinner drawing to w
+func NewSpinner(w io.Writer, label string) *Spinner {
+	s := &Spinner{
+		w:     w,
+		label: label,
+		done:  make(chan struct{}),
+	}
+
+	s.wg.Add(1)
+	go s.run()
+
+	return s
+}
+
+// Write records the last complete line of output
+func (s *Spinner) Write(p []byte) (int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.buf = append(s.buf, p...)
+	for {
+		i := bytes.IndexAny(s.buf, "\r\n")
+		if i < 0 {
+			break
+		}
+		if line := strings.TrimSpace(string(s.buf[:i])); line != "" {
+			s.last = line
+		}
+		s.buf = s.buf[i+1:]
+	}
+
+	return len(p), nil
+}
+
+// Stop stops the spinner and clears its line
+func (s *Spinner) Stop() {
+	close(s.done)
+	s.wg.Wait()
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	_, _ = fmt.Fprint(s.w, "\r\033[K")
+}
+
+// Status returns a writer that prints lines above the spinner
+func (s *Spinner) Status() io.Writer {
+	return spinnerStatus{s}
+}
+
+// spinnerStatus writes complete lines above a running spinner
+type spinnerStatus struct {
+	s *Spinner
+}
+
+func (st spinnerStatus) Write(p []byte) (int, error) {
+	st.s.mu.Lock()
+	defer st.s.mu.Unlock()
+
+	if _, err := fmt.Fprint(st.s.w, "\r\033[K"); err != nil {
+		return 0, err
+	}
+	return st.s.w.Write(p)
+}
+
+// run redraws the spinner until stopped
+func (s *Spinner) run() {
+	defer s.wg.Done()
+
+	ticker := time.NewTicker(100 * time.Millisecond)
+	defer ticker.Stop()
+
+	for {
+		s.draw()
+		select {
+		case <-s.done:
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// draw renders the current frame
+func (s *Spinner) draw() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	line := fmt.Sprintf("  %s %s", spinnerFrames[s.frame%len(spinnerFrames)], s.label)
+	if s.last != "" {
+		line += ": " + s.last
+	}
+	if runes := []rune(line); len(runes) > spinnerWidth {
+		line = string(runes[:spinnerWidth-1]) + "…"
+	}
+
+	s.frame++
+	_, _ = fmt.Fprintf(s.w, "\r\033[K%s", line)
+}
@@ -0,0 +1,21 @@
+package progress
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPrefixWriter(t *testing.T) {
+	var out bytes.Buffer
+	w := NewPrefixWriter(&out, "[git] ")
+
+	_, err := w.Write([]byte("Reading package lists...\nProgress: 10%\rProgress: 100%\n\nDone"))
+	require.NoError(t, err)
+	assert.Equal(t, "[git] Reading package lists...\n[git] Progress: 10%\n[git] Progress: 100%\n", out.String())
+
+	require.NoError(t, w.Flush())
+	assert.Equal(t, "[git] Reading package lists...\n[git] Progress: 10%\n[git] Progress: 100%\n[git] Done\n", out.String())
+}
@@ -1,13 +1,42 @@
 package provider
 
 import (
+	"context"
 	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
 	"strings"
+	"time"
 )
 
+func init() {
+	mustRegister(Registration{
+		Name:           "apt",
+		Types:          []string{"apt"},
+		Platforms:      []string{"linux"},
+		DistroFamilies: []string{"debian"},
+		Default:        true,
+		Guide: `APT is not installed.
+APT comes pre-installed on Debian-based systems (Ubuntu, Debian).
+If you're not on a Debian-based system, unipm may not support your distribution yet.`,
+		Capabilities: Capabilities{NeedsRoot: true},
+		New:          func() Provider { return NewAptProvider() },
+	})
+}
+
+// AptEtcDir is the APT configuration directory with the sources lists and keyrings
+const AptEtcDir = "/etc/apt"
+
+// aptListsMaxAge is how old package lists may be before they are refreshed
+const aptListsMaxAge = 48 * time.Hour
+
 // AptProvider handles APT package management
 type AptProvider struct {
 	BaseProvider
+	client   *http.Client
+	listsDir string // Downloaded package lists (/var/lib/apt/lists)
+	etcDir   string // APT configuration with the sources lists (/etc/apt)
 }
 
 // NewAptProvider creates a new APT provider
@@ -17,24 +46,24 @@ func NewAptProvider() *AptProvider {
 			name:       "apt",
 			executable: "apt",
 		},
+		client:   &http.Client{Timeout: time.Minute},
+		listsDir: "/var/lib/apt/lists",
+		etcDir:   AptEtcDir,
 	}
 }
 
 // Install installs a package using APT
-func (p *AptProvider) Install(spec ProviderSpec) error {
+func (p *AptProvider) Install(ctx context.Context, spec ProviderSpec) error {
 	args := []string{"install", "-y", spec.Name}
 
-	fmt.Printf("  → %s\n", FormatCommand("sudo apt", args...))
-
-	// APT requires sudo
-	return execCommandSilent("sudo", append([]string{"apt"}, args...)...)
+	return p.executePrivileged(ctx, args...)
 }
 
 // IsInstalled checks if a package is installed
-func (p *AptProvider) IsInstalled(spec ProviderSpec) bool {
+func (p *AptProvider) IsInstalled(ctx context.Context, spec ProviderSpec) bool {
 	args := []string{"list", "--installed", spec.Name}
 
-	output, err := execCommand("apt", args...)
+	output, err := p.execCommand(ctx, "apt", args...)
 
 	// Check if package name appears in installed list
 	if err != nil {
@@ -53,21 +82,118 @@ func (p *AptProvider) IsInstalled(spec ProviderSpec) bool {
 // InstallCommand returns the command that would be executed
 func (p *AptProvider) InstallCommand(spec ProviderSpec) string {
 	args := []string{"install", "-y", spec.Name}
-	return FormatCommand("sudo apt", args...)
+	return FormatPrivileged("apt", args...)
 }
 
-// Remove removes a package using APT
-func (p *AptProvider) Remove(spec ProviderSpec) error {
+// Remove removes a package using APT, and its repository if cleanup is enabled
+func (p *AptProvider) Remove(ctx context.Context, spec ProviderSpec) error {
 	args := []string{"remove", "-y", spec.Name}
 
-	fmt.Printf("  → %s\n", FormatCommand("sudo apt", args...))
+	if err := p.executePrivileged(ctx, args...); err != nil {
+		return err
+	}
 
-	// APT requires sudo
-	return execCommandSilent("sudo", append([]string{"apt"}, args...)...)
+	if cleanupRepositories && spec.Repo != nil {
+		return p.removeRepo(ctx, spec.Repo)
+	}
+	return nil
 }
 
 // RemoveCommand returns the uninstall command
 func (p *AptProvider) RemoveCommand(spec ProviderSpec) string {
 	args := []string{"remove", "-y", spec.Name}
-	return FormatCommand("sudo apt", args...)
+	cmd := FormatPrivileged("apt", args...)
+
+	if cleanupRepositories && spec.Repo != nil {
+		cmd += " && " + FormatPrivileged("rm", p.repoRemoveArgs(spec.Repo)...)
+	}
+	return cmd
+}
+
+// PrepareSteps returns steps adding missing third-party repositories, then
+// an apt-get update step when repositories were added, the package lists
+// are missing or stale, or the refresh policy requires it
+func (p *AptProvider) PrepareSteps(ctx context.Context, specs []ProviderSpec) []Step {
+	if len(specs) == 0 {
+		return nil
+	}
+
+	steps := p.repoSteps(specs)
+	if refreshPolicy == RefreshNever {
+		return steps
+	}
+
+	var reason string
+	switch {
+	case len(steps) > 0:
+		reason = "new repositories"
+	case refreshPolicy == RefreshAlways:
+		reason = "requested with --refresh"
+	default:
+		reason = p.staleReason(time.Now())
+	}
+	if reason == "" {
+		return steps
+	}
+
+	return append(steps, Step{
+		Key:         "apt-update",
+		Description: "Refresh apt package lists",
+		Reason:      reason,
+		Command:     FormatPrivileged("apt-get", "update"),
+		Run: func(ctx context.Context) error {
+			return p.executePrivilegedCommand(ctx, "apt-get", "update")
+		},
+	})
+}
+
+// staleReason explains why the package lists need refreshing, or returns ""
+func (p *AptProvider) staleReason(now time.Time) string {
+	updated, ok := newestModTime(p.listsDir, func(name string) bool {
+		return strings.Contains(name, "_Packages")
+	})
+	if !ok {
+		return "package lists are missing"
+	}
+
+	if age := now.Sub(updated); age > aptListsMaxAge {
+		return fmt.Sprintf("package lists are %d days old", int(age.Hours()/24))
+	}
+
+	sourcesChanged, _ := newestModTime(filepath.Join(p.etcDir, "sources.list.d"), func(string) bool { return true })
+	if info, err := os.Stat(filepath.Join(p.etcDir, "sources.list")); err == nil && info.ModTime().After(sourcesChanged) {
+		sourcesChanged = info.ModTime()
+	}
+	if sourcesChanged.After(updated) {
+		return "package sources changed since the last update"
+	}
+
+	return ""
+}
+
+// newestModTime returns the newest modification time of the files in dir
+// whose names match, and whether any matched
+func newestModTime(dir string, match func(name string) bool) (time.Time, bool) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return time.Time{}, false
+	}
+
+	var newest time.Time
+	found := false
+	for _, entry := range entries {
+		if entry.IsDir() || !match(entry.Name()) {
+			continue
+		}
+		info, err := entry.Info()
+		if err != nil {
+			continue
+		}
+		if info.ModTime().After(newest) {
+			newest = info.ModTime()
+		}
+		found = true
+	}
+
+	return newest, found
 }
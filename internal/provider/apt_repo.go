@@ -0,0 +1,233 @@
+package provider
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path"
+	"strings"
+
+	"github.com/Litchi-group/unipm/internal/errors"
+)
+
+// ArmoredKeyHeader starts ASCII-armored PGP keys
+const ArmoredKeyHeader = "-----BEGIN PGP"
+
+// debianArchs maps Go architectures to Debian architectures where they differ
+var debianArchs = map[string]string{
+	"386":     "i386",
+	"arm":     "armhf",
+	"ppc64le": "ppc64el",
+}
+
+// cleanupRepositories removes a package's apt repository when it is removed
+var cleanupRepositories bool
+
+// SetCleanupRepositories sets whether removing an apt package also removes
+// the third-party repository it was installed from
+func SetCleanupRepositories(cleanup bool) {
+	cleanupRepositories = cleanup
+}
+
+// DebianArch returns the Debian architecture name for a Go architecture
+func DebianArch(goarch string) string {
+	if arch, ok := debianArchs[goarch]; ok {
+		return arch
+	}
+	return goarch
+}
+
+// repoSteps returns a step adding each repository that is not configured yet
+func (p *AptProvider) repoSteps(specs []ProviderSpec) []Step {
+	var steps []Step
+	for _, spec := range specs {
+		repo := spec.Repo
+		if repo == nil || p.repoConfigured(repo) {
+			continue
+		}
+
+		steps = append(steps, Step{
+			Key:         "apt-repo-" + repo.Name,
+			Description: "Add apt repository " + repo.Name,
+			Command:     fmt.Sprintf("%s %s → %s", p.expandRepo(repo.Source), p.expandRepo(repo.Suite), p.sourcesPath(repo)),
+			Run: func(ctx context.Context) error {
+				return p.addRepo(ctx, repo)
+			},
+		})
+	}
+	return steps
+}
+
+// repoConfigured reports whether the sources list of a repository matches
+// its definition and its signing key is present
+func (p *AptProvider) repoConfigured(repo *AptRepository) bool {
+	current, err := os.ReadFile(p.sourcesPath(repo))
+	if err != nil {
+		return false
+	}
+
+	for _, keyPath := range p.keyPaths(repo) {
+		if string(current) == p.sourcesLine(repo, keyPath) {
+			_, err := os.Stat(keyPath)
+			return err == nil
+		}
+	}
+	return false
+}
+
+// addRepo installs the signing key and sources list of a repository
+func (p *AptProvider) addRepo(ctx context.Context, repo *AptRepository) error {
+	if strings.Contains(repo.Source+repo.Suite+repo.KeyURL, "{{codename}}") && p.targetOS().Codename == "" {
+		return fmt.Errorf("repository %s needs the distribution codename, which could not be detected", repo.Name)
+	}
+
+	key, err := p.repoKey(ctx, repo)
+	if err != nil {
+		return err
+	}
+
+	keyPath := AptKeyPath(p.etcDir, repo, key)
+	if err := p.installFile(ctx, key, keyPath); err != nil {
+		return err
+	}
+	return p.installFile(ctx, []byte(p.sourcesLine(repo, keyPath)), p.sourcesPath(repo))
+}
+
+// repoKey returns the inline signing key or downloads it
+func (p *AptProvider) repoKey(ctx context.Context, repo *AptRepository) ([]byte, error) {
+	if repo.Key != "" {
+		return []byte(repo.Key), nil
+	}
+
+	url := p.expandRepo(repo.KeyURL)
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := p.client.Do(req)
+	if err != nil {
+		return nil, errors.NewNetworkError(url, "failed to download signing key", err)
+	}
+	defer func() { _ = resp.Body.Close() }()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, errors.NewNetworkError(url, fmt.Sprintf("unexpected status code %d", resp.StatusCode), nil)
+	}
+
+	// Signing keys are small; anything larger is not a key
+	key, err := io.ReadAll(io.LimitReader(resp.Body, 1<<20))
+	if err != nil {
+		return nil, errors.NewNetworkError(url, "failed to read signing key", err)
+	}
+	return key, nil
+}
+
+// installFile writes data to a root-owned file through a temporary file
+func (p *AptProvider) installFile(ctx context.Context, data []byte, dest string) error {
+	tmp, err := os.CreateTemp("", "unipm-apt-*")
+	if err != nil {
+		return err
+	}
+	defer func() { _ = os.Remove(tmp.Name()) }()
+
+	if _, err := tmp.Write(data); err != nil {
+		_ = tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+
+	argv := privileged("install", "-D", "-m", "0644", tmp.Name(), dest)
+	announce(ctx, FormatPrivileged("install", "-D", "-m", "0644", "…", dest))
+	return p.execCommandSilent(ctx, argv[0], argv[1:]...)
+}
+
+// removeRepo deletes the sources list and signing key of a repository
+func (p *AptProvider) removeRepo(ctx context.Context, repo *AptRepository) error {
+	return p.executePrivilegedCommand(ctx, "rm", p.repoRemoveArgs(repo)...)
+}
+
+// repoRemoveArgs returns the rm arguments deleting a repository's files
+func (p *AptProvider) repoRemoveArgs(repo *AptRepository) []string {
+	return append([]string{"-f", p.sourcesPath(repo)}, p.keyPaths(repo)...)
+}
+
+// sourcesLine returns the one-line-style sources entry of a repository
+func (p *AptProvider) sourcesLine(repo *AptRepository, keyPath string) string {
+	return AptSourcesLine(repo, keyPath, p.repoVars()) + "\n"
+}
+
+// sourcesPath returns the sources list file of a repository
+func (p *AptProvider) sourcesPath(repo *AptRepository) string {
+	return AptSourcesPath(p.etcDir, repo)
+}
+
+// keyPaths returns the armored (.asc) and binary (.gpg) keyring paths of a repository
+func (p *AptProvider) keyPaths(repo *AptRepository) []string {
+	return AptKeyPaths(p.etcDir, repo)
+}
+
+// expandRepo replaces the {{distro}}, {{codename}} and {{arch}} placeholders
+func (p *AptProvider) expandRepo(tmpl string) string {
+	return ExpandAptRepo(tmpl, p.repoVars())
+}
+
+// repoVars returns the repository placeholder values of the target system
+func (p *AptProvider) repoVars() AptRepoVars {
+	return AptRepoVars{
+		Distro:   p.targetOS().Distro,
+		Codename: p.targetOS().Codename,
+		Arch:     DebianArch(p.targetOS().Arch),
+	}
+}
+
+// AptRepoVars are the values of the {{distro}}, {{codename}} and {{arch}}
+// placeholders of a repository, or shell expansions computing them
+type AptRepoVars struct {
+	Distro   string // e.g., "ubuntu"
+	Codename string // e.g., "noble"
+	Arch     string // Debian architecture, e.g., "amd64"
+}
+
+// ExpandAptRepo replaces the placeholders of a repository field
+func ExpandAptRepo(tmpl string, vars AptRepoVars) string {
+	return strings.NewReplacer(
+		"{{distro}}", vars.Distro,
+		"{{codename}}", vars.Codename,
+		"{{arch}}", vars.Arch,
+	).Replace(tmpl)
+}
+
+// AptSourcesLine returns the one-line-style sources entry of a repository
+// signed by the key at keyPath, without a trailing newline
+func AptSourcesLine(repo *AptRepository, keyPath string, vars AptRepoVars) string {
+	return fmt.Sprintf("deb [arch=%s signed-by=%s] %s %s %s", vars.Arch, keyPath,
+		ExpandAptRepo(repo.Source, vars), ExpandAptRepo(repo.Suite, vars), strings.Join(repo.Components, " "))
+}
+
+// AptSourcesPath returns the sources list file of a repository in an APT
+// configuration directory such as /etc/apt
+func AptSourcesPath(etcDir string, repo *AptRepository) string {
+	return path.Join(etcDir, "sources.list.d", repo.Name+".list")
+}
+
+// AptKeyPaths returns the armored (.asc) and binary (.gpg) keyring paths of
+// a repository in an APT configuration directory
+func AptKeyPaths(etcDir string, repo *AptRepository) []string {
+	base := path.Join(etcDir, "keyrings", repo.Name)
+	return []string{base + ".asc", base + ".gpg"}
+}
+
+// AptKeyPath returns the keyring path for a repository's signing key: apt
+// reads armored keys only from .asc files, and binary keys from .gpg files
+func AptKeyPath(etcDir string, repo *AptRepository, key []byte) string {
+	if bytes.HasPrefix(bytes.TrimSpace(key), []byte(ArmoredKeyHeader)) {
+		return AptKeyPaths(etcDir, repo)[0]
+	}
+	return AptKeyPaths(etcDir, repo)[1]
+}
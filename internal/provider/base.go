@@ -1,17 +1,32 @@
 package provider
 
 import (
-	"fmt"
-	"os/exec"
+	"bytes"
+	"context"
+	goerrors "errors"
+	"io"
+	"os"
 	"strings"
 
+	"github.com/Litchi-group/unipm/internal/detector"
+	"github.com/Litchi-group/unipm/internal/errors"
 	"github.com/Litchi-group/unipm/internal/logger"
 )
 
+const (
+	// outputTailBytes bounds how much command output is kept for error reports
+	outputTailBytes = 64 * 1024
+
+	// outputTailLines is the number of output lines included in error reports
+	outputTailLines = 20
+)
+
 // BaseProvider provides common functionality for all providers
 type BaseProvider struct {
 	name       string
 	executable string
+	runner     CommandRunner
+	osInfo     *detector.OSInfo // System packages are installed on; nil for this one
 }
 
 // Name returns the provider name
@@ -21,39 +36,132 @@ func (p *BaseProvider) Name() string {
 
 // IsAvailable checks if the executable is in PATH
 func (p *BaseProvider) IsAvailable() bool {
-	_, err := exec.LookPath(p.executable)
+	_, err := p.Runner().LookPath(p.executable)
 	return err == nil
 }
 
-// execCommand executes a command and returns the output
-func execCommand(name string, args ...string) (string, error) {
+// SetRunner sets the command runner used by this provider
+func (p *BaseProvider) SetRunner(r CommandRunner) {
+	p.runner = r
+}
+
+// SetOS sets the system the provider installs packages on, for planning
+// for another system. It defaults to this one.
+func (p *BaseProvider) SetOS(osInfo *detector.OSInfo) {
+	p.osInfo = osInfo
+}
+
+// targetOS returns the system the provider installs packages on
+func (p *BaseProvider) targetOS() *detector.OSInfo {
+	if p.osInfo == nil {
+		p.osInfo = detector.DetectOS()
+	}
+	return p.osInfo
+}
+
+// Runner returns the provider's command runner
+func (p *BaseProvider) Runner() CommandRunner {
+	if p.runner != nil {
+		return p.runner
+	}
+	return defaultRunner
+}
+
+// stableLocale is added to the environment of provider commands so their
+// output, which is parsed and diagnosed, does not depend on the user's language
+var stableLocale = []string{"LC_ALL=C", "LANG=C"}
+
+// execCommand executes a command and returns the combined output
+func (p *BaseProvider) execCommand(ctx context.Context, name string, args ...string) (string, error) {
 	logger.Debug("Executing: %s %s", name, strings.Join(args, " "))
-	cmd := exec.Command(name, args...)
-	output, err := cmd.CombinedOutput()
+
+	var output bytes.Buffer
+	err := p.Runner().Run(ctx, Command{
+		Name:   name,
+		Args:   args,
+		Env:    stableLocale,
+		Stdout: &output,
+		Stderr: &output,
+	})
+
+	if err != nil {
+		logger.Debug("Command failed: %v, output: %s", err, output.String())
+	}
+
+	return strings.TrimSpace(output.String()), err
+}
+
+// execCommandStdout executes a command and returns only its standard output,
+// for commands whose output is parsed as JSON
+func (p *BaseProvider) execCommandStdout(ctx context.Context, name string, args ...string) ([]byte, error) {
+	logger.Debug("Executing: %s %s", name, strings.Join(args, " "))
+
+	var stdout, stderr bytes.Buffer
+	err := p.Runner().Run(ctx, Command{
+		Name:   name,
+		Args:   args,
+		Env:    stableLocale,
+		Stdout: &stdout,
+		Stderr: &stderr,
+	})
 
 	if err != nil {
-		logger.Debug("Command failed: %v, output: %s", err, string(output))
+		logger.Debug("Command failed: %v, stderr: %s", err, stderr.String())
 	}
 
-	return strings.TrimSpace(string(output)), err
+	return stdout.Bytes(), err
 }
 
-// execCommandSilent executes a command and returns only the error
-func execCommandSilent(name string, args ...string) error {
+// execCommandSilent executes a command and returns only the error.
+// Output is captured for error reports and streamed to the context's
+// output writer, or to stdout in verbose mode.
+func (p *BaseProvider) execCommandSilent(ctx context.Context, name string, args ...string) error {
 	logger.Debug("Executing: %s %s", name, strings.Join(args, " "))
-	cmd := exec.Command(name, args...)
-	return cmd.Run()
+
+	output := newTailBuffer(outputTailBytes)
+	var w io.Writer = output
+	if stream := outputFrom(ctx); stream != nil {
+		w = io.MultiWriter(output, stream)
+	} else if logger.GetLevel() <= logger.LevelDebug {
+		w = io.MultiWriter(output, os.Stdout)
+	}
+
+	err := p.Runner().Run(ctx, Command{
+		Name:   name,
+		Args:   args,
+		Env:    stableLocale,
+		Stdout: w,
+		Stderr: w,
+	})
+
+	if err != nil {
+		return errors.NewCommandError(FormatCommand(name, args...), exitCode(err), output.Tail(outputTailLines), err)
+	}
+
+	return nil
 }
 
 // executeWithDisplay executes a command after displaying it
-func (p *BaseProvider) executeWithDisplay(args ...string) error {
-	fmt.Printf("  → %s\n", FormatCommand(p.executable, args...))
-	return execCommandSilent(p.executable, args...)
+func (p *BaseProvider) executeWithDisplay(ctx context.Context, args ...string) error {
+	announce(ctx, FormatCommand(p.executable, args...))
+	return p.execCommandSilent(ctx, p.executable, args...)
+}
+
+// executePrivileged executes the provider's executable as root after displaying it
+func (p *BaseProvider) executePrivileged(ctx context.Context, args ...string) error {
+	return p.executePrivilegedCommand(ctx, p.executable, args...)
+}
+
+// executePrivilegedCommand executes a command as root after displaying it
+func (p *BaseProvider) executePrivilegedCommand(ctx context.Context, name string, args ...string) error {
+	argv := privileged(name, args...)
+	announce(ctx, strings.Join(argv, " "))
+	return p.execCommandSilent(ctx, argv[0], argv[1:]...)
 }
 
 // checkInstalled checks if a package is installed using a list command
-func (p *BaseProvider) checkInstalled(args ...string) bool {
-	output, err := execCommand(p.executable, args...)
+func (p *BaseProvider) checkInstalled(ctx context.Context, args ...string) bool {
+	output, err := p.execCommand(ctx, p.executable, args...)
 	return err == nil && strings.TrimSpace(output) != ""
 }
 
@@ -62,3 +170,37 @@ func FormatCommand(name string, args ...string) string {
 	parts := append([]string{name}, args...)
 	return strings.Join(parts, " ")
 }
+
+// exitCode extracts the exit code from a command error, or -1
+func exitCode(err error) int {
+	var coded interface{ ExitCode() int }
+	if goerrors.As(err, &coded) {
+		return coded.ExitCode()
+	}
+	return -1
+}
+
+// tailBuffer is an io.Writer that keeps only the last max bytes written
+type tailBuffer struct {
+	buf []byte
+	max int
+}
+
+// newTailBuffer creates a tailBuffer keeping at most max bytes
+func newTailBuffer(max int) *tailBuffer {
+	return &tailBuffer{max: max}
+}
+
+// Write appends p, discarding the oldest bytes beyond the limit
+func (t *tailBuffer) Write(p []byte) (int, error) {
+	t.buf = append(t.buf, p...)
+	if len(t.buf) > t.max {
+		t.buf = t.buf[len(t.buf)-t.max:]
+	}
+	return len(p), nil
+}
+
+// Tail returns the last n lines written
+func (t *tailBuffer) Tail(n int) string {
+	return tailLines(strings.TrimSpace(string(t.buf)), n)
+}
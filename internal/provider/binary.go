@@ -0,0 +1,551 @@
+package provider
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"compress/bzip2"
+	"compress/gzip"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/Litchi-group/unipm/internal/detector"
+	"github.com/Litchi-group/unipm/internal/errors"
+	"github.com/Litchi-group/unipm/internal/logger"
+	"gopkg.in/yaml.v3"
+)
+
+const (
+	// BinaryDir is the directory binaries are installed to, relative to home
+	BinaryDir = ".unipm/bin"
+
+	// binaryStateFile records installed binaries, relative to home
+	binaryStateFile = ".unipm/binaries.yaml"
+)
+
+func init() {
+	mustRegister(Registration{
+		Name:    "binary",
+		Types:   []string{"binary"},
+		Builtin: true,
+		Guide: `The binary provider needs no external tools.
+Binaries are installed to ~/.unipm/bin. Make sure that directory is on your PATH.`,
+		Capabilities: Capabilities{SupportsVersions: true},
+		New:          func() Provider { return NewBinaryProvider() },
+	})
+}
+
+// BinaryProvider installs release binaries downloaded directly from a URL
+type BinaryProvider struct {
+	BaseProvider
+	binDir    string
+	statePath string
+	client    *http.Client
+}
+
+// binaryRecord describes an installed binary package
+type binaryRecord struct {
+	Version     string    `yaml:"version,omitempty"`
+	URL         string    `yaml:"url"`
+	SHA256      string    `yaml:"sha256"`
+	Files       []string  `yaml:"files"`
+	InstalledAt time.Time `yaml:"installed_at"`
+}
+
+// binaryState is the on-disk record of installed binaries
+type binaryState struct {
+	Packages map[string]binaryRecord `yaml:"packages"`
+}
+
+// NewBinaryProvider creates a new binary download provider
+func NewBinaryProvider() *BinaryProvider {
+	homeDir, _ := os.UserHomeDir()
+
+	return &BinaryProvider{
+		BaseProvider: BaseProvider{
+			name: "binary",
+		},
+		binDir:    filepath.Join(homeDir, BinaryDir),
+		statePath: filepath.Join(homeDir, binaryStateFile),
+		client: &http.Client{
+			Timeout: 10 * time.Minute,
+		},
+	}
+}
+
+// IsAvailable always returns true since downloads need no external tools
+func (p *BinaryProvider) IsAvailable() bool {
+	return true
+}
+
+// Install downloads, verifies and extracts the binaries for a package
+func (p *BinaryProvider) Install(ctx context.Context, spec ProviderSpec) error {
+	url := p.downloadURL(spec)
+
+	checksum, err := p.checksum(spec)
+	if err != nil {
+		return err
+	}
+
+	announce(ctx, p.InstallCommand(spec))
+
+	archive, err := p.download(ctx, url, checksum)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = os.Remove(archive) }()
+
+	if err := os.MkdirAll(p.binDir, 0755); err != nil {
+		return fmt.Errorf("failed to create %s: %w", p.binDir, err)
+	}
+
+	files, err := p.extract(archive, url, p.binaries(spec))
+	if err != nil {
+		return err
+	}
+
+	state, err := p.loadState()
+	if err != nil {
+		return err
+	}
+
+	state.Packages[spec.Name] = binaryRecord{
+		Version:     spec.Version,
+		URL:         url,
+		SHA256:      checksum,
+		Files:       files,
+		InstalledAt: time.Now(),
+	}
+
+	if err := p.saveState(state); err != nil {
+		return err
+	}
+
+	if !p.onPath() {
+		note(ctx, "add %s to your PATH to use installed binaries", p.binDir)
+	}
+
+	return nil
+}
+
+// IsInstalled checks the install record and that every recorded file exists
+func (p *BinaryProvider) IsInstalled(ctx context.Context, spec ProviderSpec) bool {
+	state, err := p.loadState()
+	if err != nil {
+		return false
+	}
+
+	record, ok := state.Packages[spec.Name]
+	if !ok {
+		return false
+	}
+
+	// A different pinned version means the package needs to be reinstalled
+	if spec.Version != "" && record.Version != spec.Version {
+		return false
+	}
+
+	for _, file := range record.Files {
+		if _, err := os.Stat(file); err != nil {
+			return false
+		}
+	}
+
+	return true
+}
+
+// InstallCommand returns a description of the download that would be executed
+func (p *BinaryProvider) InstallCommand(spec ProviderSpec) string {
+	var targets []string
+	for _, name := range p.binaries(spec) {
+		targets = append(targets, filepath.Join(p.binDir, p.executableName(name)))
+	}
+
+	return fmt.Sprintf("download %s → %s", p.downloadURL(spec), strings.Join(targets, ", "))
+}
+
+// Remove deletes the recorded binaries of a package
+func (p *BinaryProvider) Remove(ctx context.Context, spec ProviderSpec) error {
+	state, err := p.loadState()
+	if err != nil {
+		return err
+	}
+
+	record, ok := state.Packages[spec.Name]
+	if !ok {
+		return fmt.Errorf("%s was not installed by unipm", spec.Name)
+	}
+
+	announce(ctx, FormatCommand("rm", record.Files...))
+
+	for _, file := range record.Files {
+		if err := os.Remove(file); err != nil && !os.IsNotExist(err) {
+			return fmt.Errorf("failed to remove %s: %w", file, err)
+		}
+	}
+
+	delete(state.Packages, spec.Name)
+	return p.saveState(state)
+}
+
+// RemoveCommand returns the uninstall command
+func (p *BinaryProvider) RemoveCommand(spec ProviderSpec) string {
+	if state, err := p.loadState(); err == nil {
+		if record, ok := state.Packages[spec.Name]; ok {
+			return FormatCommand("rm", record.Files...)
+		}
+	}
+
+	var files []string
+	for _, name := range p.binaries(spec) {
+		files = append(files, filepath.Join(p.binDir, p.executableName(name)))
+	}
+	return FormatCommand("rm", files...)
+}
+
+// ListInstalled returns the packages recorded as installed, with the URL
+// they were downloaded from as the source
+func (p *BinaryProvider) ListInstalled(ctx context.Context) ([]InstalledPackage, error) {
+	state, err := p.loadState()
+	if err != nil {
+		return nil, err
+	}
+
+	var packages []InstalledPackage
+	for _, name := range sortedKeys(state.Packages) {
+		record := state.Packages[name]
+		packages = append(packages, InstalledPackage{
+			Name:     name,
+			Version:  record.Version,
+			Source:   record.URL,
+			Explicit: true,
+		})
+	}
+	return packages, nil
+}
+
+// downloadURL expands the {{os}}, {{arch}} and {{version}} placeholders
+func (p *BinaryProvider) downloadURL(spec ProviderSpec) string {
+	return ExpandURLTemplate(spec.URL, p.targetOS(), spec.Version)
+}
+
+// ExpandURLTemplate replaces the {{os}}, {{arch}} and {{version}} placeholders
+// of a download URL using the given OS information
+func ExpandURLTemplate(tmpl string, osInfo *detector.OSInfo, version string) string {
+	replacer := strings.NewReplacer(
+		"{{os}}", osInfo.Platform,
+		"{{arch}}", osInfo.Arch,
+		"{{version}}", version,
+	)
+	return replacer.Replace(tmpl)
+}
+
+// checksum returns the expected SHA256 for the target OS and architecture
+func (p *BinaryProvider) checksum(spec ProviderSpec) (string, error) {
+	key := p.targetOS().Platform + "-" + p.targetOS().Arch
+
+	checksum, ok := spec.Checksums[key]
+	if !ok || checksum == "" {
+		return "", fmt.Errorf("no SHA256 checksum for %s on %s; refusing to install unverified binary", spec.Name, key)
+	}
+
+	return strings.ToLower(checksum), nil
+}
+
+// binaries returns the binary names to extract, defaulting to the package name
+func (p *BinaryProvider) binaries(spec ProviderSpec) []string {
+	if len(spec.Binaries) > 0 {
+		return spec.Binaries
+	}
+	return []string{spec.Name}
+}
+
+// executableName adds the platform executable suffix to a binary name
+func (p *BinaryProvider) executableName(name string) string {
+	name = path.Base(name)
+	if p.targetOS().IsWindows() && !strings.HasSuffix(name, ".exe") {
+		name += ".exe"
+	}
+	return name
+}
+
+// download fetches url into a temporary file and verifies its SHA256
+func (p *BinaryProvider) download(ctx context.Context, url, checksum string) (string, error) {
+	logger.Debug("Downloading %s", url)
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return "", err
+	}
+
+	resp, err := p.client.Do(req)
+	if err != nil {
+		return "", errors.NewNetworkError(url, "failed to download", err)
+	}
+	defer func() { _ = resp.Body.Close() }()
+
+	if resp.StatusCode != http.StatusOK {
+		return "", errors.NewNetworkError(url, fmt.Sprintf("unexpected status code %d", resp.StatusCode), nil)
+	}
+
+	tmp, err := os.CreateTemp("", "unipm-download-*")
+	if err != nil {
+		return "", err
+	}
+	defer func() { _ = tmp.Close() }()
+
+	hash := sha256.New()
+	if _, err := io.Copy(io.MultiWriter(tmp, hash), resp.Body); err != nil {
+		_ = os.Remove(tmp.Name())
+		return "", errors.NewNetworkError(url, "failed to read response", err)
+	}
+
+	actual := hex.EncodeToString(hash.Sum(nil))
+	if actual != checksum {
+		_ = os.Remove(tmp.Name())
+		return "", fmt.Errorf("checksum mismatch for %s: expected %s, got %s", url, checksum, actual)
+	}
+
+	logger.Debug("Checksum verified for %s", url)
+	return tmp.Name(), nil
+}
+
+// extract copies the wanted binaries out of the downloaded file into binDir
+// and returns the paths it wrote
+func (p *BinaryProvider) extract(archive, url string, wanted []string) ([]string, error) {
+	lowerURL := strings.ToLower(url)
+
+	switch {
+	case strings.HasSuffix(lowerURL, ".tar.gz"), strings.HasSuffix(lowerURL, ".tgz"):
+		return p.extractTar(archive, wanted, func(r io.Reader) (io.Reader, error) {
+			return gzip.NewReader(r)
+		})
+	case strings.HasSuffix(lowerURL, ".tar.bz2"), strings.HasSuffix(lowerURL, ".tbz"):
+		return p.extractTar(archive, wanted, func(r io.Reader) (io.Reader, error) {
+			return bzip2.NewReader(r), nil
+		})
+	case strings.HasSuffix(lowerURL, ".tar"):
+		return p.extractTar(archive, wanted, func(r io.Reader) (io.Reader, error) {
+			return r, nil
+		})
+	case strings.HasSuffix(lowerURL, ".zip"):
+		return p.extractZip(archive, wanted)
+	default:
+		// Not an archive: the download is the binary itself
+		if len(wanted) != 1 {
+			return nil, fmt.Errorf("%s is not an archive but %d binaries were requested", url, len(wanted))
+		}
+
+		src, err := os.Open(archive)
+		if err != nil {
+			return nil, err
+		}
+		defer func() { _ = src.Close() }()
+
+		dest, err := p.writeBinary(wanted[0], src)
+		if err != nil {
+			return nil, err
+		}
+		return []string{dest}, nil
+	}
+}
+
+// extractTar extracts wanted binaries from a (possibly compressed) tarball
+func (p *BinaryProvider) extractTar(archive string, wanted []string, decompress func(io.Reader) (io.Reader, error)) ([]string, error) {
+	f, err := os.Open(archive)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = f.Close() }()
+
+	r, err := decompress(f)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open archive: %w", err)
+	}
+
+	found := make(map[string]string)
+	tr := tar.NewReader(r)
+
+	for {
+		header, err := tr.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to read archive: %w", err)
+		}
+
+		if header.Typeflag != tar.TypeReg {
+			continue
+		}
+
+		name := p.matchBinary(header.Name, wanted)
+		if name == "" || found[name] != "" {
+			continue
+		}
+
+		dest, err := p.writeBinary(name, tr)
+		if err != nil {
+			return nil, err
+		}
+		found[name] = dest
+	}
+
+	return collectExtracted(found, wanted)
+}
+
+// extractZip extracts wanted binaries from a zip archive
+func (p *BinaryProvider) extractZip(archive string, wanted []string) ([]string, error) {
+	zr, err := zip.OpenReader(archive)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open archive: %w", err)
+	}
+	defer func() { _ = zr.Close() }()
+
+	found := make(map[string]string)
+
+	for _, file := range zr.File {
+		if file.FileInfo().IsDir() {
+			continue
+		}
+
+		name := p.matchBinary(file.Name, wanted)
+		if name == "" || found[name] != "" {
+			continue
+		}
+
+		rc, err := file.Open()
+		if err != nil {
+			return nil, fmt.Errorf("failed to read %s from archive: %w", file.Name, err)
+		}
+
+		dest, err := p.writeBinary(name, rc)
+		_ = rc.Close()
+		if err != nil {
+			return nil, err
+		}
+		found[name] = dest
+	}
+
+	return collectExtracted(found, wanted)
+}
+
+// matchBinary returns the wanted entry matching an archive member, if any.
+// Entries match either the full member path or its base name.
+func (p *BinaryProvider) matchBinary(member string, wanted []string) string {
+	member = strings.TrimPrefix(path.Clean(filepath.ToSlash(member)), "./")
+
+	for _, name := range wanted {
+		if member == name || member == name+".exe" {
+			return name
+		}
+		base := path.Base(member)
+		if base == path.Base(name) || base == path.Base(name)+".exe" {
+			return name
+		}
+	}
+
+	return ""
+}
+
+// writeBinary writes an executable into binDir
+func (p *BinaryProvider) writeBinary(name string, r io.Reader) (string, error) {
+	dest := filepath.Join(p.binDir, p.executableName(name))
+
+	// Write to a temporary file first so a running binary is replaced atomically
+	tmp, err := os.CreateTemp(p.binDir, ".unipm-*")
+	if err != nil {
+		return "", err
+	}
+
+	if _, err := io.Copy(tmp, r); err != nil {
+		_ = tmp.Close()
+		_ = os.Remove(tmp.Name())
+		return "", fmt.Errorf("failed to write %s: %w", dest, err)
+	}
+
+	if err := tmp.Close(); err != nil {
+		_ = os.Remove(tmp.Name())
+		return "", err
+	}
+
+	if err := os.Chmod(tmp.Name(), 0755); err != nil {
+		_ = os.Remove(tmp.Name())
+		return "", err
+	}
+
+	if err := os.Rename(tmp.Name(), dest); err != nil {
+		_ = os.Remove(tmp.Name())
+		return "", fmt.Errorf("failed to install %s: %w", dest, err)
+	}
+
+	return dest, nil
+}
+
+// collectExtracted returns extracted paths in wanted order, failing if any are missing
+func collectExtracted(found map[string]string, wanted []string) ([]string, error) {
+	var files []string
+	for _, name := range wanted {
+		dest, ok := found[name]
+		if !ok {
+			return files, fmt.Errorf("binary %s not found in archive", name)
+		}
+		files = append(files, dest)
+	}
+	return files, nil
+}
+
+// onPath reports whether binDir is on PATH
+func (p *BinaryProvider) onPath() bool {
+	for _, dir := range filepath.SplitList(os.Getenv("PATH")) {
+		if filepath.Clean(dir) == filepath.Clean(p.binDir) {
+			return true
+		}
+	}
+	return false
+}
+
+// loadState reads the installed binaries record
+func (p *BinaryProvider) loadState() (*binaryState, error) {
+	state := &binaryState{Packages: make(map[string]binaryRecord)}
+
+	data, err := os.ReadFile(p.statePath)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return state, nil
+		}
+		return nil, err
+	}
+
+	if err := yaml.Unmarshal(data, state); err != nil {
+		return nil, fmt.Errorf("failed to parse %s: %w", p.statePath, err)
+	}
+
+	if state.Packages == nil {
+		state.Packages = make(map[string]binaryRecord)
+	}
+
+	return state, nil
+}
+
+// saveState writes the installed binaries record
+func (p *BinaryProvider) saveState(state *binaryState) error {
+	if err := os.MkdirAll(filepath.Dir(p.statePath), 0755); err != nil {
+		return err
+	}
+
+	data, err := yaml.Marshal(state)
+	if err != nil {
+		return err
+	}
+
+	return os.WriteFile(p.statePath, data, 0644)
+}
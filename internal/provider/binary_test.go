@@ -0,0 +1,263 @@
+package provider
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/Litchi-group/unipm/internal/detector"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// serveFiles serves each file's content at its path
+func serveFiles(t *testing.T, files map[string][]byte) *httptest.Server {
+	t.Helper()
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		data, ok := files[r.URL.Path]
+		if !ok {
+			http.NotFound(w, r)
+			return
+		}
+		_, _ = w.Write(data)
+	}))
+	t.Cleanup(server.Close)
+	return server
+}
+
+// testBinaryProvider returns a linux/amd64 binary provider installing into a temp dir
+func testBinaryProvider(t *testing.T, server *httptest.Server) *BinaryProvider {
+	t.Helper()
+
+	dir := t.TempDir()
+	p := NewBinaryProvider()
+	p.osInfo = &detector.OSInfo{Platform: "linux", Distro: "ubuntu", Family: "debian", Arch: "amd64"}
+	p.binDir = filepath.Join(dir, "bin")
+	p.statePath = filepath.Join(dir, "binaries.yaml")
+	p.client = server.Client()
+	return p
+}
+
+func sha256Hex(data []byte) string {
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
+
+func tarGz(t *testing.T, files map[string]string) []byte {
+	t.Helper()
+
+	var buf bytes.Buffer
+	gz := gzip.NewWriter(&buf)
+	tw := tar.NewWriter(gz)
+	for name, content := range files {
+		require.NoError(t, tw.WriteHeader(&tar.Header{Name: name, Mode: 0755, Size: int64(len(content)), Typeflag: tar.TypeReg}))
+		_, err := io.WriteString(tw, content)
+		require.NoError(t, err)
+	}
+	require.NoError(t, tw.Close())
+	require.NoError(t, gz.Close())
+	return buf.Bytes()
+}
+
+func zipArchive(t *testing.T, files map[string]string) []byte {
+	t.Helper()
+
+	var buf bytes.Buffer
+	zw := zip.NewWriter(&buf)
+	for name, content := range files {
+		w, err := zw.Create(name)
+		require.NoError(t, err)
+		_, err = io.WriteString(w, content)
+		require.NoError(t, err)
+	}
+	require.NoError(t, zw.Close())
+	return buf.Bytes()
+}
+
+func readFile(t *testing.T, path string) string {
+	t.Helper()
+
+	data, err := os.ReadFile(path)
+	require.NoError(t, err)
+	return string(data)
+}
+
+func TestBinaryProvider_ChecksumMismatch(t *testing.T) {
+	server := serveFiles(t, map[string][]byte{"/tool": []byte("tampered")})
+	p := testBinaryProvider(t, server)
+
+	err := p.Install(context.Background(), ProviderSpec{
+		Type:      "binary",
+		Name:      "tool",
+		URL:       server.URL + "/tool",
+		Checksums: map[string]string{"linux-amd64": sha256Hex([]byte("original"))},
+	})
+	require.ErrorContains(t, err, "checksum mismatch")
+
+	_, statErr := os.Stat(filepath.Join(p.binDir, "tool"))
+	assert.True(t, os.IsNotExist(statErr))
+	assert.False(t, p.IsInstalled(context.Background(), ProviderSpec{Type: "binary", Name: "tool"}))
+}
+
+func TestBinaryProvider_MissingChecksum(t *testing.T) {
+	requests := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+	}))
+	defer server.Close()
+	p := testBinaryProvider(t, server)
+
+	err := p.Install(context.Background(), ProviderSpec{
+		Type:      "binary",
+		Name:      "tool",
+		URL:       server.URL + "/tool-{{os}}-{{arch}}",
+		Checksums: map[string]string{"darwin-arm64": sha256Hex([]byte("tool"))},
+	})
+	require.ErrorContains(t, err, "no SHA256 checksum for tool on linux-amd64")
+	assert.Zero(t, requests, "nothing is downloaded without a checksum")
+}
+
+func TestBinaryProvider_InstallArchives(t *testing.T) {
+	tarball := tarGz(t, map[string]string{
+		"tool-1.2.0/README.md":   "docs",
+		"tool-1.2.0/bin/tool":    "tool binary",
+		"tool-1.2.0/bin/toolctl": "toolctl binary",
+	})
+	zipped := zipArchive(t, map[string]string{
+		"dist/linux/tool": "zipped tool",
+		"dist/LICENSE":    "license",
+	})
+	plain := []byte("plain tool")
+
+	server := serveFiles(t, map[string][]byte{
+		"/v1.2.0/tool-linux-amd64.tar.gz": tarball,
+		"/v1.2.0/tool-linux-amd64.zip":    zipped,
+		"/v1.2.0/tool-linux-amd64":        plain,
+	})
+
+	tests := []struct {
+		name     string
+		suffix   string
+		data     []byte
+		binaries []string
+		expected map[string]string
+	}{
+		{
+			name:     "tar.gz",
+			suffix:   ".tar.gz",
+			data:     tarball,
+			binaries: []string{"tool", "toolctl"},
+			expected: map[string]string{"tool": "tool binary", "toolctl": "toolctl binary"},
+		},
+		{
+			name:     "zip",
+			suffix:   ".zip",
+			data:     zipped,
+			expected: map[string]string{"tool": "zipped tool"},
+		},
+		{
+			name:     "plain binary",
+			data:     plain,
+			expected: map[string]string{"tool": "plain tool"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := testBinaryProvider(t, server)
+			spec := ProviderSpec{
+				Type:      "binary",
+				Name:      "tool",
+				Version:   "1.2.0",
+				URL:       server.URL + "/v{{version}}/tool-{{os}}-{{arch}}" + tt.suffix,
+				Checksums: map[string]string{"linux-amd64": sha256Hex(tt.data)},
+				Binaries:  tt.binaries,
+			}
+
+			var status bytes.Buffer
+			ctx := WithOutput(context.Background(), io.Discard, &status)
+			require.NoError(t, p.Install(ctx, spec))
+
+			for name, content := range tt.expected {
+				assert.Equal(t, content, readFile(t, filepath.Join(p.binDir, name)))
+			}
+			assert.Contains(t, status.String(), "Note: add "+p.binDir+" to your PATH")
+			assert.True(t, p.IsInstalled(ctx, spec))
+		})
+	}
+}
+
+func TestBinaryProvider_State(t *testing.T) {
+	server := serveFiles(t, map[string][]byte{"/tool": []byte("tool")})
+	p := testBinaryProvider(t, server)
+	ctx := WithOutput(context.Background(), io.Discard, io.Discard)
+
+	spec := ProviderSpec{
+		Type:      "binary",
+		Name:      "tool",
+		Version:   "1.0.0",
+		URL:       server.URL + "/tool",
+		Checksums: map[string]string{"linux-amd64": sha256Hex([]byte("tool"))},
+	}
+	require.NoError(t, p.Install(ctx, spec))
+
+	// A fresh provider reads the same state file
+	reloaded := testBinaryProvider(t, server)
+	reloaded.binDir, reloaded.statePath = p.binDir, p.statePath
+
+	packages, err := reloaded.ListInstalled(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, []InstalledPackage{
+		{Name: "tool", Version: "1.0.0", Source: server.URL + "/tool", Explicit: true},
+	}, packages)
+
+	assert.True(t, reloaded.IsInstalled(ctx, spec))
+	assert.True(t, reloaded.IsInstalled(ctx, ProviderSpec{Type: "binary", Name: "tool"}))
+	assert.False(t, reloaded.IsInstalled(ctx, ProviderSpec{Type: "binary", Name: "tool", Version: "2.0.0"}))
+
+	require.NoError(t, reloaded.Remove(ctx, spec))
+	assert.False(t, reloaded.IsInstalled(ctx, spec))
+	_, statErr := os.Stat(filepath.Join(p.binDir, "tool"))
+	assert.True(t, os.IsNotExist(statErr))
+
+	packages, err = reloaded.ListInstalled(ctx)
+	require.NoError(t, err)
+	assert.Empty(t, packages)
+
+	assert.ErrorContains(t, reloaded.Remove(ctx, spec), "was not installed by unipm")
+}
+
+func TestBinaryProvider_MatchBinary(t *testing.T) {
+	p := NewBinaryProvider()
+
+	tests := []struct {
+		member   string
+		wanted   []string
+		expected string
+	}{
+		{"tool", []string{"tool"}, "tool"},
+		{"./tool-1.0/tool", []string{"tool"}, "tool"},
+		{"tool-1.0/bin/tool.exe", []string{"tool"}, "tool"},
+		{"bin/toolctl", []string{"tool", "toolctl"}, "toolctl"},
+		{"dist/bin/tool", []string{"dist/bin/tool"}, "dist/bin/tool"},
+		{"tool-1.0/README.md", []string{"tool"}, ""},
+		{"tools/other", []string{"tool"}, ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.member, func(t *testing.T) {
+			assert.Equal(t, tt.expected, p.matchBinary(tt.member, tt.wanted))
+		})
+	}
+}
@@ -1,5 +1,24 @@
 package provider
 
+import (
+	"context"
+	"strings"
+)
+
+func init() {
+	mustRegister(Registration{
+		Name:      "brew",
+		Types:     []string{"brew", "brew_cask"},
+		Platforms: []string{"darwin"},
+		Default:   true,
+		Guide: `Homebrew is not installed.
+Install it from: https://brew.sh
+
+  /bin/bash -c "$(curl -fsSL https://raw.githubusercontent.com/Homebrew/install/HEAD/install.sh)"`,
+		New: func() Provider { return NewBrewProvider() },
+	})
+}
+
 // BrewProvider handles Homebrew package management
 type BrewProvider struct {
 	BaseProvider
@@ -15,22 +34,89 @@ func NewBrewProvider() *BrewProvider {
 	}
 }
 
-// Install installs a package using Homebrew
-func (p *BrewProvider) Install(spec ProviderSpec) error {
+// Install installs a package using Homebrew, tapping its tap first if needed
+func (p *BrewProvider) Install(ctx context.Context, spec ProviderSpec) error {
+	if tap := BrewTap(spec); tap != "" && !p.isTapped(ctx, tap) {
+		if err := p.executeWithDisplay(ctx, p.buildTapArgs(spec)...); err != nil {
+			return err
+		}
+	}
+
 	args := p.buildInstallArgs(spec)
-	return p.executeWithDisplay(args...)
+	return p.executeWithDisplay(ctx, args...)
+}
+
+// PrepareSteps returns a brew tap step for each tap that is not tapped yet
+func (p *BrewProvider) PrepareSteps(ctx context.Context, specs []ProviderSpec) []Step {
+	var steps []Step
+	for _, spec := range specs {
+		tap := BrewTap(spec)
+		if tap == "" || p.isTapped(ctx, tap) {
+			continue
+		}
+
+		args := p.buildTapArgs(spec)
+		steps = append(steps, Step{
+			Key:         "brew-tap-" + tap,
+			Description: "Tap " + tap,
+			Command:     FormatCommand("brew", args...),
+			Run: func(ctx context.Context) error {
+				return p.executeWithDisplay(ctx, args...)
+			},
+		})
+	}
+	return steps
+}
+
+// isTapped reports whether a tap is already tapped
+func (p *BrewProvider) isTapped(ctx context.Context, tap string) bool {
+	output, err := p.execCommand(ctx, "brew", "tap")
+	if err != nil {
+		return false
+	}
+	return containsString(parseLines(strings.ToLower(output)), strings.ToLower(tap))
+}
+
+// buildTapArgs builds the brew tap arguments for a spec
+func (p *BrewProvider) buildTapArgs(spec ProviderSpec) []string {
+	args := []string{"tap", BrewTap(spec)}
+	if spec.TapURL != "" {
+		args = append(args, spec.TapURL)
+	}
+	return args
+}
+
+// BrewTap returns the tap a spec's formula comes from, or "" for core formulae
+func BrewTap(spec ProviderSpec) string {
+	if spec.Tap != "" {
+		return spec.Tap
+	}
+
+	// Fully qualified names (user/repo/formula) imply their tap
+	if parts := strings.Split(spec.Name, "/"); len(parts) == 3 {
+		return parts[0] + "/" + parts[1]
+	}
+	return ""
+}
+
+// brewName returns the fully qualified formula or cask name of a spec
+func brewName(spec ProviderSpec) string {
+	if spec.Tap != "" && !strings.Contains(spec.Name, "/") {
+		return spec.Tap + "/" + spec.Name
+	}
+	return spec.Name
 }
 
 // IsInstalled checks if a package is installed
-func (p *BrewProvider) IsInstalled(spec ProviderSpec) bool {
+func (p *BrewProvider) IsInstalled(ctx context.Context, spec ProviderSpec) bool {
 	args := []string{"list"}
 
 	if spec.Type == "brew_cask" {
 		args = append(args, "--cask")
 	}
 
-	args = append(args, spec.Name)
-	return p.checkInstalled(args...)
+	args = append(args, brewName(spec))
+	return p.checkInstalled(ctx, args...)
 }
 
 // buildInstallArgs builds installation arguments
@@ -41,7 +127,7 @@ func (p *BrewProvider) buildInstallArgs(spec ProviderSpec) []string {
 		args = append(args, "--cask")
 	}
 
-	return append(args, spec.Name)
+	return append(args, brewName(spec))
 }
 
 // InstallCommand returns the command that would be executed
@@ -51,9 +137,9 @@ func (p *BrewProvider) InstallCommand(spec ProviderSpec) string {
 }
 
 // Remove removes a package using Homebrew
-func (p *BrewProvider) Remove(spec ProviderSpec) error {
+func (p *BrewProvider) Remove(ctx context.Context, spec ProviderSpec) error {
 	args := p.buildRemoveArgs(spec)
-	return p.executeWithDisplay(args...)
+	return p.executeWithDisplay(ctx, args...)
 }
 
 // RemoveCommand returns the uninstall command
@@ -70,5 +156,5 @@ func (p *BrewProvider) buildRemoveArgs(spec ProviderSpec) []string {
 		args = append(args, "--cask")
 	}
 
-	return append(args, spec.Name)
+	return append(args, brewName(spec))
 }
@@ -0,0 +1,56 @@
+package provider
+
+import "context"
+
+func init() {
+	mustRegister(Registration{
+		Name:  "cargo",
+		Types: []string{"cargo"},
+		Guide: `Cargo is not installed.
+Cargo ships with the Rust toolchain. Install it with rustup:
+
+  curl --proto '=https' --tlsv1.2 -sSf https://sh.rustup.rs | sh`,
+		New: func() Provider { return NewCargoProvider() },
+	})
+}
+
+// CargoProvider handles Rust binaries installed with cargo install
+type CargoProvider struct {
+	BaseProvider
+}
+
+// NewCargoProvider creates a new cargo provider
+func NewCargoProvider() *CargoProvider {
+	return &CargoProvider{
+		BaseProvider: BaseProvider{
+			name:       "cargo",
+			executable: "cargo",
+		},
+	}
+}
+
+// Install installs a crate using cargo
+func (p *CargoProvider) Install(ctx context.Context, spec ProviderSpec) error {
+	return p.executeWithDisplay(ctx, "install", spec.Name)
+}
+
+// IsInstalled checks if a crate is installed
+func (p *CargoProvider) IsInstalled(ctx context.Context, spec ProviderSpec) bool {
+	packages, err := p.ListInstalled(ctx)
+	return err == nil && hasPackage(packages, spec.Name)
+}
+
+// InstallCommand returns the command that would be executed
+func (p *CargoProvider) InstallCommand(spec ProviderSpec) string {
+	return FormatCommand("cargo", "install", spec.Name)
+}
+
+// Remove removes a crate using cargo
+func (p *CargoProvider) Remove(ctx context.Context, spec ProviderSpec) error {
+	return p.executeWithDisplay(ctx, "uninstall", spec.Name)
+}
+
+// RemoveCommand returns the uninstall command
+func (p *CargoProvider) RemoveCommand(spec ProviderSpec) string {
+	return FormatCommand("cargo", "uninstall", spec.Name)
+}
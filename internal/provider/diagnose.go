@@ -0,0 +1,118 @@
+package provider
+
+import (
+	goerrors "errors"
+	"strings"
+
+	"github.com/Litchi-group/unipm/internal/errors"
+)
+
+// diagnosis maps output patterns to a suggested fix
+type diagnosis struct {
+	providers []string // Providers the patterns apply to; empty means all
+	patterns  []string
+	hint      string
+}
+
+// diagnoses are checked in order; the first match wins
+var diagnoses = []diagnosis{
+	{
+		providers: []string{"apt"},
+		patterns:  []string{"Could not get lock", "dpkg frontend lock", "Unable to acquire the dpkg"},
+		hint:      "Another package manager is running (e.g. unattended-upgrades or another apt). Wait for it to finish, then retry.",
+	},
+	{
+		providers: []string{"apt"},
+		patterns:  []string{"dpkg was interrupted"},
+		hint:      "A previous install was interrupted. Run 'sudo dpkg --configure -a', then retry.",
+	},
+	{
+		providers: []string{"apt"},
+		patterns:  []string{"Unable to locate package", "has no installation candidate"},
+		hint:      "apt does not know this package. Run 'sudo apt-get update' and retry; if it still fails, the package may not exist for your distro release.",
+	},
+	{
+		providers: []string{"snap"},
+		patterns:  []string{"classic confinement"},
+		hint:      "This snap needs classic confinement. Set 'classic: true' in the registry mapping for this package.",
+	},
+	{
+		providers: []string{"snap"},
+		patterns:  []string{"not found"},
+		hint:      "The snap was not found in the store. Check the name with 'snap find'.",
+	},
+	{
+		providers: []string{"brew"},
+		patterns:  []string{"No available formula", "No cask with this name", "No formulae or casks found"},
+		hint:      "Homebrew does not know this package. Run 'brew update' and check the name with 'brew search'.",
+	},
+	{
+		providers: []string{"winget"},
+		patterns:  []string{"No package found matching input criteria"},
+		hint:      "winget does not know this package ID. Check it with 'winget search'.",
+	},
+	{
+		providers: []string{"npm"},
+		patterns:  []string{"E404", "404 Not Found"},
+		hint:      "The package was not found in the npm registry. Check the name with 'npm view'.",
+	},
+	{
+		providers: []string{"pipx"},
+		patterns:  []string{"No matching distribution"},
+		hint:      "The package was not found on PyPI for your Python version.",
+	},
+	{
+		providers: []string{"cargo"},
+		patterns:  []string{"could not find"},
+		hint:      "The crate was not found on crates.io. Check the name with 'cargo search'.",
+	},
+	{
+		patterns: []string{
+			"Temporary failure resolving",
+			"Could not resolve",
+			"Failed to fetch",
+			"Connection timed out",
+			"Network is unreachable",
+			"ENOTFOUND",
+			"ETIMEDOUT",
+		},
+		hint: "The package manager could not reach the network. Check your connection and proxy settings, then retry.",
+	},
+	{
+		patterns: []string{"a password is required", "a terminal is required"},
+		hint:     "sudo needs a password but cannot prompt for one. Run 'sudo -v' first, or run unipm from an interactive terminal.",
+	},
+}
+
+// Diagnose returns a suggested fix for a failed command's output, or ""
+func Diagnose(providerName, output string) string {
+	for _, d := range diagnoses {
+		if len(d.providers) > 0 && !containsString(d.providers, providerName) {
+			continue
+		}
+		for _, pattern := range d.patterns {
+			if strings.Contains(output, pattern) {
+				return d.hint
+			}
+		}
+	}
+	return ""
+}
+
+// NewInstallError wraps a provider failure in an InstallError, including the
+// failed command, its exit code and output, and a diagnosis when available
+func NewInstallError(packageID string, p Provider, err error) *errors.InstallError {
+	installErr := errors.NewInstallError(packageID, p.Name(), "installation failed", err)
+	installErr.ExitCode = -1
+
+	var cmdErr *errors.CommandError
+	if goerrors.As(err, &cmdErr) {
+		installErr.Message = cmdErr.Error()
+		installErr.Command = cmdErr.Command
+		installErr.ExitCode = cmdErr.ExitCode
+		installErr.Output = cmdErr.Output
+		installErr.Hint = Diagnose(p.Name(), cmdErr.Output)
+	}
+
+	return installErr
+}
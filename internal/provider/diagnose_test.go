@@ -0,0 +1,54 @@
+package provider
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewInstallError_CapturesOutput(t *testing.T) {
+	withPrivilege(t, PrivilegeSudo, true)
+
+	runner := NewMockRunner().
+		On("sudo apt install -y git", MockResponse{Stderr: fixture(t, "apt_lock_held.txt"), ExitCode: 100})
+
+	p := NewAptProvider()
+	p.SetRunner(runner)
+
+	err := p.Install(context.Background(), ProviderSpec{Type: "apt", Name: "git"})
+	require.Error(t, err)
+
+	installErr := NewInstallError("git", p, err)
+	assert.Equal(t, "sudo apt install -y git", installErr.Command)
+	assert.Equal(t, 100, installErr.ExitCode)
+	assert.Contains(t, installErr.Output, "Could not get lock")
+	assert.Contains(t, installErr.Hint, "Another package manager is running")
+}
+
+func TestDiagnose(t *testing.T) {
+	tests := []struct {
+		provider string
+		output   string
+		want     string
+	}{
+		{"apt", "E: Unable to locate package foo", "apt does not know this package"},
+		{"snap", `error: This revision of snap "code" was published using classic confinement`, "classic: true"},
+		{"snap", `error: snap "foo" not found`, "snap find"},
+		{"brew", "Error: No available formula with the name \"foo\".", "brew search"},
+		{"apt", "Err:1 http://archive.ubuntu.com Temporary failure resolving 'archive.ubuntu.com'", "could not reach the network"},
+		{"npm", "npm ERR! code ENOTFOUND", "could not reach the network"},
+		{"brew", "E: Unable to locate package foo", ""},
+		{"apt", "some unrelated failure", ""},
+	}
+
+	for _, tt := range tests {
+		hint := Diagnose(tt.provider, tt.output)
+		if tt.want == "" {
+			assert.Empty(t, hint, tt.output)
+		} else {
+			assert.Contains(t, hint, tt.want, tt.output)
+		}
+	}
+}
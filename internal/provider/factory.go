@@ -6,23 +6,25 @@ import (
 	"github.com/Litchi-group/unipm/internal/detector"
 )
 
-// GetProvidersForOS returns available providers for the given OS
+// GetProvidersForOS returns the providers supported on the given OS,
+// including language package managers and plugins
 func GetProvidersForOS(osInfo *detector.OSInfo) []Provider {
 	var providers []Provider
 
-	switch {
-	case osInfo.IsMacOS():
-		providers = []Provider{
-			NewBrewProvider(),
-		}
-	case osInfo.IsWindows():
-		providers = []Provider{
-			NewWinGetProvider(),
-		}
-	case osInfo.IsLinux():
-		providers = []Provider{
-			NewAptProvider(),
-			NewSnapProvider(),
+	for _, reg := range RegistrationsForOS(osInfo) {
+		providers = append(providers, withOS(reg.New(), osInfo))
+	}
+
+	return providers
+}
+
+// GetAvailableProvidersForOS returns the supported providers that are installed
+func GetAvailableProvidersForOS(osInfo *detector.OSInfo) []Provider {
+	var providers []Provider
+
+	for _, p := range GetProvidersForOS(osInfo) {
+		if p.IsAvailable() {
+			providers = append(providers, p)
 		}
 	}
 
@@ -31,16 +33,51 @@ func GetProvidersForOS(osInfo *detector.OSInfo) []Provider {
 
 // GetProviderByType returns a provider instance for the given type
 func GetProviderByType(providerType string) (Provider, error) {
-	switch providerType {
-	case "brew", "brew_cask":
-		return NewBrewProvider(), nil
-	case "winget":
-		return NewWinGetProvider(), nil
-	case "apt":
-		return NewAptProvider(), nil
-	case "snap":
-		return NewSnapProvider(), nil
-	default:
+	reg, ok := Lookup(providerType)
+	if !ok {
 		return nil, fmt.Errorf("unknown provider type: %s", providerType)
 	}
+
+	return reg.New(), nil
+}
+
+// OSTargeted is implemented by providers whose commands depend on the system
+// they install on, such as the download URL of a binary
+type OSTargeted interface {
+	SetOS(osInfo *detector.OSInfo)
+}
+
+// GetProviderForOS returns a provider instance for the given type that
+// installs packages on osInfo, which may be another system
+func GetProviderForOS(providerType string, osInfo *detector.OSInfo) (Provider, error) {
+	prov, err := GetProviderByType(providerType)
+	if err != nil {
+		return nil, err
+	}
+	return withOS(prov, osInfo), nil
+}
+
+// withOS sets the target system of a provider that depends on it
+func withOS(prov Provider, osInfo *detector.OSInfo) Provider {
+	if targeted, ok := prov.(OSTargeted); ok {
+		targeted.SetOS(osInfo)
+	}
+	return prov
+}
+
+// GetFallbackProvider returns the provider to use for a spec type whose own
+// provider is unavailable, and the spec type it handles. It reports false if
+// the type has no fallback or the fallback is unavailable too.
+func GetFallbackProvider(providerType string, osInfo *detector.OSInfo) (Provider, string, bool) {
+	reg, ok := Lookup(providerType)
+	if !ok || reg.Fallback == "" {
+		return nil, "", false
+	}
+
+	prov, err := GetProviderForOS(reg.Fallback, osInfo)
+	if err != nil || !prov.IsAvailable() {
+		return nil, "", false
+	}
+
+	return prov, reg.Fallback, true
 }
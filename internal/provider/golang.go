@@ -0,0 +1,135 @@
+package provider
+
+import (
+	"context"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"github.com/Litchi-group/unipm/internal/detector"
+)
+
+// majorVersionSuffix matches the /vN suffix of Go module paths
+var majorVersionSuffix = regexp.MustCompile(`^v[0-9]+$`)
+
+func init() {
+	mustRegister(Registration{
+		Name:  "go",
+		Types: []string{"go"},
+		Guide: `Go is not installed.
+Install it from: https://go.dev/dl
+
+Binaries installed with 'go install' go to $GOBIN (default: $GOPATH/bin).
+Make sure that directory is on your PATH.`,
+		New: func() Provider { return NewGoProvider() },
+	})
+}
+
+// GoProvider handles Go binaries installed with go install
+type GoProvider struct {
+	BaseProvider
+}
+
+// NewGoProvider creates a new go install provider
+func NewGoProvider() *GoProvider {
+	return &GoProvider{
+		BaseProvider: BaseProvider{
+			name:       "go",
+			executable: "go",
+		},
+	}
+}
+
+// Install installs a Go binary using go install
+func (p *GoProvider) Install(ctx context.Context, spec ProviderSpec) error {
+	return p.executeWithDisplay(ctx, "install", p.installTarget(spec))
+}
+
+// IsInstalled checks if the binary exists in the Go bin directory
+func (p *GoProvider) IsInstalled(ctx context.Context, spec ProviderSpec) bool {
+	binPath, err := p.binaryPath(ctx, spec)
+	if err != nil {
+		return false
+	}
+
+	_, err = os.Stat(binPath)
+	return err == nil
+}
+
+// InstallCommand returns the command that would be executed
+func (p *GoProvider) InstallCommand(spec ProviderSpec) string {
+	return FormatCommand("go", "install", p.installTarget(spec))
+}
+
+// Remove deletes the installed binary, since go has no uninstall command
+func (p *GoProvider) Remove(ctx context.Context, spec ProviderSpec) error {
+	binPath, err := p.binaryPath(ctx, spec)
+	if err != nil {
+		return err
+	}
+
+	announce(ctx, FormatCommand("rm", binPath))
+	return os.Remove(binPath)
+}
+
+// RemoveCommand returns the uninstall command. The bin directory is only
+// known by asking go, which Remove does, so it is shown as $GOBIN.
+func (p *GoProvider) RemoveCommand(spec ProviderSpec) string {
+	return FormatCommand("rm", path.Join("$GOBIN", goBinaryName(spec.Name, p.targetOS())))
+}
+
+// installTarget returns the package path with a version suffix
+func (p *GoProvider) installTarget(spec ProviderSpec) string {
+	if strings.Contains(spec.Name, "@") {
+		return spec.Name
+	}
+	return spec.Name + "@latest"
+}
+
+// binDir returns the directory go install writes binaries to
+func (p *GoProvider) binDir(ctx context.Context) (string, error) {
+	gobin, err := p.execCommand(ctx, "go", "env", "GOBIN")
+	if err != nil {
+		return "", err
+	}
+	if gobin != "" {
+		return gobin, nil
+	}
+
+	gopath, err := p.execCommand(ctx, "go", "env", "GOPATH")
+	if err != nil {
+		return "", err
+	}
+	// GOPATH may be a list; go install uses the first entry
+	gopath = filepath.SplitList(gopath)[0]
+
+	return filepath.Join(gopath, "bin"), nil
+}
+
+// binaryPath returns the path of the installed binary for a spec
+func (p *GoProvider) binaryPath(ctx context.Context, spec ProviderSpec) (string, error) {
+	dir, err := p.binDir(ctx)
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, goBinaryName(spec.Name, p.targetOS())), nil
+}
+
+// goBinaryName derives the binary name go install produces for a package path
+// on osInfo (e.g., "github.com/x/tool/v2@latest" -> "tool")
+func goBinaryName(pkgPath string, osInfo *detector.OSInfo) string {
+	pkgPath, _, _ = strings.Cut(pkgPath, "@")
+
+	name := path.Base(pkgPath)
+	if majorVersionSuffix.MatchString(name) {
+		name = path.Base(path.Dir(pkgPath))
+	}
+
+	if osInfo.IsWindows() {
+		name += ".exe"
+	}
+
+	return name
+}
@@ -1,100 +1,424 @@
 package provider
 
-import "strings"
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
 
-// ListInstalled implementation for BrewProvider
-func (p *BrewProvider) ListInstalled() ([]string, error) {
-	output, err := execCommand("brew", "list", "--formula")
+// dpkgQueryFormat prints one tab-separated line per package known to dpkg
+const dpkgQueryFormat = `${binary:Package}\t${Version}\t${db:Status-Status}\n`
+
+// ListInstalled implementation for BrewProvider, covering formulae and casks
+func (p *BrewProvider) ListInstalled(ctx context.Context) ([]InstalledPackage, error) {
+	output, err := p.execCommandStdout(ctx, "brew", "info", "--json=v2", "--installed")
 	if err != nil {
 		return nil, err
 	}
 
-	return parseLines(output), nil
+	return parseBrewInfo(output)
+}
+
+// parseBrewInfo parses the output of brew info --json=v2
+func parseBrewInfo(output []byte) ([]InstalledPackage, error) {
+	var info struct {
+		Formulae []struct {
+			FullName  string `json:"full_name"`
+			Tap       string `json:"tap"`
+			Installed []struct {
+				Version            string `json:"version"`
+				InstalledOnRequest bool   `json:"installed_on_request"`
+			} `json:"installed"`
+		} `json:"formulae"`
+		Casks []struct {
+			FullToken string `json:"full_token"`
+			Tap       string `json:"tap"`
+			Installed string `json:"installed"`
+		} `json:"casks"`
+	}
+	if err := json.Unmarshal(output, &info); err != nil {
+		return nil, fmt.Errorf("failed to parse brew output: %w", err)
+	}
+
+	var packages []InstalledPackage
+	for _, formula := range info.Formulae {
+		if len(formula.Installed) == 0 {
+			continue
+		}
+
+		// Fully qualified names (hashicorp/tap/terraform) keep the tap for export
+		latest := formula.Installed[len(formula.Installed)-1]
+		packages = append(packages, InstalledPackage{
+			Name:     formula.FullName,
+			Version:  latest.Version,
+			Source:   formula.Tap,
+			Explicit: latest.InstalledOnRequest,
+		})
+	}
+	for _, cask := range info.Casks {
+		packages = append(packages, InstalledPackage{
+			Name:     cask.FullToken,
+			Version:  cask.Installed,
+			Source:   cask.Tap,
+			Explicit: true,
+		})
+	}
+
+	return packages, nil
 }
 
 // ListInstalled implementation for WinGetProvider
-func (p *WinGetProvider) ListInstalled() ([]string, error) {
-	output, err := execCommand("winget", "list")
+func (p *WinGetProvider) ListInstalled(ctx context.Context) ([]InstalledPackage, error) {
+	dir, err := os.MkdirTemp("", "unipm-winget-*")
 	if err != nil {
 		return nil, err
 	}
+	defer func() { _ = os.RemoveAll(dir) }()
 
-	// Parse winget output (skip header lines)
-	var packages []string
-	lines := strings.Split(output, "\n")
+	// winget export only writes to a file
+	path := filepath.Join(dir, "export.json")
+	if _, err := p.execCommand(ctx, "winget", "export", "--output", path,
+		"--include-versions", "--accept-source-agreements"); err != nil {
+		return nil, err
+	}
 
-	for i, line := range lines {
-		// Skip header (first 2-3 lines)
-		if i < 2 {
-			continue
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	return ParseWinGetExport(data)
+}
+
+// ParseWinGetExport parses the JSON written by winget export
+func ParseWinGetExport(data []byte) ([]InstalledPackage, error) {
+	var export struct {
+		Sources []struct {
+			Packages []struct {
+				PackageIdentifier string `json:"PackageIdentifier"`
+				Version           string `json:"Version"`
+			} `json:"Packages"`
+			SourceDetails struct {
+				Name string `json:"Name"`
+			} `json:"SourceDetails"`
+		} `json:"Sources"`
+	}
+	// winget writes a UTF-8 byte order mark
+	data = bytes.TrimPrefix(data, []byte("\xef\xbb\xbf"))
+	if err := json.Unmarshal(data, &export); err != nil {
+		return nil, fmt.Errorf("failed to parse winget export: %w", err)
+	}
+
+	var packages []InstalledPackage
+	for _, source := range export.Sources {
+		for _, pkg := range source.Packages {
+			packages = append(packages, InstalledPackage{
+				Name:     pkg.PackageIdentifier,
+				Version:  pkg.Version,
+				Source:   source.SourceDetails.Name,
+				Explicit: true,
+			})
 		}
+	}
 
-		line = strings.TrimSpace(line)
-		if line == "" || strings.HasPrefix(line, "-") {
+	return packages, nil
+}
+
+// ListInstalled implementation for AptProvider. Packages are explicit when
+// apt marks them as manually installed.
+func (p *AptProvider) ListInstalled(ctx context.Context) ([]InstalledPackage, error) {
+	output, err := p.execCommand(ctx, "dpkg-query", "-W", "-f", dpkgQueryFormat)
+	if err != nil {
+		return nil, err
+	}
+
+	// Without apt-mark every package counts as explicit
+	var manual map[string]bool
+	if marks, err := p.execCommand(ctx, "apt-mark", "showmanual"); err == nil {
+		manual = make(map[string]bool)
+		for _, name := range parseLines(marks) {
+			manual[name] = true
+		}
+	}
+
+	return parseDpkgQuery(output, manual), nil
+}
+
+// parseDpkgQuery parses dpkg-query output in dpkgQueryFormat, keeping only
+// installed packages. A nil manual set marks every package explicit.
+func parseDpkgQuery(output string, manual map[string]bool) []InstalledPackage {
+	var packages []InstalledPackage
+	for _, line := range strings.Split(output, "\n") {
+		fields := strings.Split(line, "\t")
+		// Removed packages keep their configuration with status config-files
+		if len(fields) != 3 || fields[2] != "installed" {
 			continue
 		}
 
-		// Extract package name (first column)
-		fields := strings.Fields(line)
-		if len(fields) > 0 {
-			packages = append(packages, fields[0])
+		name := fields[0]
+		explicit := manual == nil || manual[name]
+		if base, _, found := strings.Cut(name, ":"); found && manual != nil {
+			explicit = explicit || manual[base]
+		}
+
+		packages = append(packages, InstalledPackage{
+			Name:     name,
+			Version:  fields[1],
+			Explicit: explicit,
+		})
+	}
+
+	return packages
+}
+
+// ListInstalled implementation for SnapProvider, using the snapd REST API
+func (p *SnapProvider) ListInstalled(ctx context.Context) ([]InstalledPackage, error) {
+	client := &http.Client{
+		Transport: &http.Transport{
+			DialContext: func(ctx context.Context, _, _ string) (net.Conn, error) {
+				var dialer net.Dialer
+				return dialer.DialContext(ctx, "unix", p.socketPath)
+			},
+		},
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, "http://localhost/v2/snaps", nil)
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query snapd: %w", err)
+	}
+	defer func() { _ = resp.Body.Close() }()
+
+	var body struct {
+		Result json.RawMessage `json:"result"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&body); err != nil {
+		return nil, fmt.Errorf("failed to parse snapd response: %w", err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		var result struct {
+			Message string `json:"message"`
+		}
+		_ = json.Unmarshal(body.Result, &result)
+		return nil, fmt.Errorf("snapd returned %s: %s", resp.Status, result.Message)
+	}
+
+	return parseSnaps(body.Result)
+}
+
+// parseSnaps parses the result of the snapd /v2/snaps endpoint. Bases,
+// snapd itself and other non-app snaps are installed as dependencies.
+func parseSnaps(result []byte) ([]InstalledPackage, error) {
+	var snaps []struct {
+		Name            string `json:"name"`
+		Version         string `json:"version"`
+		Type            string `json:"type"`
+		TrackingChannel string `json:"tracking-channel"`
+	}
+	if err := json.Unmarshal(result, &snaps); err != nil {
+		return nil, fmt.Errorf("failed to parse snapd response: %w", err)
+	}
+
+	packages := make([]InstalledPackage, 0, len(snaps))
+	for _, snap := range snaps {
+		packages = append(packages, InstalledPackage{
+			Name:     snap.Name,
+			Version:  snap.Version,
+			Source:   snap.TrackingChannel,
+			Explicit: snap.Type == "app",
+		})
+	}
+	sort.Slice(packages, func(i, j int) bool { return packages[i].Name < packages[j].Name })
+
+	return packages, nil
+}
+
+// ListInstalled implementation for NpmProvider
+func (p *NpmProvider) ListInstalled(ctx context.Context) ([]InstalledPackage, error) {
+	// npm ls exits non-zero on peer dependency problems but still prints the tree
+	output, err := p.execCommandStdout(ctx, "npm", "ls", "-g", "--depth=0", "--json")
+	if err != nil && len(output) == 0 {
+		return nil, err
+	}
+
+	var tree struct {
+		Dependencies map[string]struct {
+			Version string `json:"version"`
+		} `json:"dependencies"`
+	}
+	if err := json.Unmarshal(output, &tree); err != nil {
+		return nil, fmt.Errorf("failed to parse npm output: %w", err)
+	}
+
+	var packages []InstalledPackage
+	for _, name := range sortedKeys(tree.Dependencies) {
+		packages = append(packages, InstalledPackage{
+			Name:     name,
+			Version:  tree.Dependencies[name].Version,
+			Explicit: true,
+		})
+	}
+
+	return packages, nil
+}
+
+// ListInstalled implementation for PipxProvider
+func (p *PipxProvider) ListInstalled(ctx context.Context) ([]InstalledPackage, error) {
+	output, err := p.execCommandStdout(ctx, "pipx", "list", "--json")
+	if err != nil {
+		return nil, err
+	}
+
+	var list struct {
+		Venvs map[string]struct {
+			Metadata struct {
+				MainPackage struct {
+					Package        string `json:"package"`
+					PackageVersion string `json:"package_version"`
+				} `json:"main_package"`
+			} `json:"metadata"`
+		} `json:"venvs"`
+	}
+	if err := json.Unmarshal(output, &list); err != nil {
+		return nil, fmt.Errorf("failed to parse pipx output: %w", err)
+	}
+
+	var packages []InstalledPackage
+	for _, venv := range sortedKeys(list.Venvs) {
+		main := list.Venvs[venv].Metadata.MainPackage
+		name := main.Package
+		if name == "" {
+			name = venv
 		}
+		packages = append(packages, InstalledPackage{
+			Name:     name,
+			Version:  main.PackageVersion,
+			Explicit: true,
+		})
 	}
 
 	return packages, nil
 }
 
-// ListInstalled implementation for AptProvider
-func (p *AptProvider) ListInstalled() ([]string, error) {
-	output, err := execCommand("dpkg", "--get-selections")
+// ListInstalled implementation for CargoProvider
+func (p *CargoProvider) ListInstalled(ctx context.Context) ([]InstalledPackage, error) {
+	output, err := p.execCommand(ctx, "cargo", "install", "--list")
 	if err != nil {
 		return nil, err
 	}
 
-	var packages []string
+	// Crates are listed as "name vX.Y.Z[ (source)]:" followed by indented binary names
+	var packages []InstalledPackage
 	for _, line := range strings.Split(output, "\n") {
-		if strings.Contains(line, "install") {
-			fields := strings.Fields(line)
-			if len(fields) > 0 {
-				packages = append(packages, fields[0])
-			}
+		if line == "" || line[0] == ' ' || line[0] == '\t' || !strings.HasSuffix(line, ":") {
+			continue
+		}
+
+		fields := strings.Fields(strings.TrimSuffix(line, ":"))
+		if len(fields) == 0 {
+			continue
+		}
+
+		pkg := InstalledPackage{Name: fields[0], Explicit: true}
+		if len(fields) > 1 {
+			pkg.Version = strings.TrimPrefix(fields[1], "v")
 		}
+		if len(fields) > 2 {
+			pkg.Source = strings.Trim(strings.Join(fields[2:], " "), "()")
+		}
+		packages = append(packages, pkg)
 	}
 
 	return packages, nil
 }
 
-// ListInstalled implementation for SnapProvider
-func (p *SnapProvider) ListInstalled() ([]string, error) {
-	output, err := execCommand("snap", "list")
+// ListInstalled implementation for GoProvider
+func (p *GoProvider) ListInstalled(ctx context.Context) ([]InstalledPackage, error) {
+	dir, err := p.binDir(ctx)
 	if err != nil {
 		return nil, err
 	}
 
-	var packages []string
-	lines := strings.Split(output, "\n")
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
 
-	for i, line := range lines {
-		// Skip header
-		if i == 0 {
+	// Recover the package path and module version from the build info
+	// embedded in each binary
+	var packages []InstalledPackage
+	for _, entry := range entries {
+		if entry.IsDir() {
 			continue
 		}
 
-		line = strings.TrimSpace(line)
-		if line == "" {
+		output, err := p.execCommand(ctx, "go", "version", "-m", filepath.Join(dir, entry.Name()))
+		if err != nil {
 			continue
 		}
 
-		// Extract package name (first column)
-		fields := strings.Fields(line)
-		if len(fields) > 0 {
-			packages = append(packages, fields[0])
+		pkg := InstalledPackage{Explicit: true}
+		for _, line := range strings.Split(output, "\n") {
+			fields := strings.Fields(line)
+			switch {
+			case len(fields) >= 2 && fields[0] == "path":
+				pkg.Name = fields[1]
+			case len(fields) >= 3 && fields[0] == "mod":
+				pkg.Version = fields[2]
+			}
+		}
+		if pkg.Name != "" {
+			packages = append(packages, pkg)
 		}
 	}
 
 	return packages, nil
 }
 
+// ListInstalled implementation for MiseProvider
+func (p *MiseProvider) ListInstalled(ctx context.Context) ([]InstalledPackage, error) {
+	versions, err := p.installedVersions(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	return installedTools(versions), nil
+}
+
+// ListInstalled implementation for AsdfProvider
+func (p *AsdfProvider) ListInstalled(ctx context.Context) ([]InstalledPackage, error) {
+	versions, err := p.installedVersions(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	return installedTools(versions), nil
+}
+
+// hasPackage reports whether packages contains one named name
+func hasPackage(packages []InstalledPackage, name string) bool {
+	for _, pkg := range packages {
+		if pkg.Name == name {
+			return true
+		}
+	}
+	return false
+}
+
 // parseLines splits output by newlines and filters empty lines
 func parseLines(output string) []string {
 	var result []string
@@ -106,3 +430,23 @@ func parseLines(output string) []string {
 	}
 	return result
 }
+
+// sortedKeys returns the keys of a map in sorted order
+func sortedKeys[T any](m map[string]T) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// containsString reports whether list contains s
+func containsString(list []string, s string) bool {
+	for _, item := range list {
+		if item == s {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,195 @@
+package provider
+
+import (
+	"context"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/Litchi-group/unipm/internal/detector"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAptProvider_ListInstalled(t *testing.T) {
+	runner := NewMockRunner().
+		On("dpkg-query -W -f "+dpkgQueryFormat, MockResponse{Stdout: fixture(t, "dpkg_query.txt")}).
+		On("apt-mark showmanual", MockResponse{Stdout: "git\ncurl\nlibssl3t64\n"})
+
+	p := NewAptProvider()
+	p.SetRunner(runner)
+
+	packages, err := p.ListInstalled(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, []InstalledPackage{
+		{Name: "adduser", Version: "3.134"},
+		{Name: "apt", Version: "2.7.14build2"},
+		{Name: "curl", Version: "8.5.0-2ubuntu10.1", Explicit: true},
+		{Name: "git", Version: "1:2.43.0-1ubuntu7", Explicit: true},
+		{Name: "libssl3t64:amd64", Version: "3.0.13-0ubuntu3", Explicit: true},
+	}, packages)
+}
+
+func TestWinGetProvider_ParseExport(t *testing.T) {
+	packages, err := ParseWinGetExport([]byte(fixture(t, "winget_export.json")))
+	require.NoError(t, err)
+	assert.Equal(t, []InstalledPackage{
+		{Name: "Git.Git", Version: "2.43.0", Source: "winget", Explicit: true},
+		{Name: "Microsoft.VisualStudioCode", Version: "1.85.2", Source: "winget", Explicit: true},
+		{Name: "Microsoft.PowerToys", Version: "0.77.0", Source: "winget", Explicit: true},
+	}, packages)
+}
+
+func TestSnapProvider_ListInstalled(t *testing.T) {
+	// Serve the snapd API on a unix socket like /run/snapd.socket
+	socket := filepath.Join(t.TempDir(), "snapd.socket")
+	listener, err := net.Listen("unix", socket)
+	require.NoError(t, err)
+
+	server := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/v2/snaps", r.URL.Path)
+		_, _ = io.WriteString(w, fixture(t, "snapd_snaps.json"))
+	}))
+	server.Listener = listener
+	server.Start()
+	defer server.Close()
+
+	p := NewSnapProvider()
+	p.socketPath = socket
+
+	packages, err := p.ListInstalled(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, []InstalledPackage{
+		{Name: "core22", Version: "20240111", Source: "latest/stable"},
+		{Name: "firefox", Version: "122.0-2", Source: "latest/stable", Explicit: true},
+		{Name: "kubectl", Version: "1.29.1", Source: "1.29/stable", Explicit: true},
+		{Name: "snapd", Version: "2.61.1", Source: "latest/stable"},
+	}, packages)
+}
+
+func TestBrewProvider_ListInstalled(t *testing.T) {
+	runner := NewMockRunner().
+		On("brew info --json=v2 --installed", MockResponse{Stdout: fixture(t, "brew_info.json")})
+
+	p := NewBrewProvider()
+	p.SetRunner(runner)
+
+	packages, err := p.ListInstalled(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, []InstalledPackage{
+		{Name: "git", Version: "2.43.0", Source: "homebrew/core", Explicit: true},
+		{Name: "pcre2", Version: "10.42", Source: "homebrew/core"},
+		{Name: "hashicorp/tap/terraform", Version: "1.7.2", Source: "hashicorp/tap", Explicit: true},
+		{Name: "visual-studio-code", Version: "1.85.2", Source: "homebrew/cask", Explicit: true},
+	}, packages)
+}
+
+func TestCargoProvider_ListInstalled(t *testing.T) {
+	runner := NewMockRunner().
+		On("cargo install --list", MockResponse{Stdout: "bat v0.24.0:\n    bat\nripgrep v14.1.0:\n    rg\n"})
+
+	p := NewCargoProvider()
+	p.SetRunner(runner)
+
+	packages, err := p.ListInstalled(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, []InstalledPackage{
+		{Name: "bat", Version: "0.24.0", Explicit: true},
+		{Name: "ripgrep", Version: "14.1.0", Explicit: true},
+	}, packages)
+}
+
+func TestNpmProvider_ListInstalled(t *testing.T) {
+	// npm ls exits 1 on peer dependency problems but still prints the tree
+	runner := NewMockRunner().
+		On("npm ls -g --depth=0 --json", MockResponse{Stdout: fixture(t, "npm_ls.json"), ExitCode: 1})
+
+	p := NewNpmProvider()
+	p.SetRunner(runner)
+
+	packages, err := p.ListInstalled(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, []InstalledPackage{
+		{Name: "@angular/cli", Version: "17.1.0", Explicit: true},
+		{Name: "npm", Version: "10.2.4", Explicit: true},
+		{Name: "typescript", Version: "5.3.3", Explicit: true},
+	}, packages)
+	assert.True(t, p.IsInstalled(context.Background(), ProviderSpec{Type: "npm", Name: "@angular/cli"}))
+	assert.False(t, p.IsInstalled(context.Background(), ProviderSpec{Type: "npm", Name: "eslint"}))
+}
+
+func TestPipxProvider_ListInstalled(t *testing.T) {
+	runner := NewMockRunner().
+		On("pipx list --json", MockResponse{Stdout: fixture(t, "pipx_list.json")})
+
+	p := NewPipxProvider()
+	p.SetRunner(runner)
+
+	packages, err := p.ListInstalled(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, []InstalledPackage{
+		{Name: "black", Version: "24.1.1", Explicit: true},
+		{Name: "httpie-dev", Version: "3.2.2", Explicit: true},
+		{Name: "poetry", Version: "1.7.1", Explicit: true},
+	}, packages)
+}
+
+func TestGoProvider_ListInstalled(t *testing.T) {
+	dir := t.TempDir()
+	for _, name := range []string{"gopls", "not-a-go-binary"} {
+		require.NoError(t, os.WriteFile(filepath.Join(dir, name), nil, 0755))
+	}
+
+	runner := NewMockRunner().
+		On("go env GOBIN", MockResponse{Stdout: dir + "\n"}).
+		On("go version -m "+filepath.Join(dir, "gopls"), MockResponse{Stdout: fixture(t, "go_version_m.txt")}).
+		On("go version -m "+filepath.Join(dir, "not-a-go-binary"), MockResponse{Stderr: "not a Go executable", ExitCode: 1})
+
+	p := NewGoProvider()
+	p.SetRunner(runner)
+
+	packages, err := p.ListInstalled(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, []InstalledPackage{
+		{Name: "golang.org/x/tools/gopls", Version: "v0.15.0", Explicit: true},
+	}, packages)
+}
+
+func TestGoBinaryName(t *testing.T) {
+	tests := []struct {
+		pkgPath  string
+		expected string
+	}{
+		{"golang.org/x/tools/gopls", "gopls"},
+		{"golang.org/x/tools/gopls@v0.15.0", "gopls"},
+		{"github.com/go-delve/delve/cmd/dlv@latest", "dlv"},
+		{"github.com/golangci/golangci-lint/v2/cmd/golangci-lint", "golangci-lint"},
+		{"github.com/air-verse/air/v2@latest", "air"},
+		{"mvdan.cc/gofumpt/v10", "gofumpt"},
+		{"example.com/tool/version", "version"},
+	}
+
+	linux := &detector.OSInfo{Platform: "linux", Arch: "amd64"}
+	windows := &detector.OSInfo{Platform: "windows", Arch: "amd64"}
+	for _, tt := range tests {
+		t.Run(tt.pkgPath, func(t *testing.T) {
+			assert.Equal(t, tt.expected, goBinaryName(tt.pkgPath, linux))
+			assert.Equal(t, tt.expected+".exe", goBinaryName(tt.pkgPath, windows))
+		})
+	}
+}
+
+func TestGoProvider_RemoveCommand(t *testing.T) {
+	// Showing the command runs nothing
+	runner := NewMockRunner()
+	p := NewGoProvider()
+	p.SetRunner(runner)
+	p.SetOS(&detector.OSInfo{Platform: "windows", Arch: "amd64"})
+
+	assert.Equal(t, "rm $GOBIN/dlv.exe", p.RemoveCommand(ProviderSpec{Type: "go", Name: "github.com/go-delve/delve/cmd/dlv@latest"}))
+	assert.Empty(t, runner.CommandLines())
+}
@@ -0,0 +1,56 @@
+package provider
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestExecCommand_StableLocale(t *testing.T) {
+	runner := NewMockRunner().
+		On("apt list --installed git", MockResponse{Stdout: fixture(t, "apt_list_installed.txt")})
+
+	p := NewAptProvider()
+	p.SetRunner(runner)
+	p.IsInstalled(context.Background(), ProviderSpec{Name: "git"})
+
+	require.Len(t, runner.Calls, 1)
+	assert.Subset(t, runner.Calls[0].Env, []string{"LC_ALL=C", "LANG=C"})
+}
+
+// Parsers must not depend on translated headers or status words, in case a
+// package manager ignores the locale
+func TestParsers_LocalizedOutput(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("apt", func(t *testing.T) {
+		runner := NewMockRunner().
+			On("apt list --installed git", MockResponse{Stdout: fixture(t, "apt_list_installed_ko.txt")})
+		p := NewAptProvider()
+		p.SetRunner(runner)
+
+		assert.True(t, p.IsInstalled(ctx, ProviderSpec{Name: "git"}))
+	})
+
+	t.Run("winget", func(t *testing.T) {
+		runner := NewMockRunner().
+			On("winget list --id Git.Git", MockResponse{Stdout: fixture(t, "winget_list_ko.txt")})
+		p := NewWinGetProvider()
+		p.SetRunner(runner)
+
+		assert.True(t, p.IsInstalled(ctx, ProviderSpec{ID: "Git.Git"}))
+	})
+
+	t.Run("snap", func(t *testing.T) {
+		runner := NewMockRunner().
+			On("snap list kubectl", MockResponse{Stdout: fixture(t, "snap_list_ko.txt")})
+		p := NewSnapProvider()
+		p.SetRunner(runner)
+
+		assert.True(t, p.IsInstalled(ctx, ProviderSpec{Name: "kubectl", Channel: "1.29/stable"}))
+		assert.Empty(t, p.Drift(ctx, ProviderSpec{Name: "kubectl", Channel: "1.29/stable"}))
+		assert.Equal(t, "tracks 1.29/stable instead of latest/edge", p.Drift(ctx, ProviderSpec{Name: "kubectl", Channel: "latest/edge"}))
+	})
+}
@@ -0,0 +1,397 @@
+package provider
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+// asdfPluginNames maps tool names to asdf plugin names where they differ
+var asdfPluginNames = map[string]string{
+	"node": "nodejs",
+	"go":   "golang",
+}
+
+func init() {
+	mustRegister(Registration{
+		Name:  "mise",
+		Types: []string{"mise"},
+		Guide: `mise is not installed.
+Install it from: https://mise.jdx.dev
+
+  curl https://mise.run | sh
+
+If you already use asdf, packages of type mise are installed with asdf instead.`,
+		Fallback:     "asdf",
+		Capabilities: Capabilities{SupportsVersions: true},
+		New:          func() Provider { return NewMiseProvider() },
+	})
+
+	mustRegister(Registration{
+		Name:  "asdf",
+		Types: []string{"asdf"},
+		Guide: `asdf is not installed.
+Install it from: https://asdf-vm.com (version 0.16 or later)`,
+		Capabilities: Capabilities{SupportsVersions: true},
+		New:          func() Provider { return NewAsdfProvider() },
+	})
+}
+
+// MiseProvider handles runtimes managed by mise
+type MiseProvider struct {
+	BaseProvider
+}
+
+// NewMiseProvider creates a new mise provider
+func NewMiseProvider() *MiseProvider {
+	return &MiseProvider{
+		BaseProvider: BaseProvider{
+			name:       "mise",
+			executable: "mise",
+		},
+	}
+}
+
+// Install installs a runtime version and makes it the global default
+func (p *MiseProvider) Install(ctx context.Context, spec ProviderSpec) error {
+	if err := checkConstraint(spec.Version); err != nil {
+		return err
+	}
+	tool := miseToolVersion(spec)
+
+	if err := p.executeWithDisplay(ctx, "install", tool); err != nil {
+		return err
+	}
+	return p.executeWithDisplay(ctx, "use", "-g", tool)
+}
+
+// IsInstalled checks if a matching runtime version is installed
+func (p *MiseProvider) IsInstalled(ctx context.Context, spec ProviderSpec) bool {
+	versions, err := p.installedVersions(ctx)
+	if err != nil {
+		return false
+	}
+
+	return matchesVersion(versions[spec.Name], spec.Version)
+}
+
+// InstallCommand returns the command that would be executed
+func (p *MiseProvider) InstallCommand(spec ProviderSpec) string {
+	tool := miseToolVersion(spec)
+	return FormatCommand("mise", "install", tool) + " && " + FormatCommand("mise", "use", "-g", tool)
+}
+
+// Remove uninstalls a runtime version, leaving other versions in place
+func (p *MiseProvider) Remove(ctx context.Context, spec ProviderSpec) error {
+	return p.executeWithDisplay(ctx, p.buildRemoveArgs(spec)...)
+}
+
+// RemoveCommand returns the uninstall command
+func (p *MiseProvider) RemoveCommand(spec ProviderSpec) string {
+	return FormatCommand("mise", p.buildRemoveArgs(spec)...)
+}
+
+// buildRemoveArgs builds removal arguments
+func (p *MiseProvider) buildRemoveArgs(spec ProviderSpec) []string {
+	if spec.Version == "" {
+		return []string{"uninstall", "--all", spec.Name}
+	}
+	return []string{"uninstall", miseToolVersion(spec)}
+}
+
+// installedVersions returns installed versions per tool from mise ls --json
+func (p *MiseProvider) installedVersions(ctx context.Context) (map[string][]string, error) {
+	output, err := p.execCommandStdout(ctx, "mise", "ls", "--json", "--installed")
+	if err != nil {
+		return nil, err
+	}
+
+	var tools map[string][]struct {
+		Version   string `json:"version"`
+		Installed bool   `json:"installed"`
+	}
+	if err := json.Unmarshal(output, &tools); err != nil {
+		return nil, fmt.Errorf("failed to parse mise output: %w", err)
+	}
+
+	result := make(map[string][]string)
+	for tool, entries := range tools {
+		for _, entry := range entries {
+			if entry.Installed {
+				result[tool] = append(result[tool], entry.Version)
+			}
+		}
+	}
+
+	return result, nil
+}
+
+// AsdfProvider handles runtimes managed by asdf
+type AsdfProvider struct {
+	BaseProvider
+}
+
+// NewAsdfProvider creates a new asdf provider
+func NewAsdfProvider() *AsdfProvider {
+	return &AsdfProvider{
+		BaseProvider: BaseProvider{
+			name:       "asdf",
+			executable: "asdf",
+		},
+	}
+}
+
+// Install adds the plugin, installs a runtime version and sets it globally
+func (p *AsdfProvider) Install(ctx context.Context, spec ProviderSpec) error {
+	if err := checkConstraint(spec.Version); err != nil {
+		return err
+	}
+	plugin := asdfPlugin(spec.Name)
+	version := asdfVersion(spec.Version)
+
+	// Adding an existing plugin fails harmlessly
+	_ = p.executeWithDisplay(ctx, "plugin", "add", plugin)
+
+	if err := p.executeWithDisplay(ctx, "install", plugin, version); err != nil {
+		return err
+	}
+	return p.executeWithDisplay(ctx, "set", "-u", plugin, version)
+}
+
+// IsInstalled checks if a matching runtime version is installed
+func (p *AsdfProvider) IsInstalled(ctx context.Context, spec ProviderSpec) bool {
+	versions, err := p.installedVersions(ctx)
+	if err != nil {
+		return false
+	}
+
+	return matchesVersion(versions[asdfPlugin(spec.Name)], spec.Version)
+}
+
+// InstallCommand returns the command that would be executed
+func (p *AsdfProvider) InstallCommand(spec ProviderSpec) string {
+	plugin := asdfPlugin(spec.Name)
+	version := asdfVersion(spec.Version)
+	return FormatCommand("asdf", "install", plugin, version) + " && " + FormatCommand("asdf", "set", "-u", plugin, version)
+}
+
+// Remove uninstalls a runtime version
+func (p *AsdfProvider) Remove(ctx context.Context, spec ProviderSpec) error {
+	args, err := p.buildRemoveArgs(ctx, spec)
+	if err != nil {
+		return err
+	}
+	return p.executeWithDisplay(ctx, args...)
+}
+
+// RemoveCommand returns the uninstall command. asdf needs an exact version,
+// which Remove looks up among the installed ones, so the requested version
+// is shown instead.
+func (p *AsdfProvider) RemoveCommand(spec ProviderSpec) string {
+	return FormatCommand("asdf", "uninstall", asdfPlugin(spec.Name), MiseVersion(spec.Version))
+}
+
+// buildRemoveArgs resolves the exact installed version to uninstall,
+// since asdf does not accept prefixes
+func (p *AsdfProvider) buildRemoveArgs(ctx context.Context, spec ProviderSpec) ([]string, error) {
+	plugin := asdfPlugin(spec.Name)
+
+	versions, err := p.installedVersions(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, v := range versions[plugin] {
+		if matchesVersion([]string{v}, spec.Version) {
+			return []string{"uninstall", plugin, v}, nil
+		}
+	}
+
+	return nil, fmt.Errorf("no installed %s version matches %s", plugin, MiseVersion(spec.Version))
+}
+
+// installedVersions parses asdf list output:
+//
+//	nodejs
+//	  18.19.0
+//	 *20.11.0
+func (p *AsdfProvider) installedVersions(ctx context.Context) (map[string][]string, error) {
+	output, err := p.execCommand(ctx, "asdf", "list")
+	if err != nil {
+		return nil, err
+	}
+
+	return parseAsdfList(output), nil
+}
+
+// parseAsdfList parses the plugin/version tree printed by asdf list
+func parseAsdfList(output string) map[string][]string {
+	result := make(map[string][]string)
+	current := ""
+
+	for _, line := range strings.Split(output, "\n") {
+		if strings.TrimSpace(line) == "" {
+			continue
+		}
+
+		if line[0] != ' ' && line[0] != '\t' {
+			current = strings.TrimSpace(line)
+			continue
+		}
+
+		version := strings.TrimPrefix(strings.TrimSpace(line), "*")
+		if current != "" && version != "" && !strings.HasPrefix(version, "No versions") {
+			result[current] = append(result[current], version)
+		}
+	}
+
+	return result
+}
+
+// MiseVersion converts a devpack version constraint to a mise version prefix
+// (e.g., "18.x" -> "18", "~3.11" -> "3.11", "^1.2.3" -> "1", ">=20" -> "latest").
+// The lower bound of ^, ~ and >= constraints is checked by matchesVersion.
+func MiseVersion(constraint string) string {
+	constraint = strings.TrimSpace(constraint)
+
+	switch {
+	case constraint == "":
+		return "latest"
+	case strings.HasPrefix(constraint, ">="):
+		return "latest"
+	case strings.HasPrefix(constraint, "^"):
+		major, _, _ := strings.Cut(strings.TrimPrefix(constraint, "^"), ".")
+		return major
+	case strings.HasPrefix(constraint, "~"):
+		parts := strings.SplitN(strings.TrimPrefix(constraint, "~"), ".", 3)
+		if len(parts) > 2 {
+			parts = parts[:2]
+		}
+		return strings.Join(parts, ".")
+	}
+
+	return trimWildcards(constraint)
+}
+
+// checkConstraint returns an error for version constraints that cannot be
+// installed as a prefix: upper bounds, exclusions and ranges
+func checkConstraint(constraint string) error {
+	constraint = strings.TrimSpace(constraint)
+	rest := strings.TrimPrefix(constraint, ">=")
+	rest = strings.TrimPrefix(strings.TrimPrefix(rest, "^"), "~")
+
+	if strings.ContainsAny(rest, "<>=!^~, |") {
+		return fmt.Errorf("unsupported version constraint %q: use a version or prefix (18, 18.x), ^, ~ or >=", constraint)
+	}
+	return nil
+}
+
+// versionLowerBound returns the least version a ^, ~ or >= constraint allows
+// (e.g., "^1.2.3" -> "1.2.3", ">=20" -> "20"), or "" for other constraints
+func versionLowerBound(constraint string) string {
+	constraint = strings.TrimSpace(constraint)
+
+	for _, op := range []string{">=", "^", "~"} {
+		if strings.HasPrefix(constraint, op) {
+			return trimWildcards(strings.TrimPrefix(constraint, op))
+		}
+	}
+	return ""
+}
+
+// trimWildcards removes trailing ".x" parts of a version (e.g., "3.x.x" -> "3")
+func trimWildcards(version string) string {
+	for strings.HasSuffix(version, ".x") {
+		version = strings.TrimSuffix(version, ".x")
+	}
+	return version
+}
+
+// compareVersions compares dotted versions part by part as numbers, reading
+// the leading digits of each part (e.g., "20.1.0" > "20", "3.10" > "3.9")
+func compareVersions(a, b string) int {
+	as, bs := strings.Split(a, "."), strings.Split(b, ".")
+	for i := 0; i < len(as) || i < len(bs); i++ {
+		x, y := versionPart(as, i), versionPart(bs, i)
+		switch {
+		case x < y:
+			return -1
+		case x > y:
+			return 1
+		}
+	}
+	return 0
+}
+
+// versionPart returns the leading number of the i-th part, 0 if missing
+func versionPart(parts []string, i int) int {
+	if i >= len(parts) {
+		return 0
+	}
+
+	n := 0
+	for _, r := range parts[i] {
+		if r < '0' || r > '9' {
+			break
+		}
+		n = n*10 + int(r-'0')
+	}
+	return n
+}
+
+// miseToolVersion returns the tool@version argument for a spec
+func miseToolVersion(spec ProviderSpec) string {
+	return spec.Name + "@" + MiseVersion(spec.Version)
+}
+
+// asdfPlugin returns the asdf plugin name for a tool
+func asdfPlugin(tool string) string {
+	if plugin, ok := asdfPluginNames[tool]; ok {
+		return plugin
+	}
+	return tool
+}
+
+// asdfVersion converts a constraint to an asdf version ("latest:18" for prefixes)
+func asdfVersion(constraint string) string {
+	version := MiseVersion(constraint)
+	if version == "latest" || strings.Count(version, ".") >= 2 {
+		return version
+	}
+	return "latest:" + version
+}
+
+// matchesVersion reports whether any installed version meets a devpack
+// constraint: it must have the constraint's prefix and be at least its lower
+// bound, so ">=20" is not met by 16.20.0. Unsupported constraints never match.
+func matchesVersion(installed []string, constraint string) bool {
+	if checkConstraint(constraint) != nil {
+		return false
+	}
+
+	prefix := MiseVersion(constraint)
+	lower := versionLowerBound(constraint)
+	for _, v := range installed {
+		if prefix != "latest" && v != prefix && !strings.HasPrefix(v, prefix+".") {
+			continue
+		}
+		if lower != "" && compareVersions(v, lower) < 0 {
+			continue
+		}
+		return true
+	}
+	return false
+}
+
+// installedTools flattens installed versions into one entry per tool
+// version, sorted by tool
+func installedTools(versions map[string][]string) []InstalledPackage {
+	var result []InstalledPackage
+	for _, tool := range sortedKeys(versions) {
+		for _, v := range versions[tool] {
+			result = append(result, InstalledPackage{Name: tool, Version: v, Explicit: true})
+		}
+	}
+	return result
+}
@@ -0,0 +1,186 @@
+package provider
+
+import (
+	"context"
+	"io"
+	"testing"
+
+	"github.com/Litchi-group/unipm/internal/detector"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMiseVersion(t *testing.T) {
+	tests := []struct {
+		constraint string
+		expected   string
+	}{
+		{"", "latest"},
+		{"  ", "latest"},
+		{"18", "18"},
+		{"18.x", "18"},
+		{"3.x.x", "3"},
+		{"3.11.x", "3.11"},
+		{"20.11.0", "20.11.0"},
+		{"~3.11", "3.11"},
+		{"~3.11.4", "3.11"},
+		{"^1.2.3", "1"},
+		{">=20", "latest"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.constraint, func(t *testing.T) {
+			assert.Equal(t, tt.expected, MiseVersion(tt.constraint))
+		})
+	}
+}
+
+func TestAsdfVersion(t *testing.T) {
+	tests := []struct {
+		constraint string
+		expected   string
+	}{
+		{"", "latest"},
+		{"18.x", "latest:18"},
+		{"~3.11", "latest:3.11"},
+		{"20.11.0", "20.11.0"},
+		{">=20", "latest"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.constraint, func(t *testing.T) {
+			assert.Equal(t, tt.expected, asdfVersion(tt.constraint))
+		})
+	}
+}
+
+func TestParseAsdfList(t *testing.T) {
+	output := `golang
+  No versions installed
+nodejs
+  18.19.0
+ *20.11.0
+python
+	3.12.1
+
+`
+	assert.Equal(t, map[string][]string{
+		"nodejs": {"18.19.0", "20.11.0"},
+		"python": {"3.12.1"},
+	}, parseAsdfList(output))
+	assert.Empty(t, parseAsdfList(""))
+}
+
+func TestMatchesVersion(t *testing.T) {
+	installed := []string{"16.20.2", "18.19.0", "20.11.0"}
+
+	tests := []struct {
+		constraint string
+		expected   bool
+	}{
+		{"", true},
+		{"latest", true},
+		{"18", true},
+		{"18.x", true},
+		{"18.19", true},
+		{"20.11.0", true},
+		{"2", false},
+		{"20.1", false},
+		{"21", false},
+		{">=20", true},
+		{">=20.12", false},
+		{">=22", false},
+		{"^18.19.0", true},
+		{"^18.20", false},
+		{"~20.11.0", true},
+		{"~16.21", false},
+		{"<20", false},
+		{">=16 <18", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.constraint, func(t *testing.T) {
+			assert.Equal(t, tt.expected, matchesVersion(installed, tt.constraint))
+		})
+	}
+	assert.False(t, matchesVersion(nil, "latest"))
+
+	// Only node 16 is installed
+	assert.False(t, matchesVersion([]string{"16.20.2"}, ">=20"))
+}
+
+func TestCompareVersions(t *testing.T) {
+	tests := []struct {
+		a, b     string
+		expected int
+	}{
+		{"20", "20.0.0", 0},
+		{"20.1.0", "20", 1},
+		{"3.9", "3.10", -1},
+		{"1.2.3-rc1", "1.2.3", 0},
+		{"16.20.2", "20", -1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.a+" "+tt.b, func(t *testing.T) {
+			assert.Equal(t, tt.expected, compareVersions(tt.a, tt.b))
+		})
+	}
+}
+
+func TestCheckConstraint(t *testing.T) {
+	for _, ok := range []string{"", "18", "18.x", "lts", "^1.2", "~3.11", ">=20"} {
+		assert.NoError(t, checkConstraint(ok), ok)
+	}
+	for _, bad := range []string{"<20", ">20", "=1.2", "!=3", ">=16 <18", "1 || 2", ">=1,<2"} {
+		assert.ErrorContains(t, checkConstraint(bad), "unsupported version constraint", bad)
+	}
+
+	p := NewMiseProvider()
+	p.SetRunner(NewMockRunner())
+	assert.ErrorContains(t, p.Install(context.Background(), ProviderSpec{Type: "mise", Name: "node", Version: "<20"}), "unsupported version constraint")
+}
+
+func TestAsdfProvider_Remove(t *testing.T) {
+	runner := NewMockRunner().
+		On("asdf list", MockResponse{Stdout: "nodejs\n  18.19.0\n *20.11.0\n"}).
+		On("asdf uninstall nodejs 20.11.0", MockResponse{})
+	p := NewAsdfProvider()
+	p.SetRunner(runner)
+	spec := ProviderSpec{Type: "asdf", Name: "node", Version: ">=20"}
+
+	// Showing the command runs nothing
+	assert.Equal(t, "asdf uninstall nodejs latest", p.RemoveCommand(spec))
+	assert.Empty(t, runner.CommandLines())
+
+	ctx := WithOutput(context.Background(), io.Discard, io.Discard)
+	require.NoError(t, p.Remove(ctx, spec))
+	assert.Equal(t, []string{"asdf list", "asdf uninstall nodejs 20.11.0"}, runner.CommandLines())
+
+	assert.ErrorContains(t, p.Remove(ctx, ProviderSpec{Type: "asdf", Name: "node", Version: "22"}), "no installed nodejs version matches 22")
+}
+
+func TestMiseRegistration_FallsBackToAsdf(t *testing.T) {
+	runner := NewMockRunner()
+	runner.SetAvailable("mise", false)
+	defer SetDefaultRunner(SetDefaultRunner(runner))
+
+	mise, err := GetProviderByType("mise")
+	require.NoError(t, err)
+	assert.Equal(t, "mise", mise.Name())
+	assert.False(t, mise.IsAvailable())
+	assert.Equal(t, "mise install node@18 && mise use -g node@18",
+		mise.InstallCommand(ProviderSpec{Type: "mise", Name: "node", Version: "18.x"}))
+
+	fallback, fallbackType, ok := GetFallbackProvider("mise", detector.DetectOS())
+	require.True(t, ok)
+	assert.Equal(t, "asdf", fallbackType)
+	assert.Equal(t, "asdf", fallback.Name())
+
+	runner.SetAvailable("asdf", false)
+	_, _, ok = GetFallbackProvider("mise", detector.DetectOS())
+	assert.False(t, ok)
+
+	_, _, ok = GetFallbackProvider("npm", detector.DetectOS())
+	assert.False(t, ok)
+}
@@ -0,0 +1,110 @@
+package provider
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"strings"
+	"sync"
+)
+
+// MockResponse is the scripted result of a command run by MockRunner
+type MockResponse struct {
+	Stdout   string
+	Stderr   string
+	ExitCode int   // Non-zero exit codes are returned as errors
+	Err      error // Returned as is, overriding ExitCode
+}
+
+// MockRunner is a scripted CommandRunner for testing providers
+type MockRunner struct {
+	mu        sync.Mutex
+	Calls     []Command               // Commands run, in order
+	Responses map[string]MockResponse // Keyed by the full command line
+	Available map[string]bool         // LookPath results; missing entries are available
+	Default   *MockResponse           // Response for unscripted commands (nil fails them)
+}
+
+// NewMockRunner creates a new mock runner
+func NewMockRunner() *MockRunner {
+	return &MockRunner{
+		Responses: make(map[string]MockResponse),
+		Available: make(map[string]bool),
+	}
+}
+
+// On scripts the response for a command line (e.g., "apt list --installed git")
+func (m *MockRunner) On(commandLine string, resp MockResponse) *MockRunner {
+	m.Responses[commandLine] = resp
+	return m
+}
+
+// SetAvailable sets whether LookPath finds an executable
+func (m *MockRunner) SetAvailable(file string, available bool) {
+	m.Available[file] = available
+}
+
+// Run records the command and replays its scripted response
+func (m *MockRunner) Run(ctx context.Context, cmd Command) error {
+	m.mu.Lock()
+	m.Calls = append(m.Calls, cmd)
+	resp, ok := m.Responses[strings.Join(cmd.Argv(), " ")]
+	if !ok && m.Default != nil {
+		resp, ok = *m.Default, true
+	}
+	m.mu.Unlock()
+
+	if !ok {
+		return fmt.Errorf("unexpected command: %s", strings.Join(cmd.Argv(), " "))
+	}
+
+	if cmd.Stdout != nil {
+		_, _ = io.WriteString(cmd.Stdout, resp.Stdout)
+	}
+	if cmd.Stderr != nil {
+		_, _ = io.WriteString(cmd.Stderr, resp.Stderr)
+	}
+
+	if resp.Err != nil {
+		return resp.Err
+	}
+	if resp.ExitCode != 0 {
+		return &MockExitError{Code: resp.ExitCode}
+	}
+
+	return nil
+}
+
+// LookPath reports executables as available unless marked otherwise
+func (m *MockRunner) LookPath(file string) (string, error) {
+	if available, ok := m.Available[file]; ok && !available {
+		return "", fmt.Errorf("executable file not found in $PATH: %s", file)
+	}
+	return "/usr/bin/" + file, nil
+}
+
+// CommandLines returns the command lines run so far
+func (m *MockRunner) CommandLines() []string {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	lines := make([]string, len(m.Calls))
+	for i, cmd := range m.Calls {
+		lines[i] = strings.Join(cmd.Argv(), " ")
+	}
+	return lines
+}
+
+// MockExitError simulates a command exiting with a non-zero status
+type MockExitError struct {
+	Code int
+}
+
+func (e *MockExitError) Error() string {
+	return fmt.Sprintf("exit status %d", e.Code)
+}
+
+// ExitCode returns the simulated exit code
+func (e *MockExitError) ExitCode() int {
+	return e.Code
+}
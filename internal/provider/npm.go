@@ -0,0 +1,56 @@
+package provider
+
+import "context"
+
+func init() {
+	mustRegister(Registration{
+		Name:  "npm",
+		Types: []string{"npm"},
+		Guide: `npm is not installed.
+npm ships with Node.js. Install Node.js from: https://nodejs.org
+
+  unipm can install it for you: add "node" to your devpack.yaml`,
+		New: func() Provider { return NewNpmProvider() },
+	})
+}
+
+// NpmProvider handles globally installed npm packages
+type NpmProvider struct {
+	BaseProvider
+}
+
+// NewNpmProvider creates a new npm provider
+func NewNpmProvider() *NpmProvider {
+	return &NpmProvider{
+		BaseProvider: BaseProvider{
+			name:       "npm",
+			executable: "npm",
+		},
+	}
+}
+
+// Install installs a package globally using npm
+func (p *NpmProvider) Install(ctx context.Context, spec ProviderSpec) error {
+	return p.executeWithDisplay(ctx, "install", "-g", spec.Name)
+}
+
+// IsInstalled checks if a package is installed globally
+func (p *NpmProvider) IsInstalled(ctx context.Context, spec ProviderSpec) bool {
+	packages, err := p.ListInstalled(ctx)
+	return err == nil && hasPackage(packages, spec.Name)
+}
+
+// InstallCommand returns the command that would be executed
+func (p *NpmProvider) InstallCommand(spec ProviderSpec) string {
+	return FormatCommand("npm", "install", "-g", spec.Name)
+}
+
+// Remove removes a globally installed npm package
+func (p *NpmProvider) Remove(ctx context.Context, spec ProviderSpec) error {
+	return p.executeWithDisplay(ctx, "uninstall", "-g", spec.Name)
+}
+
+// RemoveCommand returns the uninstall command
+func (p *NpmProvider) RemoveCommand(spec ProviderSpec) string {
+	return FormatCommand("npm", "uninstall", "-g", spec.Name)
+}
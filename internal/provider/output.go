@@ -0,0 +1,49 @@
+package provider
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+)
+
+// outputKey is the context key for taskOutput
+type outputKey struct{}
+
+// taskOutput holds where a task's commands report to
+type taskOutput struct {
+	output io.Writer // Command stdout and stderr
+	status io.Writer // Commands being run
+}
+
+// WithOutput returns a context in which providers stream command output to
+// output and announce the commands they run on status
+func WithOutput(ctx context.Context, output, status io.Writer) context.Context {
+	return context.WithValue(ctx, outputKey{}, taskOutput{output: output, status: status})
+}
+
+// outputFrom returns the command output writer of a context, or nil
+func outputFrom(ctx context.Context) io.Writer {
+	if out, ok := ctx.Value(outputKey{}).(taskOutput); ok {
+		return out.output
+	}
+	return nil
+}
+
+// statusFrom returns the status writer of a context, or os.Stdout
+func statusFrom(ctx context.Context) io.Writer {
+	if out, ok := ctx.Value(outputKey{}).(taskOutput); ok && out.status != nil {
+		return out.status
+	}
+	return os.Stdout
+}
+
+// announce prints a command before it runs
+func announce(ctx context.Context, command string) {
+	_, _ = fmt.Fprintf(statusFrom(ctx), "  → %s\n", command)
+}
+
+// note prints a message about a task next to its commands
+func note(ctx context.Context, format string, args ...interface{}) {
+	_, _ = fmt.Fprintf(statusFrom(ctx), "  Note: "+format+"\n", args...)
+}
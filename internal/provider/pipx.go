@@ -0,0 +1,57 @@
+package provider
+
+import "context"
+
+func init() {
+	mustRegister(Registration{
+		Name:  "pipx",
+		Types: []string{"pipx"},
+		Guide: `pipx is not installed.
+Install it from: https://pipx.pypa.io
+
+  python3 -m pip install --user pipx
+  python3 -m pipx ensurepath`,
+		New: func() Provider { return NewPipxProvider() },
+	})
+}
+
+// PipxProvider handles Python applications installed with pipx
+type PipxProvider struct {
+	BaseProvider
+}
+
+// NewPipxProvider creates a new pipx provider
+func NewPipxProvider() *PipxProvider {
+	return &PipxProvider{
+		BaseProvider: BaseProvider{
+			name:       "pipx",
+			executable: "pipx",
+		},
+	}
+}
+
+// Install installs a package using pipx
+func (p *PipxProvider) Install(ctx context.Context, spec ProviderSpec) error {
+	return p.executeWithDisplay(ctx, "install", spec.Name)
+}
+
+// IsInstalled checks if a package is installed
+func (p *PipxProvider) IsInstalled(ctx context.Context, spec ProviderSpec) bool {
+	packages, err := p.ListInstalled(ctx)
+	return err == nil && hasPackage(packages, spec.Name)
+}
+
+// InstallCommand returns the command that would be executed
+func (p *PipxProvider) InstallCommand(spec ProviderSpec) string {
+	return FormatCommand("pipx", "install", spec.Name)
+}
+
+// Remove removes a package using pipx
+func (p *PipxProvider) Remove(ctx context.Context, spec ProviderSpec) error {
+	return p.executeWithDisplay(ctx, "uninstall", spec.Name)
+}
+
+// RemoveCommand returns the uninstall command
+func (p *PipxProvider) RemoveCommand(spec ProviderSpec) string {
+	return FormatCommand("pipx", "uninstall", spec.Name)
+}
@@ -0,0 +1,314 @@
+package provider
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"sync"
+
+	"github.com/Litchi-group/unipm/internal/logger"
+)
+
+const (
+	// PluginPrefix is the executable name prefix of provider plugins
+	PluginPrefix = "unipm-provider-"
+
+	// PluginDir is the plugin directory, relative to home
+	PluginDir = ".unipm/plugins"
+
+	// PluginProtocolVersion is the version of the plugin protocol
+	PluginProtocolVersion = 1
+)
+
+var (
+	pluginsOnce sync.Once
+	plugins     map[string]*PluginProvider
+)
+
+// PluginRequest is the JSON request written to a plugin's stdin
+type PluginRequest struct {
+	Protocol int         `json:"protocol"`
+	Method   string      `json:"method"` // available, install, remove, is_installed, list_installed, version
+	Spec     *PluginSpec `json:"spec,omitempty"`
+}
+
+// PluginSpec is the package specification sent to plugins
+type PluginSpec struct {
+	Type    string `json:"type"`
+	Name    string `json:"name"`
+	ID      string `json:"id,omitempty"`
+	Version string `json:"version,omitempty"`
+}
+
+// PluginResponse is the JSON response a plugin writes to stdout
+type PluginResponse struct {
+	Result json.RawMessage `json:"result,omitempty"`
+	Error  string          `json:"error,omitempty"`
+}
+
+// PluginProvider is a provider implemented by an external executable
+type PluginProvider struct {
+	BaseProvider
+	path string
+}
+
+// NewPluginProvider creates a provider for the plugin executable at path
+func NewPluginProvider(name, path string) *PluginProvider {
+	return &PluginProvider{
+		BaseProvider: BaseProvider{
+			name:       name,
+			executable: path,
+		},
+		path: path,
+	}
+}
+
+// Path returns the plugin executable path
+func (p *PluginProvider) Path() string {
+	return p.path
+}
+
+// IsAvailable asks the plugin whether its package manager can be used
+func (p *PluginProvider) IsAvailable() bool {
+	var available bool
+	if err := p.call(context.Background(), "available", nil, &available); err != nil {
+		logger.Debug("Plugin %s unavailable: %v", p.name, err)
+		return false
+	}
+	return available
+}
+
+// Install installs a package through the plugin
+func (p *PluginProvider) Install(ctx context.Context, spec ProviderSpec) error {
+	announce(ctx, p.InstallCommand(spec))
+	return p.call(ctx, "install", &spec, nil)
+}
+
+// IsInstalled asks the plugin whether a package is installed
+func (p *PluginProvider) IsInstalled(ctx context.Context, spec ProviderSpec) bool {
+	var installed bool
+	if err := p.call(ctx, "is_installed", &spec, &installed); err != nil {
+		logger.Debug("Plugin %s is_installed failed: %v", p.name, err)
+		return false
+	}
+	return installed
+}
+
+// InstallCommand returns a description of the plugin call
+func (p *PluginProvider) InstallCommand(spec ProviderSpec) string {
+	return FormatCommand(PluginPrefix+p.name, "install", spec.Name)
+}
+
+// Remove removes a package through the plugin
+func (p *PluginProvider) Remove(ctx context.Context, spec ProviderSpec) error {
+	announce(ctx, p.RemoveCommand(spec))
+	return p.call(ctx, "remove", &spec, nil)
+}
+
+// RemoveCommand returns a description of the plugin call
+func (p *PluginProvider) RemoveCommand(spec ProviderSpec) string {
+	return FormatCommand(PluginPrefix+p.name, "remove", spec.Name)
+}
+
+// PluginPackage is an installed package reported by a plugin
+type PluginPackage struct {
+	Name     string `json:"name"`
+	Version  string `json:"version,omitempty"`
+	Source   string `json:"source,omitempty"`
+	Explicit *bool  `json:"explicit,omitempty"` // Defaults to true
+}
+
+// ListInstalled asks the plugin for installed packages. Plugins may report
+// bare package names or PluginPackage objects.
+func (p *PluginProvider) ListInstalled(ctx context.Context) ([]InstalledPackage, error) {
+	var entries []json.RawMessage
+	if err := p.call(ctx, "list_installed", nil, &entries); err != nil {
+		return nil, err
+	}
+
+	packages := make([]InstalledPackage, 0, len(entries))
+	for _, entry := range entries {
+		var name string
+		if err := json.Unmarshal(entry, &name); err == nil {
+			packages = append(packages, InstalledPackage{Name: name, Explicit: true})
+			continue
+		}
+
+		var pkg PluginPackage
+		if err := json.Unmarshal(entry, &pkg); err != nil || pkg.Name == "" {
+			return nil, fmt.Errorf("plugin %s returned an invalid package: %s", p.name, entry)
+		}
+		packages = append(packages, InstalledPackage{
+			Name:     pkg.Name,
+			Version:  pkg.Version,
+			Source:   pkg.Source,
+			Explicit: pkg.Explicit == nil || *pkg.Explicit,
+		})
+	}
+	return packages, nil
+}
+
+// Version returns the plugin's self-reported version
+func (p *PluginProvider) Version() (string, error) {
+	var version string
+	if err := p.call(context.Background(), "version", nil, &version); err != nil {
+		return "", err
+	}
+	return version, nil
+}
+
+// call sends a request to the plugin and decodes the result into out
+func (p *PluginProvider) call(ctx context.Context, method string, spec *ProviderSpec, out interface{}) error {
+	req := PluginRequest{
+		Protocol: PluginProtocolVersion,
+		Method:   method,
+	}
+	if spec != nil {
+		req.Spec = &PluginSpec{
+			Type:    spec.Type,
+			Name:    spec.Name,
+			ID:      spec.ID,
+			Version: spec.Version,
+		}
+	}
+
+	input, err := json.Marshal(req)
+	if err != nil {
+		return err
+	}
+
+	logger.Debug("Plugin %s request: %s", p.name, input)
+
+	// stderr is passed through so plugins can show progress
+	var stderr io.Writer = os.Stderr
+	if stream := outputFrom(ctx); stream != nil {
+		stderr = stream
+	}
+
+	var stdout bytes.Buffer
+	runErr := p.Runner().Run(ctx, Command{
+		Name:   p.path,
+		Stdin:  bytes.NewReader(input),
+		Stdout: &stdout,
+		Stderr: stderr,
+	})
+
+	logger.Debug("Plugin %s response: %s", p.name, stdout.String())
+
+	var resp PluginResponse
+	if err := json.Unmarshal(stdout.Bytes(), &resp); err != nil {
+		if runErr != nil {
+			return fmt.Errorf("plugin %s %s failed: %w", p.name, method, runErr)
+		}
+		return fmt.Errorf("plugin %s returned invalid response to %s: %w", p.name, method, err)
+	}
+
+	if resp.Error != "" {
+		return fmt.Errorf("plugin %s %s failed: %s", p.name, method, resp.Error)
+	}
+	if runErr != nil {
+		return fmt.Errorf("plugin %s %s failed: %w", p.name, method, runErr)
+	}
+
+	if out != nil && len(resp.Result) > 0 {
+		if err := json.Unmarshal(resp.Result, out); err != nil {
+			return fmt.Errorf("plugin %s returned invalid result for %s: %w", p.name, method, err)
+		}
+	}
+
+	return nil
+}
+
+// GetPlugins returns the discovered provider plugins, sorted by name.
+// Discovery runs once per process.
+func GetPlugins() []*PluginProvider {
+	pluginsOnce.Do(func() {
+		plugins = discoverPlugins(pluginDirs())
+	})
+
+	result := make([]*PluginProvider, 0, len(plugins))
+	for _, name := range sortedKeys(plugins) {
+		result = append(result, plugins[name])
+	}
+	return result
+}
+
+// pluginDirs returns the plugin search path: ~/.unipm/plugins, then PATH
+func pluginDirs() []string {
+	var dirs []string
+
+	if homeDir, err := os.UserHomeDir(); err == nil {
+		dirs = append(dirs, filepath.Join(homeDir, PluginDir))
+	}
+
+	return append(dirs, filepath.SplitList(os.Getenv("PATH"))...)
+}
+
+// discoverPlugins finds unipm-provider-<name> executables in dirs.
+// Earlier directories take precedence.
+func discoverPlugins(dirs []string) map[string]*PluginProvider {
+	found := make(map[string]*PluginProvider)
+
+	for _, dir := range dirs {
+		entries, err := os.ReadDir(dir)
+		if err != nil {
+			continue
+		}
+
+		for _, entry := range entries {
+			name, ok := pluginName(entry.Name())
+			if !ok || entry.IsDir() {
+				continue
+			}
+
+			if _, exists := found[name]; exists {
+				continue
+			}
+
+			path := filepath.Join(dir, entry.Name())
+			if !isExecutable(path) {
+				continue
+			}
+
+			logger.Debug("Found provider plugin %s at %s", name, path)
+			found[name] = NewPluginProvider(name, path)
+		}
+	}
+
+	return found
+}
+
+// pluginName extracts the provider name from a plugin file name
+func pluginName(fileName string) (string, bool) {
+	if !strings.HasPrefix(fileName, PluginPrefix) {
+		return "", false
+	}
+
+	name := strings.TrimPrefix(fileName, PluginPrefix)
+	if runtime.GOOS == "windows" {
+		name = strings.TrimSuffix(name, filepath.Ext(name))
+	}
+
+	return name, name != ""
+}
+
+// isExecutable reports whether path is an executable file
+func isExecutable(path string) bool {
+	info, err := os.Stat(path)
+	if err != nil || info.IsDir() {
+		return false
+	}
+
+	if runtime.GOOS == "windows" {
+		ext := strings.ToLower(filepath.Ext(path))
+		return ext == ".exe" || ext == ".bat" || ext == ".cmd"
+	}
+
+	return info.Mode()&0111 != 0
+}
@@ -0,0 +1,182 @@
+package provider
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const testPluginPath = "/plugins/unipm-provider-flatpak"
+
+// testPlugin returns a plugin provider whose calls are answered by runner
+func testPlugin(runner *MockRunner) *PluginProvider {
+	p := NewPluginProvider("flatpak", testPluginPath)
+	p.SetRunner(runner)
+	return p
+}
+
+// pluginRequest decodes the request a plugin received on stdin
+func pluginRequest(t *testing.T, cmd Command) PluginRequest {
+	t.Helper()
+
+	data, err := io.ReadAll(cmd.Stdin)
+	require.NoError(t, err)
+
+	var req PluginRequest
+	require.NoError(t, json.Unmarshal(data, &req))
+	return req
+}
+
+func TestPluginProvider_Call(t *testing.T) {
+	runner := NewMockRunner().
+		On(testPluginPath, MockResponse{Stdout: `{"result": true}`})
+	p := testPlugin(runner)
+
+	spec := ProviderSpec{Type: "flatpak", Name: "org.gimp.GIMP", Version: "2.10"}
+	assert.True(t, p.IsInstalled(context.Background(), spec))
+
+	require.Len(t, runner.Calls, 1)
+	assert.Equal(t, PluginRequest{
+		Protocol: PluginProtocolVersion,
+		Method:   "is_installed",
+		Spec:     &PluginSpec{Type: "flatpak", Name: "org.gimp.GIMP", Version: "2.10"},
+	}, pluginRequest(t, runner.Calls[0]))
+}
+
+func TestPluginProvider_CallErrors(t *testing.T) {
+	tests := []struct {
+		name     string
+		response MockResponse
+		expected string
+	}{
+		{
+			name:     "error response",
+			response: MockResponse{Stdout: `{"error": "remote not configured"}`, ExitCode: 1},
+			expected: "plugin flatpak install failed: remote not configured",
+		},
+		{
+			name:     "error response with exit 0",
+			response: MockResponse{Stdout: `{"error": "remote not configured"}`},
+			expected: "plugin flatpak install failed: remote not configured",
+		},
+		{
+			name:     "no response and non-zero exit",
+			response: MockResponse{Stderr: "segfault", ExitCode: 2},
+			expected: "plugin flatpak install failed: exit status 2",
+		},
+		{
+			name:     "invalid response",
+			response: MockResponse{Stdout: "installing..."},
+			expected: "plugin flatpak returned invalid response to install",
+		},
+		{
+			name:     "valid response and non-zero exit",
+			response: MockResponse{Stdout: `{"result": null}`, ExitCode: 1},
+			expected: "plugin flatpak install failed: exit status 1",
+		},
+		{
+			name:     "runner error",
+			response: MockResponse{Err: errors.New("permission denied")},
+			expected: "plugin flatpak install failed: permission denied",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := testPlugin(NewMockRunner().On(testPluginPath, tt.response))
+			ctx := WithOutput(context.Background(), io.Discard, io.Discard)
+
+			err := p.Install(ctx, ProviderSpec{Type: "flatpak", Name: "org.gimp.GIMP"})
+			assert.ErrorContains(t, err, tt.expected)
+		})
+	}
+
+	// A result of the wrong type makes the call fail
+	p := testPlugin(NewMockRunner().On(testPluginPath, MockResponse{Stdout: `{"result": "yes"}`}))
+	assert.False(t, p.IsAvailable())
+}
+
+func TestPluginProvider_ListInstalled(t *testing.T) {
+	runner := NewMockRunner().On(testPluginPath, MockResponse{Stdout: `{"result": [
+		"org.gimp.GIMP",
+		{"name": "org.videolan.VLC", "version": "3.0.20", "source": "flathub"},
+		{"name": "org.gnome.Platform", "explicit": false}
+	]}`})
+
+	packages, err := testPlugin(runner).ListInstalled(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, []InstalledPackage{
+		{Name: "org.gimp.GIMP", Explicit: true},
+		{Name: "org.videolan.VLC", Version: "3.0.20", Source: "flathub", Explicit: true},
+		{Name: "org.gnome.Platform"},
+	}, packages)
+	req := pluginRequest(t, runner.Calls[0])
+	assert.Equal(t, "list_installed", req.Method)
+	assert.Nil(t, req.Spec)
+
+	for _, result := range []string{`[42]`, `[{"version": "1.0"}]`, `{"name": "org.gimp.GIMP"}`} {
+		runner := NewMockRunner().On(testPluginPath, MockResponse{Stdout: `{"result": ` + result + `}`})
+		_, err := testPlugin(runner).ListInstalled(context.Background())
+		assert.Error(t, err, result)
+	}
+}
+
+func TestPluginName(t *testing.T) {
+	tests := []struct {
+		fileName string
+		name     string
+		ok       bool
+	}{
+		{"unipm-provider-flatpak", "flatpak", true},
+		{"unipm-provider-", "", false},
+		{"unipm-flatpak", "", false},
+		{"flatpak", "", false},
+	}
+	if runtime.GOOS == "windows" {
+		tests = append(tests, struct {
+			fileName string
+			name     string
+			ok       bool
+		}{"unipm-provider-flatpak.exe", "flatpak", true})
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.fileName, func(t *testing.T) {
+			name, ok := pluginName(tt.fileName)
+			assert.Equal(t, tt.ok, ok)
+			assert.Equal(t, tt.name, name)
+		})
+	}
+}
+
+func TestDiscoverPlugins(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("plugins are found by file extension on Windows")
+	}
+
+	first, second := t.TempDir(), t.TempDir()
+	write := func(dir, name string, mode os.FileMode) {
+		require.NoError(t, os.WriteFile(filepath.Join(dir, name), []byte("#!/bin/sh\n"), mode))
+	}
+	write(first, "unipm-provider-flatpak", 0755)
+	write(first, "unipm-provider-nix", 0644) // Not executable
+	write(first, "other-tool", 0755)
+	write(second, "unipm-provider-flatpak", 0755) // Shadowed by the first directory
+	write(second, "unipm-provider-nix", 0755)
+	require.NoError(t, os.Mkdir(filepath.Join(second, "unipm-provider-dir"), 0755))
+
+	found := discoverPlugins([]string{first, filepath.Join(first, "missing"), second})
+
+	require.Len(t, found, 2)
+	assert.Equal(t, filepath.Join(first, "unipm-provider-flatpak"), found["flatpak"].Path())
+	assert.Equal(t, filepath.Join(second, "unipm-provider-nix"), found["nix"].Path())
+	assert.Equal(t, "nix", found["nix"].Name())
+}
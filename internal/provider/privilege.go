@@ -0,0 +1,167 @@
+package provider
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+
+	"github.com/Litchi-group/unipm/internal/errors"
+	"github.com/Litchi-group/unipm/internal/logger"
+)
+
+// Privilege escalation methods, selected with privilege.method in ~/.unipm/config.yaml
+const (
+	PrivilegeAuto   = "auto"   // none when root, otherwise the first of sudo, doas, pkexec found
+	PrivilegeNone   = "none"   // run commands as the current user
+	PrivilegeSudo   = "sudo"   // sudo, or sudo -n when not interactive
+	PrivilegeDoas   = "doas"   // doas, or doas -n when not interactive
+	PrivilegePkexec = "pkexec" // polkit, for desktop sessions
+)
+
+var (
+	privilegeMu     sync.Mutex
+	privilegeMethod = PrivilegeAuto
+	interactive     = isTerminal(os.Stdin)
+	privilegeOnce   sync.Once
+	privilegeErr    error
+)
+
+// SetPrivilegeMethod sets how commands that need root are escalated
+func SetPrivilegeMethod(method string) error {
+	if method == "" {
+		method = PrivilegeAuto
+	}
+
+	switch method {
+	case PrivilegeAuto, PrivilegeNone, PrivilegeSudo, PrivilegeDoas, PrivilegePkexec:
+	default:
+		return fmt.Errorf("unknown privilege method %q (expected auto, none, sudo, doas or pkexec)", method)
+	}
+
+	privilegeMu.Lock()
+	defer privilegeMu.Unlock()
+	privilegeMethod = method
+	return nil
+}
+
+// SetInteractive sets whether escalation may prompt for a password.
+// It defaults to whether stdin is a terminal.
+func SetInteractive(value bool) {
+	privilegeMu.Lock()
+	defer privilegeMu.Unlock()
+	interactive = value
+}
+
+// PrivilegeMethod returns the escalation method in effect, resolving auto
+func PrivilegeMethod() string {
+	privilegeMu.Lock()
+	method := privilegeMethod
+	privilegeMu.Unlock()
+
+	if method != PrivilegeAuto {
+		return method
+	}
+
+	if os.Geteuid() == 0 {
+		return PrivilegeNone
+	}
+	for _, candidate := range []string{PrivilegeSudo, PrivilegeDoas, PrivilegePkexec} {
+		if _, err := defaultRunner.LookPath(candidate); err == nil {
+			return candidate
+		}
+	}
+
+	// Fall back to sudo so the failure names a familiar tool
+	return PrivilegeSudo
+}
+
+// privilegePrefix returns the command prefix for running as root
+func privilegePrefix() []string {
+	privilegeMu.Lock()
+	nonInteractive := !interactive
+	privilegeMu.Unlock()
+
+	switch method := PrivilegeMethod(); method {
+	case PrivilegeNone:
+		return nil
+	case PrivilegeSudo, PrivilegeDoas:
+		if nonInteractive {
+			return []string{method, "-n"}
+		}
+		return []string{method}
+	default:
+		return []string{method}
+	}
+}
+
+// CheckPrivilege verifies once per process that commands can be escalated,
+// so a missing password fails before anything is installed
+func CheckPrivilege() error {
+	privilegeOnce.Do(func() {
+		privilegeErr = checkPrivilege(defaultRunner)
+	})
+	return privilegeErr
+}
+
+// checkPrivilege runs a no-op command through the escalation prefix
+func checkPrivilege(runner CommandRunner) error {
+	prefix := privilegePrefix()
+	if len(prefix) == 0 {
+		return nil
+	}
+
+	if _, err := runner.LookPath(prefix[0]); err != nil {
+		return errors.NewProviderUnavailableError(prefix[0],
+			"root privileges are required; install it or set privilege.method in ~/.unipm/config.yaml")
+	}
+
+	args := append(prefix[1:], "true")
+	if prefix[0] == PrivilegeSudo {
+		// Validate and cache credentials, prompting once if interactive
+		args = append(prefix[1:], "-v")
+	}
+
+	logger.Debug("Checking privilege escalation: %s", FormatCommand(prefix[0], args...))
+
+	output := newTailBuffer(outputTailBytes)
+	cmd := Command{
+		Name:   prefix[0],
+		Args:   args,
+		Env:    stableLocale,
+		Stdin:  os.Stdin,
+		Stdout: output,
+		Stderr: output,
+	}
+	if err := runner.Run(context.Background(), cmd); err != nil {
+		return fmt.Errorf("cannot run commands as root with %s: %w", prefix[0],
+			errors.NewCommandError(FormatCommand(prefix[0], args...), exitCode(err), output.Tail(outputTailLines), err))
+	}
+
+	return nil
+}
+
+// privileged returns a command line run as root
+func privileged(name string, args ...string) []string {
+	prefix := privilegePrefix()
+	if len(prefix) > 0 && (prefix[0] == PrivilegeDoas || prefix[0] == PrivilegePkexec) {
+		// doas and pkexec reset the environment, dropping stableLocale
+		prefix = append(append(prefix, "env"), stableLocale...)
+	}
+	return append(prefix, append([]string{name}, args...)...)
+}
+
+// FormatPrivileged formats a command that runs as root for display
+func FormatPrivileged(name string, args ...string) string {
+	return strings.Join(privileged(name, args...), " ")
+}
+
+// isTerminal reports whether f is an interactive terminal
+func isTerminal(f *os.File) bool {
+	info, err := f.Stat()
+	if err != nil {
+		return false
+	}
+	return info.Mode()&os.ModeCharDevice != 0
+}
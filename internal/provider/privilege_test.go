@@ -0,0 +1,61 @@
+package provider
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// withPrivilege sets the escalation method for the duration of a test
+func withPrivilege(t *testing.T, method string, isInteractive bool) {
+	t.Helper()
+
+	prevMethod, prevInteractive := privilegeMethod, interactive
+	t.Cleanup(func() {
+		privilegeMethod, interactive = prevMethod, prevInteractive
+	})
+
+	require.NoError(t, SetPrivilegeMethod(method))
+	SetInteractive(isInteractive)
+}
+
+func TestPrivilegePrefix(t *testing.T) {
+	tests := []struct {
+		method      string
+		interactive bool
+		want        string
+	}{
+		{PrivilegeNone, true, "apt install -y git"},
+		{PrivilegeSudo, true, "sudo apt install -y git"},
+		{PrivilegeSudo, false, "sudo -n apt install -y git"},
+		{PrivilegeDoas, false, "doas -n env LC_ALL=C LANG=C apt install -y git"},
+		{PrivilegePkexec, true, "pkexec env LC_ALL=C LANG=C apt install -y git"},
+	}
+
+	for _, tt := range tests {
+		withPrivilege(t, tt.method, tt.interactive)
+		assert.Equal(t, tt.want, NewAptProvider().InstallCommand(ProviderSpec{Type: "apt", Name: "git"}))
+	}
+}
+
+func TestSetPrivilegeMethod_Invalid(t *testing.T) {
+	assert.Error(t, SetPrivilegeMethod("su"))
+}
+
+func TestCheckPrivilege(t *testing.T) {
+	withPrivilege(t, PrivilegeSudo, false)
+
+	runner := NewMockRunner().
+		On("sudo -n -v", MockResponse{Stderr: "sudo: a password is required", ExitCode: 1})
+	err := checkPrivilege(runner)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "cannot run commands as root with sudo")
+
+	runner = NewMockRunner()
+	runner.SetAvailable("sudo", false)
+	assert.Error(t, checkPrivilege(runner))
+
+	withPrivilege(t, PrivilegeNone, false)
+	assert.NoError(t, checkPrivilege(NewMockRunner()))
+}
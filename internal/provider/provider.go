@@ -1,6 +1,10 @@
 package provider
 
-import "fmt"
+import (
+	"context"
+	"fmt"
+	"time"
+)
 
 // Provider defines the interface for package managers
 type Provider interface {
@@ -11,13 +15,13 @@ type Provider interface {
 	IsAvailable() bool
 
 	// Install installs a package
-	Install(spec ProviderSpec) error
+	Install(ctx context.Context, spec ProviderSpec) error
 
 	// Remove removes a package
-	Remove(spec ProviderSpec) error
+	Remove(ctx context.Context, spec ProviderSpec) error
 
 	// IsInstalled checks if a package is already installed
-	IsInstalled(spec ProviderSpec) bool
+	IsInstalled(ctx context.Context, spec ProviderSpec) bool
 
 	// InstallCommand returns the command that would be executed
 	InstallCommand(spec ProviderSpec) string
@@ -25,45 +29,89 @@ type Provider interface {
 	// RemoveCommand returns the uninstall command
 	RemoveCommand(spec ProviderSpec) string
 
-	// ListInstalled returns a list of installed package names
-	ListInstalled() ([]string, error)
+	// ListInstalled returns the packages in the package manager's inventory
+	ListInstalled(ctx context.Context) ([]InstalledPackage, error)
 }
 
-// ProviderSpec contains provider-specific package information
-type ProviderSpec struct {
-	Type    string // "brew", "brew_cask", "winget", "apt", "snap"
-	Name    string // Package name
-	ID      string // Package ID (for winget)
-	Classic bool   // Classic mode (for snap)
+// DriftDetector is implemented by providers whose packages can be installed
+// yet differ from their spec, such as a snap tracking another channel.
+// IsInstalled still reports such packages as installed.
+type DriftDetector interface {
+	// Drift describes how an installed package differs from its spec, or
+	// returns "" if it matches or is not installed
+	Drift(ctx context.Context, spec ProviderSpec) string
 }
 
-// GetInstallationGuide returns installation instructions for missing providers
-func GetInstallationGuide(providerName string) string {
-	guides := map[string]string{
-		"brew": `Homebrew is not installed.
-Install it from: https://brew.sh
-
-  /bin/bash -c "$(curl -fsSL https://raw.githubusercontent.com/Homebrew/install/HEAD/install.sh)"`,
-
-		"winget": `WinGet is not installed.
-Install it from: https://aka.ms/getwinget
+// InstalledPackage is a package reported by a package manager's inventory
+type InstalledPackage struct {
+	Name     string // Native name, as used in registry mappings
+	Version  string // Installed version, "" if unknown
+	Source   string // Origin, e.g., the brew tap, snap channel or winget source ("" if unknown)
+	Explicit bool   // Installed on request rather than as a dependency
+}
 
-WinGet comes pre-installed on Windows 11 and recent Windows 10 builds.
-If missing, install "App Installer" from the Microsoft Store.`,
+// ProviderSpec contains provider-specific package information
+type ProviderSpec struct {
+	Type      string            `json:"type"`                 // "brew", "brew_cask", "winget", "apt", "snap", "npm", "pipx", "cargo", "go", "binary", "script", "mise", "asdf"
+	Name      string            `json:"name"`                 // Package name
+	ID        string            `json:"id,omitempty"`         // Package ID (for winget)
+	Classic   bool              `json:"classic,omitempty"`    // Classic mode (for snap)
+	Channel   string            `json:"channel,omitempty"`    // Channel to track, e.g., "latest/edge" (for snap)
+	Revision  int               `json:"revision,omitempty"`   // Revision to pin, 0 for the channel's latest (for snap)
+	Devmode   bool              `json:"devmode,omitempty"`    // Developer mode confinement (for snap)
+	Refresh   bool              `json:"refresh,omitempty"`    // Already installed: refresh instead of install (for snap)
+	Tap       string            `json:"tap,omitempty"`        // Tap the formula comes from, e.g., "hashicorp/tap" (for brew)
+	TapURL    string            `json:"tap_url,omitempty"`    // Custom tap repository URL (for brew)
+	Version   string            `json:"version,omitempty"`    // Package version or constraint (for binary, mise, asdf)
+	URL       string            `json:"url,omitempty"`        // Download URL template (for binary)
+	Checksums map[string]string `json:"checksums,omitempty"`  // SHA256 checksums keyed by "<os>-<arch>" (for binary)
+	Binaries  []string          `json:"binaries,omitempty"`   // Binaries to extract from the archive (for binary)
+	Script    *ScriptSpec       `json:"script,omitempty"`     // Shell snippets (for script)
+	Repo      *AptRepository    `json:"repository,omitempty"` // Third-party repository to add first (for apt)
+	Verified  bool              `json:"-"`                    // Package definition passed checksum verification
+	Pinned    bool              `json:"-"`                    // Package definition matched the checksum pinned in devpack.yaml
+}
 
-		"apt": `APT is not installed.
-APT comes pre-installed on Debian-based systems (Ubuntu, Debian).
-If you're not on a Debian-based system, unipm may not support your distribution yet.`,
+// NativeName returns the name of a spec's package in its package manager's
+// inventory, as reported by ListInstalled
+func NativeName(spec ProviderSpec) string {
+	switch spec.Type {
+	case "brew", "brew_cask":
+		return brewName(spec)
+	case "winget":
+		if spec.ID != "" {
+			return spec.ID
+		}
+	}
+	return spec.Name
+}
 
-		"snap": `Snap is not installed.
-Install it with:
+// ScriptSpec contains the shell snippets of a script package
+type ScriptSpec struct {
+	Install string        `json:"install"`           // Installs the package
+	Remove  string        `json:"remove"`            // Removes the package
+	Check   string        `json:"check"`             // Exits 0 if the package is installed
+	Version string        `json:"version,omitempty"` // Prints the installed version (optional)
+	Timeout time.Duration `json:"timeout,omitempty"` // Timeout for install and remove (0 for default, nanoseconds in JSON)
+}
 
-  sudo apt update
-  sudo apt install snapd`,
-	}
+// AptRepository describes a third-party apt repository and its signing key.
+// Source, Suite and KeyURL may use {{distro}}, {{codename}} and {{arch}}.
+type AptRepository struct {
+	Name       string   `json:"name"`              // File name under sources.list.d and keyrings
+	Source     string   `json:"source"`            // Repository URI (e.g., "https://download.docker.com/linux/{{distro}}")
+	Suite      string   `json:"suite"`             // Suite (e.g., "stable" or "{{codename}}")
+	Components []string `json:"components"`        // Components (e.g., "main")
+	KeyURL     string   `json:"key_url,omitempty"` // URL of the signing key (armored or binary)
+	Key        string   `json:"key,omitempty"`     // Inline ASCII-armored signing key, instead of KeyURL
+}
 
-	if guide, ok := guides[providerName]; ok {
-		return guide
+// GetInstallationGuide returns installation instructions for missing providers
+func GetInstallationGuide(providerName string) string {
+	for _, reg := range Registrations() {
+		if reg.Name == providerName && reg.Guide != "" {
+			return reg.Guide
+		}
 	}
 
 	return fmt.Sprintf("No installation guide available for %s", providerName)
@@ -0,0 +1,290 @@
+package provider
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/Litchi-group/unipm/internal/detector"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fixture reads a recorded command output from testdata
+func fixture(t *testing.T, name string) string {
+	t.Helper()
+
+	data, err := os.ReadFile(filepath.Join("testdata", name))
+	require.NoError(t, err)
+
+	return string(data)
+}
+
+func TestAptProvider_Commands(t *testing.T) {
+	withPrivilege(t, PrivilegeSudo, true)
+
+	runner := NewMockRunner().
+		On("sudo apt install -y git", MockResponse{}).
+		On("sudo apt remove -y git", MockResponse{}).
+		On("apt list --installed git", MockResponse{Stdout: fixture(t, "apt_list_installed.txt")})
+
+	p := NewAptProvider()
+	p.SetRunner(runner)
+	spec := ProviderSpec{Type: "apt", Name: "git"}
+
+	require.NoError(t, p.Install(context.Background(), spec))
+	require.NoError(t, p.Remove(context.Background(), spec))
+	assert.True(t, p.IsInstalled(context.Background(), spec))
+
+	assert.Equal(t, []string{
+		"sudo apt install -y git",
+		"sudo apt remove -y git",
+		"apt list --installed git",
+	}, runner.CommandLines())
+}
+
+func TestAptProvider_IsInstalled_PrefixDoesNotMatch(t *testing.T) {
+	runner := NewMockRunner().
+		On("apt list --installed gi", MockResponse{Stdout: fixture(t, "apt_list_installed.txt")})
+
+	p := NewAptProvider()
+	p.SetRunner(runner)
+
+	assert.False(t, p.IsInstalled(context.Background(), ProviderSpec{Type: "apt", Name: "gi"}))
+}
+
+func TestSnapProvider_Commands(t *testing.T) {
+	withPrivilege(t, PrivilegeSudo, true)
+
+	runner := NewMockRunner().
+		On("snap list kubectl", MockResponse{Stderr: "error: no matching snaps installed", ExitCode: 1}).
+		On("sudo snap install kubectl --classic", MockResponse{}).
+		On("sudo snap remove kubectl", MockResponse{})
+
+	p := NewSnapProvider()
+	p.SetRunner(runner)
+	spec := ProviderSpec{Type: "snap", Name: "kubectl", Classic: true}
+
+	assert.False(t, p.IsInstalled(context.Background(), spec))
+	require.NoError(t, p.Install(context.Background(), spec))
+	require.NoError(t, p.Remove(context.Background(), spec))
+
+	assert.Equal(t, []string{
+		"snap list kubectl",
+		"sudo snap install kubectl --classic",
+		"sudo snap remove kubectl",
+	}, runner.CommandLines())
+}
+
+func TestSnapProvider_Channel(t *testing.T) {
+	withPrivilege(t, PrivilegeSudo, true)
+
+	runner := NewMockRunner().
+		On("snap list kubectl", MockResponse{Stdout: fixture(t, "snap_list.txt")}).
+		On("snap list firefox", MockResponse{Stdout: fixture(t, "snap_list.txt")}).
+		On("sudo snap refresh kubectl --channel=1.28/stable --classic", MockResponse{})
+
+	p := NewSnapProvider()
+	p.SetRunner(runner)
+	ctx := context.Background()
+
+	// kubectl tracks 1.29/stable: installed whatever the spec, drifted unless it matches
+	assert.True(t, p.IsInstalled(ctx, ProviderSpec{Name: "kubectl", Revision: 3000}))
+	assert.Empty(t, p.Drift(ctx, ProviderSpec{Name: "kubectl", Channel: "1.29"}))
+	assert.Empty(t, p.Drift(ctx, ProviderSpec{Name: "kubectl", Revision: 3179}))
+	assert.Equal(t, "is at revision 3179 instead of 3000", p.Drift(ctx, ProviderSpec{Name: "kubectl", Revision: 3000}))
+	assert.Equal(t, "is not in devmode", p.Drift(ctx, ProviderSpec{Name: "kubectl", Devmode: true}))
+
+	// firefox tracks a truncated latest/stable/<branch>
+	assert.Empty(t, p.Drift(ctx, ProviderSpec{Name: "firefox", Channel: "stable/fix-123"}))
+	assert.NotEmpty(t, p.Drift(ctx, ProviderSpec{Name: "firefox", Channel: "latest/edge"}))
+
+	// A snap the planner found installed is refreshed, and InstallCommand
+	// shows the command Install runs without looking at the host
+	spec := ProviderSpec{Name: "kubectl", Channel: "1.28/stable", Classic: true, Refresh: true}
+	calls := len(runner.Calls)
+	assert.Equal(t, "sudo snap refresh kubectl --channel=1.28/stable --classic", p.InstallCommand(spec))
+	assert.Len(t, runner.Calls, calls)
+	require.NoError(t, p.Install(ctx, spec))
+	assert.Equal(t, []string{"sudo snap refresh kubectl --channel=1.28/stable --classic"}, runner.CommandLines()[calls:])
+
+	pinned := ProviderSpec{Name: "hello", Revision: 42}
+	assert.Equal(t, "sudo snap install hello --revision=42 && sudo snap refresh --hold hello", p.InstallCommand(pinned))
+}
+
+func TestBrewProvider_Commands(t *testing.T) {
+	runner := NewMockRunner().
+		On("brew install --cask visual-studio-code", MockResponse{}).
+		On("brew uninstall --cask visual-studio-code", MockResponse{}).
+		On("brew list --cask visual-studio-code", MockResponse{Stdout: "/Applications/Visual Studio Code.app"})
+
+	p := NewBrewProvider()
+	p.SetRunner(runner)
+	spec := ProviderSpec{Type: "brew_cask", Name: "visual-studio-code"}
+
+	require.NoError(t, p.Install(context.Background(), spec))
+	require.NoError(t, p.Remove(context.Background(), spec))
+	assert.True(t, p.IsInstalled(context.Background(), spec))
+
+	assert.Equal(t, []string{
+		"brew install --cask visual-studio-code",
+		"brew uninstall --cask visual-studio-code",
+		"brew list --cask visual-studio-code",
+	}, runner.CommandLines())
+}
+
+func TestBrewProvider_Tap(t *testing.T) {
+	runner := NewMockRunner().
+		On("brew tap", MockResponse{Stdout: "homebrew/bundle\n"}).
+		On("brew tap hashicorp/tap https://github.com/hashicorp/homebrew-tap", MockResponse{}).
+		On("brew install hashicorp/tap/terraform", MockResponse{})
+
+	p := NewBrewProvider()
+	p.SetRunner(runner)
+	spec := ProviderSpec{Type: "brew", Name: "terraform", Tap: "hashicorp/tap", TapURL: "https://github.com/hashicorp/homebrew-tap"}
+
+	steps := p.PrepareSteps(context.Background(), []ProviderSpec{spec})
+	require.Len(t, steps, 1)
+	assert.Equal(t, "brew tap hashicorp/tap https://github.com/hashicorp/homebrew-tap", steps[0].Command)
+
+	require.NoError(t, p.Install(context.Background(), spec))
+	assert.Equal(t, []string{
+		"brew tap",
+		"brew tap",
+		"brew tap hashicorp/tap https://github.com/hashicorp/homebrew-tap",
+		"brew install hashicorp/tap/terraform",
+	}, runner.CommandLines())
+
+	// Qualified names imply their tap
+	assert.Equal(t, "hashicorp/tap", BrewTap(ProviderSpec{Name: "hashicorp/tap/packer"}))
+	assert.Equal(t, "", BrewTap(ProviderSpec{Name: "jq"}))
+}
+
+func TestBrewProvider_IsInstalled_NotInstalled(t *testing.T) {
+	runner := NewMockRunner().
+		On("brew list jq", MockResponse{Stderr: "Error: No such keg: /opt/homebrew/Cellar/jq", ExitCode: 1})
+
+	p := NewBrewProvider()
+	p.SetRunner(runner)
+
+	assert.False(t, p.IsInstalled(context.Background(), ProviderSpec{Type: "brew", Name: "jq"}))
+}
+
+func TestWinGetProvider_Commands(t *testing.T) {
+	runner := NewMockRunner().
+		On("winget install --id Git.Git --silent --accept-package-agreements --accept-source-agreements", MockResponse{}).
+		On("winget uninstall --id Git.Git --silent", MockResponse{}).
+		On("winget list --id Git.Git", MockResponse{Stdout: fixture(t, "winget_list.txt")})
+
+	p := NewWinGetProvider()
+	p.SetRunner(runner)
+	spec := ProviderSpec{Type: "winget", Name: "git", ID: "Git.Git"}
+
+	require.NoError(t, p.Install(context.Background(), spec))
+	require.NoError(t, p.Remove(context.Background(), spec))
+	assert.True(t, p.IsInstalled(context.Background(), spec))
+
+	assert.Equal(t, []string{
+		"winget install --id Git.Git --silent --accept-package-agreements --accept-source-agreements",
+		"winget uninstall --id Git.Git --silent",
+		"winget list --id Git.Git",
+	}, runner.CommandLines())
+}
+
+func TestBaseProvider_IsAvailable(t *testing.T) {
+	runner := NewMockRunner()
+	runner.SetAvailable("snap", false)
+
+	apt := NewAptProvider()
+	apt.SetRunner(runner)
+	snap := NewSnapProvider()
+	snap.SetRunner(runner)
+
+	assert.True(t, apt.IsAvailable())
+	assert.False(t, snap.IsAvailable())
+}
+
+func TestAptProvider_PrepareSteps(t *testing.T) {
+	withPrivilege(t, PrivilegeSudo, true)
+
+	p := NewAptProvider()
+	p.listsDir = t.TempDir()
+	p.etcDir = t.TempDir()
+	specs := []ProviderSpec{{Type: "apt", Name: "git"}}
+
+	// No package lists downloaded yet
+	steps := p.PrepareSteps(context.Background(), specs)
+	require.Len(t, steps, 1)
+	assert.Equal(t, "sudo apt-get update", steps[0].Command)
+	assert.Equal(t, "package lists are missing", steps[0].Reason)
+
+	lists := filepath.Join(p.listsDir, "deb.debian.org_debian_dists_bookworm_main_binary-amd64_Packages")
+	require.NoError(t, os.WriteFile(lists, nil, 0644))
+	assert.Empty(t, p.PrepareSteps(context.Background(), specs))
+
+	old := time.Now().Add(-72 * time.Hour)
+	require.NoError(t, os.Chtimes(lists, old, old))
+	steps = p.PrepareSteps(context.Background(), specs)
+	require.Len(t, steps, 1)
+	assert.Equal(t, "package lists are 3 days old", steps[0].Reason)
+
+	require.NoError(t, SetRefreshPolicy(RefreshNever))
+	defer func() { _ = SetRefreshPolicy(RefreshAuto) }()
+	assert.Empty(t, p.PrepareSteps(context.Background(), specs))
+}
+
+func TestAptProvider_Repository(t *testing.T) {
+	withPrivilege(t, PrivilegeNone, true)
+	require.NoError(t, SetRefreshPolicy(RefreshAuto))
+
+	p := NewAptProvider()
+	p.osInfo = &detector.OSInfo{Platform: "linux", Distro: "ubuntu", Codename: "jammy", Arch: "amd64"}
+	p.listsDir = t.TempDir()
+	p.etcDir = t.TempDir()
+
+	spec := ProviderSpec{Type: "apt", Name: "docker-ce", Repo: &AptRepository{
+		Name:       "docker",
+		Source:     "https://download.docker.com/linux/{{distro}}",
+		Suite:      "{{codename}}",
+		Components: []string{"stable"},
+		Key:        "-----BEGIN PGP PUBLIC KEY BLOCK-----\n...\n-----END PGP PUBLIC KEY BLOCK-----\n",
+	}}
+
+	steps := p.PrepareSteps(context.Background(), []ProviderSpec{spec})
+	require.Len(t, steps, 2)
+	assert.Equal(t, "apt-repo-docker", steps[0].Key)
+	assert.Equal(t, "apt-update", steps[1].Key)
+	assert.Equal(t, "new repositories", steps[1].Reason)
+
+	// Write the files directly instead of through install(1)
+	keyPath := filepath.Join(p.etcDir, "keyrings", "docker.asc")
+	sourcesPath := filepath.Join(p.etcDir, "sources.list.d", "docker.list")
+	require.NoError(t, os.MkdirAll(filepath.Dir(keyPath), 0755))
+	require.NoError(t, os.MkdirAll(filepath.Dir(sourcesPath), 0755))
+	require.NoError(t, os.WriteFile(keyPath, []byte(spec.Repo.Key), 0644))
+	require.NoError(t, os.WriteFile(sourcesPath, []byte(p.sourcesLine(spec.Repo, keyPath)), 0644))
+
+	assert.Equal(t, "deb [arch=amd64 signed-by="+keyPath+"] https://download.docker.com/linux/ubuntu jammy stable\n",
+		p.sourcesLine(spec.Repo, keyPath))
+
+	// Configured and lists newer than the sources: nothing to do
+	lists := filepath.Join(p.listsDir, "download.docker.com_linux_ubuntu_dists_jammy_stable_binary-amd64_Packages")
+	require.NoError(t, os.WriteFile(lists, nil, 0644))
+	later := time.Now().Add(time.Minute)
+	require.NoError(t, os.Chtimes(lists, later, later))
+	assert.Empty(t, p.PrepareSteps(context.Background(), []ProviderSpec{spec}))
+
+	runner := NewMockRunner()
+	runner.Default = &MockResponse{}
+	p.SetRunner(runner)
+	SetCleanupRepositories(true)
+	defer SetCleanupRepositories(false)
+
+	require.NoError(t, p.Remove(context.Background(), spec))
+	assert.Equal(t, []string{
+		"apt remove -y docker-ce",
+		"rm -f " + sourcesPath + " " + keyPath + " " + filepath.Join(p.etcDir, "keyrings", "docker.gpg"),
+	}, runner.CommandLines())
+}
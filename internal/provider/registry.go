@@ -0,0 +1,144 @@
+package provider
+
+import (
+	"fmt"
+	"sort"
+	"sync"
+
+	"github.com/Litchi-group/unipm/internal/detector"
+)
+
+// Capabilities describes optional provider features
+type Capabilities struct {
+	NeedsRoot        bool // Install and remove run with elevated privileges
+	SupportsVersions bool // ProviderSpec.Version selects the installed version
+}
+
+// Registration describes a provider type and where it can be used
+type Registration struct {
+	Name           string       // Provider name (e.g., "brew")
+	Types          []string     // Spec types handled (e.g., "brew", "brew_cask")
+	Platforms      []string     // Supported platforms ("darwin", "windows", "linux"); empty means all
+	DistroFamilies []string     // Supported Linux distro families (e.g., "debian"); empty means all
+	Default        bool         // Ships with supported systems; required by doctor
+	Builtin        bool         // Implemented by unipm itself; needs no other tool
+	Plugin         bool         // Provided by an external plugin
+	Guide          string       // Installation instructions shown when missing
+	Fallback       string       // Spec type to install with instead when this provider is missing (optional)
+	Capabilities   Capabilities // Optional features
+	New            func() Provider
+}
+
+var (
+	registryMu    sync.RWMutex
+	registrations []*Registration
+	typeIndex     = make(map[string]*Registration)
+	pluginsLoaded sync.Once
+)
+
+// Register adds a provider registration.
+// Registering a type that is already taken is an error, so built-in
+// providers always win over plugins with the same name.
+func Register(reg Registration) error {
+	registryMu.Lock()
+	defer registryMu.Unlock()
+
+	for _, t := range reg.Types {
+		if _, exists := typeIndex[t]; exists {
+			return fmt.Errorf("provider type %s is already registered", t)
+		}
+	}
+
+	r := &reg
+	registrations = append(registrations, r)
+	for _, t := range reg.Types {
+		typeIndex[t] = r
+	}
+
+	return nil
+}
+
+// mustRegister registers a built-in provider and panics on conflicts
+func mustRegister(reg Registration) {
+	if err := Register(reg); err != nil {
+		panic(err)
+	}
+}
+
+// Lookup returns the registration handling a spec type
+func Lookup(providerType string) (*Registration, bool) {
+	loadPlugins()
+
+	registryMu.RLock()
+	defer registryMu.RUnlock()
+
+	reg, ok := typeIndex[providerType]
+	return reg, ok
+}
+
+// Registrations returns all registrations: defaults first, then by name
+func Registrations() []*Registration {
+	loadPlugins()
+
+	registryMu.RLock()
+	result := make([]*Registration, len(registrations))
+	copy(result, registrations)
+	registryMu.RUnlock()
+
+	sort.SliceStable(result, func(i, j int) bool {
+		if result[i].Default != result[j].Default {
+			return result[i].Default
+		}
+		return result[i].Name < result[j].Name
+	})
+
+	return result
+}
+
+// RegistrationsForOS returns the registrations supporting the given OS
+func RegistrationsForOS(osInfo *detector.OSInfo) []*Registration {
+	var result []*Registration
+	for _, reg := range Registrations() {
+		if reg.SupportsOS(osInfo) {
+			result = append(result, reg)
+		}
+	}
+	return result
+}
+
+// SupportsOS reports whether the provider can be used on the given OS
+func (r *Registration) SupportsOS(osInfo *detector.OSInfo) bool {
+	if len(r.Platforms) > 0 && !containsString(r.Platforms, osInfo.Platform) {
+		return false
+	}
+
+	if len(r.DistroFamilies) > 0 && osInfo.IsLinux() {
+		for _, family := range r.DistroFamilies {
+			if osInfo.InFamily(family) {
+				return true
+			}
+		}
+		return false
+	}
+
+	return true
+}
+
+// loadPlugins registers discovered plugins once per process
+func loadPlugins() {
+	pluginsLoaded.Do(func() {
+		for _, plugin := range GetPlugins() {
+			plugin := plugin
+			_ = Register(Registration{
+				Name:         plugin.Name(),
+				Types:        []string{plugin.Name()},
+				Plugin:       true,
+				Capabilities: Capabilities{SupportsVersions: true}, // The spec sent to plugins has the version
+				Guide:        fmt.Sprintf("Provider plugin %s is installed at %s but reports it is not available.", plugin.Name(), plugin.Path()),
+				New: func() Provider {
+					return plugin
+				},
+			})
+		}
+	})
+}
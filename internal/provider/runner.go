@@ -0,0 +1,82 @@
+package provider
+
+import (
+	"context"
+	"io"
+	"os"
+	"os/exec"
+	"runtime"
+	"time"
+)
+
+// killGracePeriod is how long a cancelled command may take to exit before it is killed
+const killGracePeriod = 10 * time.Second
+
+// Command describes an external command to run
+type Command struct {
+	Name   string    // Executable name or path
+	Args   []string  // Arguments
+	Env    []string  // Extra environment variables ("KEY=value") added to the current environment
+	Dir    string    // Working directory (empty for current)
+	Stdin  io.Reader // Standard input (nil for none)
+	Stdout io.Writer // Standard output (nil to discard)
+	Stderr io.Writer // Standard error (nil to discard)
+}
+
+// Argv returns the command name followed by its arguments
+func (c Command) Argv() []string {
+	return append([]string{c.Name}, c.Args...)
+}
+
+// CommandRunner runs external commands on behalf of providers
+type CommandRunner interface {
+	// Run runs a command to completion
+	Run(ctx context.Context, cmd Command) error
+
+	// LookPath searches for an executable in PATH
+	LookPath(file string) (string, error)
+}
+
+// ExecRunner runs commands with os/exec
+type ExecRunner struct{}
+
+// Run runs a command with os/exec
+func (ExecRunner) Run(ctx context.Context, c Command) error {
+	cmd := exec.CommandContext(ctx, c.Name, c.Args...)
+	cmd.Dir = c.Dir
+	cmd.Stdin = c.Stdin
+	cmd.Stdout = c.Stdout
+	cmd.Stderr = c.Stderr
+
+	// On cancellation, ask the command to stop so package managers can
+	// release their locks, and kill it if it has not exited after a grace period
+	cmd.Cancel = func() error {
+		if runtime.GOOS == "windows" {
+			return cmd.Process.Kill()
+		}
+		return cmd.Process.Signal(os.Interrupt)
+	}
+	cmd.WaitDelay = killGracePeriod
+
+	if len(c.Env) > 0 {
+		cmd.Env = append(os.Environ(), c.Env...)
+	}
+
+	return cmd.Run()
+}
+
+// LookPath searches for an executable in PATH
+func (ExecRunner) LookPath(file string) (string, error) {
+	return exec.LookPath(file)
+}
+
+// defaultRunner is used by providers without their own runner
+var defaultRunner CommandRunner = ExecRunner{}
+
+// SetDefaultRunner replaces the runner used by all providers without their
+// own runner, and returns the previous one
+func SetDefaultRunner(r CommandRunner) CommandRunner {
+	previous := defaultRunner
+	defaultRunner = r
+	return previous
+}
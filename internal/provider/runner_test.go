@@ -0,0 +1,31 @@
+package provider
+
+import (
+	"context"
+	"runtime"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestExecRunner_CancelInterruptsCommand(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("commands are killed on Windows")
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 200*time.Millisecond)
+	defer cancel()
+
+	// The command exits with 3 only if it receives the interrupt
+	start := time.Now()
+	err := ExecRunner{}.Run(ctx, Command{
+		Name: "sh",
+		Args: []string{"-c", "trap 'exit 3' INT; sleep 5 & wait"},
+	})
+	require.Error(t, err)
+
+	assert.Equal(t, 3, exitCode(err))
+	assert.Less(t, time.Since(start), killGracePeriod)
+}
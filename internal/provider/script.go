@@ -0,0 +1,256 @@
+package provider
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"regexp"
+	"runtime"
+	"strings"
+	"time"
+
+	"github.com/Litchi-group/unipm/internal/errors"
+	"github.com/Litchi-group/unipm/internal/logger"
+)
+
+const (
+	// defaultScriptTimeout bounds install and remove scripts
+	defaultScriptTimeout = 10 * time.Minute
+
+	// checkScriptTimeout bounds check and version scripts
+	checkScriptTimeout = 30 * time.Second
+)
+
+// trustRegistry allows scripts from package definitions not pinned in devpack.yaml
+var trustRegistry bool
+
+// SetTrustRegistry sets whether scripts from unpinned package definitions may run
+func SetTrustRegistry(trusted bool) {
+	trustRegistry = trusted
+}
+
+func init() {
+	mustRegister(Registration{
+		Name:         "script",
+		Types:        []string{"script"},
+		Builtin:      true,
+		Capabilities: Capabilities{SupportsVersions: true}, // Scripts get $UNIPM_VERSION
+		Guide: `The script provider runs shell snippets from the package registry.
+It uses /bin/sh (PowerShell on Windows), which should always be present.`,
+		New: func() Provider { return NewScriptProvider() },
+	})
+}
+
+// ScriptProvider runs install/remove/check snippets declared in the registry
+type ScriptProvider struct {
+	BaseProvider
+}
+
+// NewScriptProvider creates a new script provider
+func NewScriptProvider() *ScriptProvider {
+	shell := "sh"
+	if runtime.GOOS == "windows" {
+		shell = "powershell"
+	}
+
+	return &ScriptProvider{
+		BaseProvider: BaseProvider{
+			name:       "script",
+			executable: shell,
+		},
+	}
+}
+
+// Install runs the install script
+func (p *ScriptProvider) Install(ctx context.Context, spec ProviderSpec) error {
+	if err := p.checkTrusted(spec); err != nil {
+		return err
+	}
+	if spec.Script.Install == "" {
+		return fmt.Errorf("no install script defined for %s", spec.Name)
+	}
+
+	announce(ctx, p.InstallCommand(spec))
+
+	_, err := p.run(ctx, spec, spec.Script.Install, p.timeout(spec))
+	return err
+}
+
+// IsInstalled runs the check script and reports whether it exited 0. With a
+// version requested and a version script, the installed version must also
+// match it.
+func (p *ScriptProvider) IsInstalled(ctx context.Context, spec ProviderSpec) bool {
+	if err := p.checkTrusted(spec); err != nil {
+		logger.Warn("Skipping install check: %v", err)
+		return false
+	}
+	if spec.Script.Check == "" {
+		return false
+	}
+
+	if _, err := p.run(ctx, spec, spec.Script.Check, checkScriptTimeout); err != nil {
+		return false
+	}
+	if spec.Version == "" || spec.Script.Version == "" {
+		return true
+	}
+
+	version, err := p.InstalledVersion(ctx, spec)
+	if err != nil {
+		logger.Warn("Failed to get the installed version of %s: %v", spec.Name, err)
+		return false
+	}
+	return matchesVersion([]string{version}, spec.Version)
+}
+
+// InstalledVersion runs the version script and returns the first version
+// number in its output, e.g., "1.4.2" for "internal-tool v1.4.2 (linux)"
+func (p *ScriptProvider) InstalledVersion(ctx context.Context, spec ProviderSpec) (string, error) {
+	if err := p.checkTrusted(spec); err != nil {
+		return "", err
+	}
+	if spec.Script.Version == "" {
+		return "", fmt.Errorf("no version script defined for %s", spec.Name)
+	}
+
+	output, err := p.run(ctx, spec, spec.Script.Version, checkScriptTimeout)
+	if err != nil {
+		return "", err
+	}
+	version := versionNumber.FindString(output)
+	if version == "" {
+		return "", fmt.Errorf("no version number in the output of the version script for %s: %q", spec.Name, output)
+	}
+	return version, nil
+}
+
+// InstallCommand returns a summary of the install script
+func (p *ScriptProvider) InstallCommand(spec ProviderSpec) string {
+	if spec.Script == nil {
+		return "script: (none)"
+	}
+	return "script: " + summarizeScript(spec.Script.Install)
+}
+
+// Remove runs the remove script
+func (p *ScriptProvider) Remove(ctx context.Context, spec ProviderSpec) error {
+	if err := p.checkTrusted(spec); err != nil {
+		return err
+	}
+	if spec.Script.Remove == "" {
+		return fmt.Errorf("no remove script defined for %s", spec.Name)
+	}
+
+	announce(ctx, p.RemoveCommand(spec))
+
+	_, err := p.run(ctx, spec, spec.Script.Remove, p.timeout(spec))
+	return err
+}
+
+// RemoveCommand returns a summary of the remove script
+func (p *ScriptProvider) RemoveCommand(spec ProviderSpec) string {
+	if spec.Script == nil {
+		return "script: (none)"
+	}
+	return "script: " + summarizeScript(spec.Script.Remove)
+}
+
+// ListInstalled returns nothing, since scripts have no inventory
+func (p *ScriptProvider) ListInstalled(ctx context.Context) ([]InstalledPackage, error) {
+	return nil, nil
+}
+
+// checkTrusted refuses to run scripts unless the package definition matched
+// the checksum pinned in devpack.yaml or the user trusts the registry. The
+// checksum embedded in the definition is not enough: whoever edits the
+// definition can recompute it.
+func (p *ScriptProvider) checkTrusted(spec ProviderSpec) error {
+	if spec.Script == nil {
+		return fmt.Errorf("no scripts defined for %s", spec.Name)
+	}
+	if spec.Pinned || trustRegistry {
+		return nil
+	}
+	return fmt.Errorf("refusing to run scripts for %s: package definition is not pinned "+
+		"(review it with 'unipm info' and set its checksum under overrides in devpack.yaml, "+
+		"or use --trust-registry or set registry.trusted in ~/.unipm/config.yaml)", spec.Name)
+}
+
+// timeout returns the install/remove timeout for a spec
+func (p *ScriptProvider) timeout(spec ProviderSpec) time.Duration {
+	if spec.Script.Timeout > 0 {
+		return spec.Script.Timeout
+	}
+	return defaultScriptTimeout
+}
+
+// run executes a snippet in a temporary directory with a timeout and
+// returns its captured output
+func (p *ScriptProvider) run(ctx context.Context, spec ProviderSpec, script string, timeout time.Duration) (string, error) {
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	workDir, err := os.MkdirTemp("", "unipm-script-*")
+	if err != nil {
+		return "", err
+	}
+	defer func() { _ = os.RemoveAll(workDir) }()
+
+	cmd := Command{
+		Name: "sh",
+		Args: []string{"-e", "-c", script},
+		Dir:  workDir,
+		Env: []string{
+			"UNIPM_PACKAGE=" + spec.Name,
+			"UNIPM_VERSION=" + spec.Version,
+		},
+	}
+	if runtime.GOOS == "windows" {
+		cmd.Name = "powershell"
+		cmd.Args = []string{"-NoProfile", "-NonInteractive", "-Command", script}
+	}
+
+	var output bytes.Buffer
+	cmd.Stdout = &output
+	if stream := outputFrom(ctx); stream != nil {
+		cmd.Stdout = io.MultiWriter(&output, stream)
+	}
+	cmd.Stderr = cmd.Stdout
+
+	logger.Debug("Running script for %s:\n%s", spec.Name, script)
+	err = p.Runner().Run(ctx, cmd)
+	logger.Debug("Script output for %s:\n%s", spec.Name, output.String())
+
+	if ctx.Err() == context.DeadlineExceeded {
+		return "", fmt.Errorf("script for %s timed out after %s", spec.Name, timeout)
+	}
+	if err != nil {
+		command := FormatCommand(cmd.Name, cmd.Args[:len(cmd.Args)-1]...) + " " + summarizeScript(script)
+		return "", errors.NewCommandError(command, exitCode(err), tailLines(output.String(), outputTailLines), err)
+	}
+
+	return strings.TrimSpace(output.String()), nil
+}
+
+// versionNumber matches a dotted version number in version script output
+var versionNumber = regexp.MustCompile(`\d+(\.\d+)*`)
+
+// summarizeScript returns the first line of a script, marking omitted lines
+func summarizeScript(script string) string {
+	lines := strings.Split(strings.TrimSpace(script), "\n")
+	if len(lines) > 1 {
+		return fmt.Sprintf("%s … (+%d lines)", strings.TrimSpace(lines[0]), len(lines)-1)
+	}
+	return lines[0]
+}
+
+// tailLines returns the last n lines of output
+func tailLines(output string, n int) string {
+	lines := strings.Split(strings.TrimRight(output, "\n"), "\n")
+	if len(lines) > n {
+		lines = lines[len(lines)-n:]
+	}
+	return strings.Join(lines, "\n")
+}
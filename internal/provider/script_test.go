@@ -0,0 +1,134 @@
+package provider
+
+import (
+	"context"
+	"io"
+	"runtime"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// scriptCommand returns the command line the script provider runs a snippet with
+func scriptCommand(script string) string {
+	if runtime.GOOS == "windows" {
+		return "powershell -NoProfile -NonInteractive -Command " + script
+	}
+	return "sh -e -c " + script
+}
+
+func testScriptSpec(pinned bool) ProviderSpec {
+	return ProviderSpec{
+		Type: "script",
+		Name: "internal-tool",
+		Script: &ScriptSpec{
+			Install: "install-tool",
+			Remove:  "remove-tool",
+			Check:   "check-tool",
+			Version: "internal-tool --version",
+		},
+		Pinned: pinned,
+	}
+}
+
+func TestScriptProvider_TrustGate(t *testing.T) {
+	tests := []struct {
+		name     string
+		pinned   bool
+		verified bool
+		trusted  bool
+		allowed  bool
+	}{
+		{name: "pinned", pinned: true, allowed: true},
+		{name: "not pinned", allowed: false},
+		{name: "verified but not pinned", verified: true, allowed: false},
+		{name: "not pinned with trusted registry", trusted: true, allowed: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			SetTrustRegistry(tt.trusted)
+			defer SetTrustRegistry(false)
+
+			runner := NewMockRunner().
+				On(scriptCommand("install-tool"), MockResponse{}).
+				On(scriptCommand("remove-tool"), MockResponse{}).
+				On(scriptCommand("check-tool"), MockResponse{})
+
+			p := NewScriptProvider()
+			p.SetRunner(runner)
+			ctx := WithOutput(context.Background(), io.Discard, io.Discard)
+			spec := testScriptSpec(tt.pinned)
+			spec.Verified = tt.verified
+
+			installErr := p.Install(ctx, spec)
+			installed := p.IsInstalled(ctx, spec)
+			removeErr := p.Remove(ctx, spec)
+
+			if tt.allowed {
+				require.NoError(t, installErr)
+				require.NoError(t, removeErr)
+				assert.True(t, installed)
+				assert.Equal(t, []string{
+					scriptCommand("install-tool"),
+					scriptCommand("check-tool"),
+					scriptCommand("remove-tool"),
+				}, runner.CommandLines())
+				return
+			}
+
+			assert.ErrorContains(t, installErr, "refusing to run scripts for internal-tool")
+			assert.ErrorContains(t, removeErr, "refusing to run scripts for internal-tool")
+			assert.False(t, installed)
+			assert.Empty(t, runner.CommandLines(), "no script runs for an unpinned package")
+		})
+	}
+}
+
+func TestScriptProvider_Version(t *testing.T) {
+	tests := []struct {
+		name      string
+		version   string
+		output    string
+		installed bool
+	}{
+		{name: "no version requested", output: "internal-tool 1.4.2", installed: true},
+		{name: "matching prefix", version: "1.4", output: "internal-tool v1.4.2 (linux)\n", installed: true},
+		{name: "lower bound", version: ">=1.2", output: "1.4.2", installed: true},
+		{name: "other version", version: "2", output: "internal-tool 1.4.2", installed: false},
+		{name: "no version number", version: "1", output: "unknown", installed: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			runner := NewMockRunner().
+				On(scriptCommand("check-tool"), MockResponse{}).
+				On(scriptCommand("internal-tool --version"), MockResponse{Stdout: tt.output})
+
+			p := NewScriptProvider()
+			p.SetRunner(runner)
+			spec := testScriptSpec(true)
+			spec.Version = tt.version
+
+			assert.Equal(t, tt.installed, p.IsInstalled(context.Background(), spec))
+		})
+	}
+
+	runner := NewMockRunner().On(scriptCommand("internal-tool --version"), MockResponse{Stdout: "internal-tool 1.4.2\n"})
+	p := NewScriptProvider()
+	p.SetRunner(runner)
+	version, err := p.InstalledVersion(context.Background(), testScriptSpec(true))
+	require.NoError(t, err)
+	assert.Equal(t, "1.4.2", version)
+}
+
+func TestScriptProvider_CheckFails(t *testing.T) {
+	runner := NewMockRunner().
+		On(scriptCommand("check-tool"), MockResponse{ExitCode: 1})
+
+	p := NewScriptProvider()
+	p.SetRunner(runner)
+
+	assert.False(t, p.IsInstalled(context.Background(), testScriptSpec(true)))
+}
@@ -1,13 +1,34 @@
 package provider
 
 import (
+	"context"
 	"fmt"
+	"strconv"
 	"strings"
+
+	"github.com/Litchi-group/unipm/internal/logger"
 )
 
+func init() {
+	mustRegister(Registration{
+		Name:      "snap",
+		Types:     []string{"snap"},
+		Platforms: []string{"linux"},
+		Default:   true,
+		Guide: `Snap is not installed.
+Install it with:
+
+  sudo apt update
+  sudo apt install snapd`,
+		Capabilities: Capabilities{NeedsRoot: true},
+		New:          func() Provider { return NewSnapProvider() },
+	})
+}
+
 // SnapProvider handles Snap package management
 type SnapProvider struct {
 	BaseProvider
+	socketPath string // snapd REST API socket
 }
 
 // NewSnapProvider creates a new Snap provider
@@ -17,68 +38,173 @@ func NewSnapProvider() *SnapProvider {
 			name:       "snap",
 			executable: "snap",
 		},
+		socketPath: "/run/snapd.socket",
 	}
 }
 
-// Install installs a package using Snap
-func (p *SnapProvider) Install(spec ProviderSpec) error {
-	args := []string{"install", spec.Name}
+// snapRisks are the risk levels a channel may name without a track
+var snapRisks = []string{"stable", "candidate", "beta", "edge"}
 
-	if spec.Classic {
-		args = append(args, "--classic")
+// snapInfo is an installed snap as shown by snap list
+type snapInfo struct {
+	Revision string
+	Tracking string // "-" when installed from a file
+	Notes    string
+}
+
+// Install installs a package using Snap, or refreshes it to the requested
+// channel or revision if the planner found it drifted (spec.Refresh)
+func (p *SnapProvider) Install(ctx context.Context, spec ProviderSpec) error {
+	for _, args := range snapInstallCommands(spec) {
+		if err := p.executePrivileged(ctx, args...); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// IsInstalled checks if a package is installed, whichever channel or
+// revision it tracks (see Drift)
+func (p *SnapProvider) IsInstalled(ctx context.Context, spec ProviderSpec) bool {
+	return p.installedSnap(ctx, spec.Name) != nil
+}
+
+// Drift describes how an installed snap differs from the requested channel,
+// revision and confinement, or returns "" if it matches or is not installed
+func (p *SnapProvider) Drift(ctx context.Context, spec ProviderSpec) string {
+	info := p.installedSnap(ctx, spec.Name)
+	if info == nil {
+		return ""
 	}
 
-	fmt.Printf("  → %s\n", FormatCommand("sudo snap", args...))
+	drift := snapDrift(spec, info)
+	if drift != "" {
+		logger.Debug("Snap %s is installed but %s", spec.Name, drift)
+	}
+	return drift
+}
 
-	// Snap requires sudo
-	return execCommandSilent("sudo", append([]string{"snap"}, args...)...)
+// InstallCommand returns the command that would be executed
+func (p *SnapProvider) InstallCommand(spec ProviderSpec) string {
+	var commands []string
+	for _, args := range snapInstallCommands(spec) {
+		commands = append(commands, FormatPrivileged("snap", args...))
+	}
+	return strings.Join(commands, " && ")
 }
 
-// IsInstalled checks if a package is installed
-func (p *SnapProvider) IsInstalled(spec ProviderSpec) bool {
-	args := []string{"list", spec.Name}
+// snapInstallCommands returns the snap commands installing or refreshing a
+// package, then holding a pinned revision
+func snapInstallCommands(spec ProviderSpec) [][]string {
+	action := "install"
+	if spec.Refresh {
+		action = "refresh"
+	}
 
-	output, err := execCommand("snap", args...)
+	commands := [][]string{SnapArgs(action, spec)}
+	if spec.Revision != 0 {
+		// Keep automatic refreshes from moving a pinned revision
+		commands = append(commands, []string{"refresh", "--hold", spec.Name})
+	}
+	return commands
+}
 
-	// Check if package name appears in the list
+// SnapArgs returns the snap install or refresh arguments for a spec's
+// channel, revision and confinement
+func SnapArgs(action string, spec ProviderSpec) []string {
+	args := []string{action, spec.Name}
+	if spec.Channel != "" {
+		args = append(args, "--channel="+spec.Channel)
+	}
+	if spec.Revision != 0 {
+		args = append(args, "--revision="+strconv.Itoa(spec.Revision))
+	}
+	if spec.Classic {
+		args = append(args, "--classic")
+	}
+	if spec.Devmode {
+		args = append(args, "--devmode")
+	}
+	return args
+}
+
+// installedSnap returns the snap list entry of an installed snap, or nil
+func (p *SnapProvider) installedSnap(ctx context.Context, name string) *snapInfo {
+	output, err := p.execCommand(ctx, "snap", "list", name)
 	if err != nil {
-		return false
+		return nil
 	}
 
+	// Columns: Name Version Rev Tracking Publisher Notes
 	for _, line := range strings.Split(output, "\n") {
 		fields := strings.Fields(line)
-		if len(fields) > 0 && fields[0] == spec.Name {
-			return true
+		if len(fields) == 0 || fields[0] != name {
+			continue
+		}
+
+		info := &snapInfo{}
+		if len(fields) > 3 {
+			info.Revision = fields[2]
+			info.Tracking = fields[3]
 		}
+		if len(fields) > 5 {
+			info.Notes = fields[5]
+		}
+		return info
 	}
 
-	return false
+	return nil
 }
 
-// InstallCommand returns the command that would be executed
-func (p *SnapProvider) InstallCommand(spec ProviderSpec) string {
-	args := []string{"install", spec.Name}
+// snapDrift describes how an installed snap differs from its spec, or
+// returns "" if it matches
+func snapDrift(spec ProviderSpec, info *snapInfo) string {
+	if spec.Channel != "" && !channelMatches(info.Tracking, spec.Channel) {
+		return fmt.Sprintf("tracks %s instead of %s", info.Tracking, spec.Channel)
+	}
+	if spec.Revision != 0 && info.Revision != strconv.Itoa(spec.Revision) {
+		return fmt.Sprintf("is at revision %s instead of %d", info.Revision, spec.Revision)
+	}
+	if spec.Devmode && !containsString(strings.Split(info.Notes, ","), "devmode") {
+		return "is not in devmode"
+	}
+	return ""
+}
 
-	if spec.Classic {
-		args = append(args, "--classic")
+// channelMatches reports whether the tracking column of snap list refers to
+// a channel. snap list truncates long channels with "…".
+func channelMatches(tracking, channel string) bool {
+	channel = normalizeChannel(channel)
+	if prefix, ok := strings.CutSuffix(tracking, "…"); ok {
+		return strings.HasPrefix(channel, prefix)
 	}
+	return normalizeChannel(tracking) == channel
+}
 
-	return FormatCommand("sudo snap", args...)
+// normalizeChannel expands a channel to track/risk[/branch], so that
+// "edge" is "latest/edge" and "1.28" is "1.28/stable"
+func normalizeChannel(channel string) string {
+	parts := strings.Split(channel, "/")
+	switch {
+	case containsString(snapRisks, parts[0]):
+		return "latest/" + channel
+	case len(parts) == 1:
+		return channel + "/stable"
+	default:
+		return channel
+	}
 }
 
 // Remove removes a package using Snap
-func (p *SnapProvider) Remove(spec ProviderSpec) error {
+func (p *SnapProvider) Remove(ctx context.Context, spec ProviderSpec) error {
 	args := []string{"remove", spec.Name}
 
-	fmt.Printf("  → %s\n", FormatCommand("sudo snap", args...))
-
-	// Snap requires sudo
-	return execCommandSilent("sudo", append([]string{"snap"}, args...)...)
+	return p.executePrivileged(ctx, args...)
 }
 
 // RemoveCommand returns the uninstall command
 func (p *SnapProvider) RemoveCommand(spec ProviderSpec) string {
 	args := []string{"remove", spec.Name}
 
-	return FormatCommand("sudo snap", args...)
+	return FormatPrivileged("snap", args...)
 }
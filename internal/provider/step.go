@@ -0,0 +1,42 @@
+package provider
+
+import (
+	"context"
+	"fmt"
+)
+
+// Refresh policies for package indexes, set with --refresh/--no-refresh
+const (
+	RefreshAuto   = "auto"   // Refresh when indexes are missing or stale
+	RefreshAlways = "always" // Refresh before installing
+	RefreshNever  = "never"  // Never refresh
+)
+
+// refreshPolicy is the refresh policy for this run
+var refreshPolicy = RefreshAuto
+
+// SetRefreshPolicy sets when providers refresh their package indexes
+func SetRefreshPolicy(policy string) error {
+	switch policy {
+	case RefreshAuto, RefreshAlways, RefreshNever:
+		refreshPolicy = policy
+		return nil
+	}
+	return fmt.Errorf("unknown refresh policy %q", policy)
+}
+
+// Step is a preparatory action run once before a plan's tasks
+// (e.g., refreshing apt package lists)
+type Step struct {
+	Key         string // Identifies the step; steps with the same key run once
+	Description string // Shown in plan output (e.g., "Refresh apt package lists")
+	Reason      string // Why the step is needed (optional)
+	Command     string // Command that would be executed
+	Run         func(ctx context.Context) error
+}
+
+// Preparer is implemented by providers that need preparatory steps before
+// installing the given packages
+type Preparer interface {
+	PrepareSteps(ctx context.Context, specs []ProviderSpec) []Step
+}
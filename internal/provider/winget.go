@@ -1,6 +1,24 @@
 package provider
 
-import "strings"
+import (
+	"context"
+	"strings"
+)
+
+func init() {
+	mustRegister(Registration{
+		Name:      "winget",
+		Types:     []string{"winget"},
+		Platforms: []string{"windows"},
+		Default:   true,
+		Guide: `WinGet is not installed.
+Install it from: https://aka.ms/getwinget
+
+WinGet comes pre-installed on Windows 11 and recent Windows 10 builds.
+If missing, install "App Installer" from the Microsoft Store.`,
+		New: func() Provider { return NewWinGetProvider() },
+	})
+}
 
 // WinGetProvider handles WinGet package management
 type WinGetProvider struct {
@@ -26,17 +44,17 @@ func (p *WinGetProvider) getPackageID(spec ProviderSpec) string {
 }
 
 // Install installs a package using WinGet
-func (p *WinGetProvider) Install(spec ProviderSpec) error {
+func (p *WinGetProvider) Install(ctx context.Context, spec ProviderSpec) error {
 	args := p.buildInstallArgs(spec)
-	return p.executeWithDisplay(args...)
+	return p.executeWithDisplay(ctx, args...)
 }
 
 // IsInstalled checks if a package is installed
-func (p *WinGetProvider) IsInstalled(spec ProviderSpec) bool {
+func (p *WinGetProvider) IsInstalled(ctx context.Context, spec ProviderSpec) bool {
 	packageID := p.getPackageID(spec)
 	args := []string{"list", "--id", packageID}
 
-	output, err := execCommand("winget", args...)
+	output, err := p.execCommand(ctx, "winget", args...)
 	return err == nil && strings.Contains(strings.ToLower(output), strings.ToLower(packageID))
 }
 
@@ -47,9 +65,9 @@ func (p *WinGetProvider) InstallCommand(spec ProviderSpec) string {
 }
 
 // Remove removes a package using WinGet
-func (p *WinGetProvider) Remove(spec ProviderSpec) error {
+func (p *WinGetProvider) Remove(ctx context.Context, spec ProviderSpec) error {
 	args := p.buildRemoveArgs(spec)
-	return p.executeWithDisplay(args...)
+	return p.executeWithDisplay(ctx, args...)
 }
 
 // RemoveCommand returns the uninstall command
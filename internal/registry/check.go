@@ -0,0 +1,212 @@
+package registry
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/Litchi-group/unipm/internal/detector"
+	"github.com/Litchi-group/unipm/internal/provider"
+)
+
+// Finding severities
+const (
+	SeverityError   = "error"   // The package cannot be installed
+	SeverityWarning = "warning" // The package needs something not installed by default
+)
+
+// Finding kinds
+const (
+	FindingLoad        = "load"        // Package definition could not be loaded
+	FindingCycle       = "cycle"       // Dependency cycle
+	FindingUnmapped    = "unmapped"    // No mapping for the OS
+	FindingUnsupported = "unsupported" // Mapped only to providers unsupported on the target
+	FindingUnknown     = "unknown"     // Mapped to a provider type not registered here (e.g., a plugin)
+	FindingNonDefault  = "non_default" // Mapped to a provider not installed by default
+)
+
+// Finding is a problem found by Check
+type Finding struct {
+	Severity  string `json:"severity" yaml:"severity"`
+	Kind      string `json:"kind" yaml:"kind"`
+	PackageID string `json:"package" yaml:"package"`
+	Target    string `json:"target,omitempty" yaml:"target,omitempty"`     // System the finding applies to; empty for all
+	Provider  string `json:"provider,omitempty" yaml:"provider,omitempty"` // Provider type of the selected mapping
+	Message   string `json:"message" yaml:"message"`
+}
+
+// CheckReport is the result of Check
+type CheckReport struct {
+	Packages []string  `json:"packages" yaml:"packages"` // Checked packages, including dependencies
+	Targets  []string  `json:"targets" yaml:"targets"`
+	Findings []Finding `json:"findings" yaml:"findings"`
+}
+
+// Count returns the number of findings with the given severity
+func (r *CheckReport) Count(severity string) int {
+	n := 0
+	for _, f := range r.Findings {
+		if f.Severity == severity {
+			n++
+		}
+	}
+	return n
+}
+
+// CheckTargets returns the systems Check uses by default: macOS, Windows and
+// a distribution of each major Linux family
+func CheckTargets() []*detector.OSInfo {
+	targets := []*detector.OSInfo{
+		{Platform: "darwin"},
+		{Platform: "windows"},
+	}
+	for _, distro := range []string{"ubuntu", "debian", "fedora", "arch"} {
+		targets = append(targets, &detector.OSInfo{
+			Platform: "linux",
+			Distro:   distro,
+			Family:   detector.DistroFamily(distro),
+		})
+	}
+	return targets
+}
+
+// Check resolves packages and their dependencies on each target, reporting
+// packages that cannot be installed there, that need a provider not
+// installed by default, and dependency cycles
+func Check(reg RegistryInterface, packageIDs []string, targets []*detector.OSInfo) *CheckReport {
+	report := &CheckReport{Packages: []string{}, Findings: []Finding{}}
+	for _, target := range targets {
+		report.Targets = append(report.Targets, target.String())
+	}
+
+	packages := make(map[string]*Package)
+	visiting := make(map[string]bool)
+	cycles := make(map[string]bool)
+	var path []string
+
+	var visit func(string)
+	visit = func(pkgID string) {
+		if visiting[pkgID] {
+			// Report each cycle once, whichever package it was entered from
+			var cycle []string
+			for i, p := range path {
+				if p == pkgID {
+					cycle = append(append(cycle, path[i:]...), pkgID)
+					break
+				}
+			}
+			if key := cycleKey(cycle); !cycles[key] {
+				cycles[key] = true
+				report.Findings = append(report.Findings, Finding{
+					Severity:  SeverityError,
+					Kind:      FindingCycle,
+					PackageID: pkgID,
+					Message:   "dependency cycle: " + strings.Join(cycle, " → "),
+				})
+			}
+			return
+		}
+		if _, seen := packages[pkgID]; seen {
+			return
+		}
+
+		pkg, err := reg.LoadPackage(pkgID)
+		packages[pkgID] = pkg
+		if err != nil {
+			report.Findings = append(report.Findings, Finding{
+				Severity:  SeverityError,
+				Kind:      FindingLoad,
+				PackageID: pkgID,
+				Message:   err.Error(),
+			})
+			return
+		}
+
+		visiting[pkgID] = true
+		path = append(path, pkgID)
+		for _, depID := range pkg.Dependencies {
+			visit(depID)
+		}
+		path = path[:len(path)-1]
+		visiting[pkgID] = false
+	}
+
+	for _, pkgID := range packageIDs {
+		visit(pkgID)
+	}
+
+	for pkgID := range packages {
+		report.Packages = append(report.Packages, pkgID)
+	}
+	sort.Strings(report.Packages)
+
+	for _, target := range targets {
+		for _, pkgID := range report.Packages {
+			if pkg := packages[pkgID]; pkg != nil {
+				if f, ok := checkMapping(pkgID, pkg, target); ok {
+					report.Findings = append(report.Findings, f)
+				}
+			}
+		}
+	}
+
+	return report
+}
+
+// checkMapping checks the mapping a package would be installed with on target
+func checkMapping(pkgID string, pkg *Package, target *detector.OSInfo) (Finding, bool) {
+	f := Finding{PackageID: pkgID, Target: target.String(), Severity: SeverityError}
+
+	mapping, ok := SelectMapping(pkg, target)
+	if !ok {
+		candidates := candidateMappings(pkg, target)
+		if len(candidates) == 0 {
+			f.Kind = FindingUnmapped
+			f.Message = fmt.Sprintf("no provider mapping for %s", OSKey(target))
+			return f, true
+		}
+
+		// A provider type not registered here may be a plugin that supports it
+		for _, m := range candidates {
+			if _, known := provider.Lookup(m.Type); !known {
+				f.Severity = SeverityWarning
+				f.Kind = FindingUnknown
+				f.Provider = m.Type
+				f.Message = fmt.Sprintf("provider %s is not built in; it needs a plugin", m.Type)
+				return f, true
+			}
+		}
+
+		f.Kind = FindingUnsupported
+		f.Provider = candidates[0].Type
+		f.Message = fmt.Sprintf("no mapping for this OS: %s not supported on %s", mappingTypes(candidates), target.String())
+		return f, true
+	}
+	f.Provider = mapping.Type
+
+	reg, _ := provider.Lookup(mapping.Type)
+	if reg.Default || reg.Builtin {
+		return f, false
+	}
+
+	f.Severity = SeverityWarning
+	f.Kind = FindingNonDefault
+	f.Message = fmt.Sprintf("needs %s, which is not installed by default", reg.Name)
+	return f, true
+}
+
+// cycleKey identifies a cycle independently of where it starts
+func cycleKey(cycle []string) string {
+	if len(cycle) < 2 {
+		return strings.Join(cycle, " ")
+	}
+	nodes := cycle[:len(cycle)-1]
+
+	start := 0
+	for i, node := range nodes {
+		if node < nodes[start] {
+			start = i
+		}
+	}
+	return strings.Join(append(append([]string{}, nodes[start:]...), nodes[:start]...), " ")
+}
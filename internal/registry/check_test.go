@@ -0,0 +1,100 @@
+package registry
+
+import (
+	"testing"
+
+	"github.com/Litchi-group/unipm/internal/detector"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCheck(t *testing.T) {
+	reg := NewMockRegistry()
+	for _, pkg := range []*Package{
+		{ID: "git", Providers: map[string][]ProviderMapping{
+			"macos":   {{Type: "brew", Name: "git"}},
+			"windows": {{Type: "winget", ID: "Git.Git"}},
+			"linux":   {{Type: "apt", Name: "git"}},
+		}},
+		{ID: "typescript", Dependencies: []string{"node"}, Providers: map[string][]ProviderMapping{
+			"all": {{Type: "npm", Name: "typescript"}},
+		}},
+		{ID: "node", Dependencies: []string{"typescript"}, Providers: map[string][]ProviderMapping{
+			"macos": {{Type: "brew", Name: "node"}},
+		}},
+		{ID: "tool", Providers: map[string][]ProviderMapping{
+			"linux": {{Type: "apt", Name: "tool"}, {Type: "nixpkg", Name: "tool"}},
+		}},
+	} {
+		reg.AddPackage(pkg)
+	}
+
+	targets := []*detector.OSInfo{
+		{Platform: "darwin"},
+		{Platform: "linux", Distro: "fedora", Family: "rhel"},
+	}
+	report := Check(reg, []string{"git", "typescript", "tool", "missing"}, targets)
+
+	assert.Equal(t, []string{"git", "missing", "node", "tool", "typescript"}, report.Packages)
+	assert.Equal(t, []string{"darwin", "linux (fedora)"}, report.Targets)
+
+	type key struct{ kind, pkg, target string }
+	var got []key
+	for _, f := range report.Findings {
+		got = append(got, key{f.Kind, f.PackageID, f.Target})
+	}
+	assert.ElementsMatch(t, []key{
+		{FindingCycle, "typescript", ""},
+		{FindingLoad, "missing", ""},
+		{FindingNonDefault, "typescript", "darwin"},
+		{FindingUnsupported, "git", "linux (fedora)"},
+		{FindingUnmapped, "node", "linux (fedora)"},
+		{FindingNonDefault, "typescript", "linux (fedora)"},
+		{FindingUnmapped, "tool", "darwin"},
+		{FindingUnknown, "tool", "linux (fedora)"},
+	}, got)
+
+	assert.Equal(t, 5, report.Count(SeverityError))
+	assert.Equal(t, 3, report.Count(SeverityWarning))
+
+	for _, f := range report.Findings {
+		if f.Kind == FindingUnsupported {
+			assert.Equal(t, "no mapping for this OS: apt not supported on linux (fedora)", f.Message)
+		}
+	}
+}
+
+func TestCycleKey(t *testing.T) {
+	assert.Equal(t, cycleKey([]string{"b", "c", "a", "b"}), cycleKey([]string{"a", "b", "c", "a"}))
+	assert.NotEqual(t, cycleKey([]string{"a", "b", "a"}), cycleKey([]string{"a", "c", "a"}))
+}
+
+func TestSelectMapping(t *testing.T) {
+	pkg := &Package{ID: "code", Providers: map[string][]ProviderMapping{
+		"linux": {{Type: "apt", Name: "code"}, {Type: "snap", Name: "code"}},
+		"all":   {{Type: "npm", Name: "code"}},
+	}}
+
+	tests := []struct {
+		name     string
+		osInfo   *detector.OSInfo
+		expected string // Selected provider type, "" for none
+	}{
+		{"first supported", &detector.OSInfo{Platform: "linux", Distro: "ubuntu", Family: "debian"}, "apt"},
+		{"skips unsupported", &detector.OSInfo{Platform: "linux", Distro: "fedora", Family: "rhel"}, "snap"},
+		{"cross-OS mapping", &detector.OSInfo{Platform: "darwin"}, "npm"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mapping, ok := SelectMapping(pkg, tt.osInfo)
+			assert.True(t, ok)
+			assert.Equal(t, tt.expected, mapping.Type)
+		})
+	}
+
+	aptOnly := &Package{ID: "git", Providers: map[string][]ProviderMapping{
+		"linux": {{Type: "apt", Name: "git"}},
+	}}
+	_, ok := SelectMapping(aptOnly, &detector.OSInfo{Platform: "linux", Distro: "fedora", Family: "rhel"})
+	assert.False(t, ok, "no task for a provider the OS does not support")
+}
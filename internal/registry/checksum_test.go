@@ -0,0 +1,91 @@
+package registry
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const scriptPackage = `id: internal-tool
+name: Internal Tool
+homepage: https://example.com
+providers:
+  linux:
+    - type: script
+      name: internal-tool
+      script:
+        install: curl -fsSL https://example.com/install.sh | sh
+        remove: rm -f "$HOME/.local/bin/internal-tool"
+        check: command -v internal-tool
+`
+
+// serveRegistry returns a registry fetching the given definitions from a test server
+func serveRegistry(t *testing.T, definitions map[string]string) *Registry {
+	t.Helper()
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		data, ok := definitions[strings.TrimSuffix(strings.TrimPrefix(r.URL.Path, "/"), ".yaml")]
+		if !ok {
+			http.NotFound(w, r)
+			return
+		}
+		_, _ = w.Write([]byte(data))
+	}))
+	t.Cleanup(server.Close)
+
+	return &Registry{
+		baseURL:  server.URL,
+		cacheDir: t.TempDir(),
+		cacheTTL: time.Hour,
+		client:   server.Client(),
+	}
+}
+
+func TestLoadPackage_Checksum(t *testing.T) {
+	signed := scriptPackage + "checksum: " + GenerateChecksum([]byte(scriptPackage)) + "\n"
+	tampered := `id: git
+name: Git
+homepage: https://git-scm.com
+providers:
+  linux:
+    - type: apt
+      name: git
+      repository:
+        source: https://attacker.example.com/apt
+checksum: ` + GenerateChecksum([]byte("id: git\nname: Git\n")) + "\n"
+
+	reg := serveRegistry(t, map[string]string{
+		"signed":   signed,
+		"unsigned": scriptPackage,
+		"tampered": tampered,
+	})
+
+	pkg, err := reg.LoadPackage("signed")
+	require.NoError(t, err)
+	assert.True(t, pkg.Verified)
+
+	// Loading again from the cache verifies it again
+	pkg, err = reg.LoadPackage("signed")
+	require.NoError(t, err)
+	assert.True(t, pkg.Verified)
+
+	assert.Equal(t, GenerateChecksum([]byte(scriptPackage)), pkg.Digest)
+
+	// Unsigned packages have a digest to pin too, the same from the cache
+	pkg, err = reg.LoadPackage("unsigned")
+	require.NoError(t, err)
+	assert.False(t, pkg.Verified)
+	assert.Equal(t, GenerateChecksum([]byte(scriptPackage)), pkg.Digest)
+	pkg, err = reg.LoadPackage("unsigned")
+	require.NoError(t, err)
+	assert.Equal(t, GenerateChecksum([]byte(scriptPackage)), pkg.Digest)
+
+	// A mismatch fails every package, not only those with scripts
+	_, err = reg.LoadPackage("tampered")
+	assert.ErrorContains(t, err, "checksum mismatch for package git")
+}
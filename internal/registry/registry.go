@@ -21,35 +21,72 @@ const (
 
 	// CacheDir is the local cache directory
 	CacheDir = ".unipm/cache"
+
+	// AllOSKey is the providers key for mappings that work on every OS
+	// (e.g., npm, pipx, cargo, go)
+	AllOSKey = "all"
 )
 
 // Package represents a package definition from the registry
 type Package struct {
-	ID           string                       `yaml:"id"`
-	Name         string                       `yaml:"name"`
-	Homepage     string                       `yaml:"homepage"`
-	Dependencies []string                     `yaml:"dependencies,omitempty"`
-	Providers    map[string][]ProviderMapping `yaml:"providers"`
-	Checksum     string                       `yaml:"checksum,omitempty"` // SHA256 checksum
+	ID           string                       `json:"id" yaml:"id"`
+	Name         string                       `json:"name" yaml:"name"`
+	Homepage     string                       `json:"homepage" yaml:"homepage"`
+	Dependencies []string                     `json:"dependencies,omitempty" yaml:"dependencies,omitempty"`
+	Providers    map[string][]ProviderMapping `json:"providers" yaml:"providers"`
+	Checksum     string                       `json:"checksum,omitempty" yaml:"checksum,omitempty"` // SHA256 checksum
+	Verified     bool                         `json:"verified" yaml:"-"`                            // Checksum was present and matched
+	Digest       string                       `json:"digest" yaml:"-"`                              // SHA256 of the definition, to pin in devpack.yaml
 }
 
 // ProviderMapping represents OS-specific provider configuration
 type ProviderMapping struct {
-	Type    string `yaml:"type"`    // "brew", "brew_cask", "winget", "apt", "snap"
-	Name    string `yaml:"name"`    // Package name
-	ID      string `yaml:"id"`      // Package ID (for winget)
-	Classic bool   `yaml:"classic"` // Classic mode (for snap)
+	Type      string            `json:"type" yaml:"type"`                                 // "brew", "brew_cask", "winget", "apt", "snap", "npm", "pipx", "cargo", "go", "binary", "script", "mise", "asdf"
+	Name      string            `json:"name" yaml:"name"`                                 // Package name
+	ID        string            `json:"id" yaml:"id"`                                     // Package ID (for winget)
+	Classic   bool              `json:"classic" yaml:"classic"`                           // Classic mode (for snap)
+	Channel   string            `json:"channel,omitempty" yaml:"channel,omitempty"`       // Channel to track, e.g., "1.28/stable" (for snap)
+	Revision  int               `json:"revision,omitempty" yaml:"revision,omitempty"`     // Revision to pin (for snap)
+	Devmode   bool              `json:"devmode,omitempty" yaml:"devmode,omitempty"`       // Developer mode confinement (for snap)
+	Tap       string            `json:"tap,omitempty" yaml:"tap,omitempty"`               // Tap, e.g., "hashicorp/tap" (for brew)
+	TapURL    string            `json:"tap_url,omitempty" yaml:"tap_url,omitempty"`       // Custom tap repository URL (for brew)
+	Version   string            `json:"version,omitempty" yaml:"version,omitempty"`       // Default package version (for binary, mise, asdf)
+	URL       string            `json:"url,omitempty" yaml:"url,omitempty"`               // Download URL with {{os}}, {{arch}}, {{version}} (for binary)
+	Checksums map[string]string `json:"checksums,omitempty" yaml:"checksums,omitempty"`   // SHA256 per "<os>-<arch>" (for binary)
+	Binaries  []string          `json:"binaries,omitempty" yaml:"binaries,omitempty"`     // Binaries to extract, defaults to name (for binary)
+	Script    *ScriptMapping    `json:"script,omitempty" yaml:"script,omitempty"`         // Shell snippets (for script)
+	Repo      *RepoMapping      `json:"repository,omitempty" yaml:"repository,omitempty"` // Third-party repository (for apt)
+}
+
+// RepoMapping declares a third-party apt repository. Source, suite and
+// key_url may use {{distro}}, {{codename}} and {{arch}}.
+type RepoMapping struct {
+	Name       string `json:"name,omitempty" yaml:"name,omitempty"`             // File name, defaults to the package name
+	Source     string `json:"source" yaml:"source"`                             // Repository URI
+	Suite      string `json:"suite,omitempty" yaml:"suite,omitempty"`           // Suite, defaults to "{{codename}}"
+	Components string `json:"components,omitempty" yaml:"components,omitempty"` // Space-separated components, defaults to "main"
+	KeyURL     string `json:"key_url,omitempty" yaml:"key_url,omitempty"`       // URL of the signing key
+	Key        string `json:"key,omitempty" yaml:"key,omitempty"`               // Inline ASCII-armored signing key
+}
+
+// ScriptMapping contains the shell snippets of a script provider mapping
+type ScriptMapping struct {
+	Install string `json:"install" yaml:"install"`                     // Installs the package
+	Remove  string `json:"remove" yaml:"remove"`                       // Removes the package
+	Check   string `json:"check" yaml:"check"`                         // Exits 0 if the package is installed
+	Version string `json:"version,omitempty" yaml:"version,omitempty"` // Prints the installed version (optional)
+	Timeout string `json:"timeout,omitempty" yaml:"timeout,omitempty"` // Install/remove timeout (e.g., "15m")
 }
 
 // PackageInfo represents minimal package information for listing/searching
 type PackageInfo struct {
-	ID   string `yaml:"id"`
-	Name string `yaml:"name"`
+	ID   string `json:"id" yaml:"id"`
+	Name string `json:"name" yaml:"name"`
 }
 
 // PackageIndex represents the package index file
 type PackageIndex struct {
-	Packages []PackageInfo `yaml:"packages"`
+	Packages []PackageInfo `json:"packages" yaml:"packages"`
 }
 
 // Registry manages package definitions
@@ -122,9 +159,33 @@ func (r *Registry) fetchPackage(packageID string) (*Package, error) {
 		return nil, fmt.Errorf("failed to parse package definition: %w", err)
 	}
 
+	if err := verifyPackage(data, &pkg); err != nil {
+		return nil, err
+	}
+
 	return &pkg, nil
 }
 
+// verifyPackage checks the package checksum and records whether it was verified.
+// Packages without a checksum load unverified; a mismatch is an error. The
+// embedded checksum only proves the file is intact: whoever edits it can
+// recompute it, so the digest is also recorded for pinning in devpack.yaml.
+func verifyPackage(data []byte, pkg *Package) error {
+	pkg.Digest = calculatePackageChecksum(data)
+
+	if pkg.Checksum == "" {
+		pkg.Verified = false
+		return nil
+	}
+
+	if !VerifyChecksum(data, pkg) {
+		return fmt.Errorf("checksum mismatch for package %s", pkg.ID)
+	}
+
+	pkg.Verified = true
+	return nil
+}
+
 // loadFromCache loads a package from the local cache
 func (r *Registry) loadFromCache(packageID string) (*Package, error) {
 	cachePath := r.getCachePath(packageID)
@@ -151,6 +212,10 @@ func (r *Registry) loadFromCache(packageID string) (*Package, error) {
 		return nil, err
 	}
 
+	if err := verifyPackage(data, &pkg); err != nil {
+		return nil, err
+	}
+
 	return &pkg, nil
 }
 
@@ -180,17 +245,17 @@ func (r *Registry) getCachePath(packageID string) string {
 func (r *Registry) LoadIndex() ([]PackageInfo, error) {
 	resp, err := r.client.Get(IndexURL)
 	if err != nil {
-		return nil, fmt.Errorf("failed to fetch index: %w", err)
+		return nil, errors.NewNetworkError(IndexURL, "failed to fetch index", err)
 	}
 	defer func() { _ = resp.Body.Close() }()
 
 	if resp.StatusCode != 200 {
-		return nil, fmt.Errorf("unexpected status code %d for index", resp.StatusCode)
+		return nil, errors.NewNetworkError(IndexURL, fmt.Sprintf("unexpected status code %d for index", resp.StatusCode), nil)
 	}
 
 	data, err := io.ReadAll(resp.Body)
 	if err != nil {
-		return nil, fmt.Errorf("failed to read index: %w", err)
+		return nil, errors.NewNetworkError(IndexURL, "failed to read index", err)
 	}
 
 	var index PackageIndex
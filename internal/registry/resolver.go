@@ -2,6 +2,9 @@ package registry
 
 import (
 	"fmt"
+	"regexp"
+	"strings"
+	"time"
 
 	"github.com/Litchi-group/unipm/internal/detector"
 	"github.com/Litchi-group/unipm/internal/provider"
@@ -30,32 +33,148 @@ func (r *Resolver) Resolve(packageID string) (*provider.ProviderSpec, error) {
 	}
 
 	// Get OS-specific providers
-	osKey := r.getOSKey()
-	mappings, ok := pkg.Providers[osKey]
-	if !ok || len(mappings) == 0 {
-		return nil, fmt.Errorf("no provider available for %s on %s", packageID, osKey)
+	mapping, ok := SelectMapping(pkg, r.osInfo)
+	if !ok {
+		if candidates := candidateMappings(pkg, r.osInfo); len(candidates) > 0 {
+			return nil, fmt.Errorf("no provider available for %s on %s: %s not supported there",
+				packageID, r.osInfo.String(), mappingTypes(candidates))
+		}
+		return nil, fmt.Errorf("no provider available for %s on %s", packageID, OSKey(r.osInfo))
+	}
+
+	var script *provider.ScriptSpec
+	if mapping.Script != nil {
+		script, err = convertScript(mapping.Script)
+		if err != nil {
+			return nil, fmt.Errorf("invalid script for %s: %w", packageID, err)
+		}
 	}
 
-	// Use the first available provider
-	// TODO: Implement provider priority/fallback
-	mapping := mappings[0]
+	var repo *provider.AptRepository
+	if mapping.Repo != nil {
+		repo, err = convertRepo(mapping.Name, mapping.Repo)
+		if err != nil {
+			return nil, fmt.Errorf("invalid repository for %s: %w", packageID, err)
+		}
+	}
 
 	return &provider.ProviderSpec{
-		Type:    mapping.Type,
-		Name:    mapping.Name,
-		ID:      mapping.ID,
-		Classic: mapping.Classic,
+		Type:      mapping.Type,
+		Name:      mapping.Name,
+		ID:        mapping.ID,
+		Classic:   mapping.Classic,
+		Channel:   mapping.Channel,
+		Revision:  mapping.Revision,
+		Devmode:   mapping.Devmode,
+		Tap:       mapping.Tap,
+		TapURL:    mapping.TapURL,
+		Version:   mapping.Version,
+		URL:       mapping.URL,
+		Checksums: mapping.Checksums,
+		Binaries:  mapping.Binaries,
+		Script:    script,
+		Repo:      repo,
+		Verified:  pkg.Verified,
 	}, nil
 }
 
-// getOSKey returns the OS key for provider lookup
-func (r *Resolver) getOSKey() string {
+// convertScript converts a script mapping to a provider script spec
+func convertScript(m *ScriptMapping) (*provider.ScriptSpec, error) {
+	script := &provider.ScriptSpec{
+		Install: m.Install,
+		Remove:  m.Remove,
+		Check:   m.Check,
+		Version: m.Version,
+	}
+
+	if m.Timeout != "" {
+		timeout, err := time.ParseDuration(m.Timeout)
+		if err != nil {
+			return nil, fmt.Errorf("invalid timeout %q: %w", m.Timeout, err)
+		}
+		script.Timeout = timeout
+	}
+
+	return script, nil
+}
+
+// repoNamePattern restricts repository names, which become file names under /etc/apt
+var repoNamePattern = regexp.MustCompile(`^[A-Za-z0-9][A-Za-z0-9._-]*$`)
+
+// convertRepo converts a repository mapping to a provider repository,
+// applying defaults
+func convertRepo(packageName string, m *RepoMapping) (*provider.AptRepository, error) {
+	if m.Source == "" {
+		return nil, fmt.Errorf("source is required")
+	}
+	if m.KeyURL == "" && m.Key == "" {
+		return nil, fmt.Errorf("key_url or key is required")
+	}
+
+	repo := &provider.AptRepository{
+		Name:       m.Name,
+		Source:     m.Source,
+		Suite:      m.Suite,
+		Components: strings.Fields(m.Components),
+		KeyURL:     m.KeyURL,
+		Key:        m.Key,
+	}
+
+	if repo.Name == "" {
+		repo.Name = packageName
+	}
+	if !repoNamePattern.MatchString(repo.Name) {
+		return nil, fmt.Errorf("invalid name %q: only letters, digits, '.', '_' and '-' are allowed", repo.Name)
+	}
+	if repo.Suite == "" {
+		repo.Suite = "{{codename}}"
+	}
+	if len(repo.Components) == 0 {
+		repo.Components = []string{"main"}
+	}
+
+	return repo, nil
+}
+
+// SelectMapping returns the mapping used to install a package on the given
+// OS: the first mapping under the OS key, or else under "all", whose provider
+// supports this OS (e.g., skipping apt on non-Debian distributions).
+// ok is false when no mapping for the OS has a provider supporting it.
+func SelectMapping(pkg *Package, osInfo *detector.OSInfo) (mapping ProviderMapping, ok bool) {
+	for _, m := range candidateMappings(pkg, osInfo) {
+		if reg, ok := provider.Lookup(m.Type); ok && reg.SupportsOS(osInfo) {
+			return m, true
+		}
+	}
+	return ProviderMapping{}, false
+}
+
+// candidateMappings returns the mappings of a package for an OS, whether or
+// not their providers support it: those under the OS key, or else under "all"
+func candidateMappings(pkg *Package, osInfo *detector.OSInfo) []ProviderMapping {
+	if mappings := pkg.Providers[OSKey(osInfo)]; len(mappings) > 0 {
+		return mappings
+	}
+	return pkg.Providers[AllOSKey]
+}
+
+// mappingTypes returns the provider types of mappings, e.g., "apt, snap"
+func mappingTypes(mappings []ProviderMapping) string {
+	types := make([]string, 0, len(mappings))
+	for _, m := range mappings {
+		types = append(types, m.Type)
+	}
+	return strings.Join(types, ", ")
+}
+
+// OSKey returns the providers key of an OS
+func OSKey(osInfo *detector.OSInfo) string {
 	switch {
-	case r.osInfo.IsMacOS():
+	case osInfo.IsMacOS():
 		return "macos"
-	case r.osInfo.IsWindows():
+	case osInfo.IsWindows():
 		return "windows"
-	case r.osInfo.IsLinux():
+	case osInfo.IsLinux():
 		return "linux"
 	default:
 		return "unknown"
@@ -0,0 +1,102 @@
+package registry
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/Litchi-group/unipm/internal/provider"
+)
+
+// reverseKey identifies a package in a package manager's inventory
+type reverseKey struct {
+	provider string // Provider name, e.g., "brew" for both brew and brew_cask mappings
+	name     string // Lowercased native name
+}
+
+// ReverseIndex maps native package names back to registry package IDs
+type ReverseIndex struct {
+	ids     map[reverseKey]string
+	Skipped []SkippedPackage // Packages left out because they failed to load
+}
+
+// SkippedPackage is a registry package that could not be indexed
+type SkippedPackage struct {
+	ID  string
+	Err error
+}
+
+// NewReverseIndex creates an empty reverse index
+func NewReverseIndex() *ReverseIndex {
+	return &ReverseIndex{ids: make(map[reverseKey]string)}
+}
+
+// BuildReverseIndex loads every package of the registry index and records
+// the native names of its provider mappings on all operating systems.
+// Packages that fail to load are left out and listed in Skipped.
+func BuildReverseIndex(reg RegistryInterface) (*ReverseIndex, error) {
+	infos, err := reg.LoadIndex()
+	if err != nil {
+		return nil, fmt.Errorf("failed to load package index: %w", err)
+	}
+
+	// Index in ID order so that the first of two packages mapping the same
+	// native name wins consistently
+	sort.Slice(infos, func(i, j int) bool { return infos[i].ID < infos[j].ID })
+
+	index := NewReverseIndex()
+	for _, info := range infos {
+		pkg, err := reg.LoadPackage(info.ID)
+		if err != nil {
+			index.Skipped = append(index.Skipped, SkippedPackage{ID: info.ID, Err: err})
+			continue
+		}
+		index.Add(pkg)
+	}
+
+	return index, nil
+}
+
+// Add records the native names of a package's provider mappings
+func (ri *ReverseIndex) Add(pkg *Package) {
+	for _, osKey := range sortedKeys(pkg.Providers) {
+		for _, m := range pkg.Providers[osKey] {
+			reg, ok := provider.Lookup(m.Type)
+			if !ok {
+				continue
+			}
+
+			name := provider.NativeName(provider.ProviderSpec{Type: m.Type, Name: m.Name, ID: m.ID, Tap: m.Tap})
+			key := reverseKey{provider: reg.Name, name: strings.ToLower(name)}
+			if _, taken := ri.ids[key]; !taken {
+				ri.ids[key] = pkg.ID
+			}
+		}
+	}
+}
+
+// Lookup returns the ID of the package that a provider installs under a
+// native name. Architecture-qualified apt names (libfoo:amd64) also match
+// the plain name.
+func (ri *ReverseIndex) Lookup(providerName, name string) (string, bool) {
+	name = strings.ToLower(name)
+	if id, ok := ri.ids[reverseKey{provider: providerName, name: name}]; ok {
+		return id, true
+	}
+
+	if base, _, found := strings.Cut(name, ":"); found {
+		id, ok := ri.ids[reverseKey{provider: providerName, name: base}]
+		return id, ok
+	}
+	return "", false
+}
+
+// sortedKeys returns the keys of a map in sorted order
+func sortedKeys[T any](m map[string]T) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,70 @@
+package registry
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBuildReverseIndex(t *testing.T) {
+	reg := NewMockRegistry()
+	// Listed in the index but fails to load, e.g., on a checksum mismatch
+	reg.AddIndexPackage(PackageInfo{ID: "broken"})
+	for _, pkg := range []*Package{
+		{ID: "git", Providers: map[string][]ProviderMapping{
+			"macos":   {{Type: "brew", Name: "git"}},
+			"windows": {{Type: "winget", ID: "Git.Git"}},
+			"linux":   {{Type: "apt", Name: "git"}},
+		}},
+		{ID: "openssl", Providers: map[string][]ProviderMapping{
+			"linux": {{Type: "apt", Name: "libssl3t64"}},
+		}},
+		{ID: "terraform", Providers: map[string][]ProviderMapping{
+			"macos": {{Type: "brew", Name: "terraform", Tap: "hashicorp/tap"}},
+		}},
+		{ID: "vscode", Providers: map[string][]ProviderMapping{
+			"macos": {{Type: "brew_cask", Name: "visual-studio-code"}},
+			"linux": {{Type: "snap", Name: "code", Classic: true}},
+		}},
+	} {
+		reg.AddPackage(pkg)
+		reg.AddIndexPackage(PackageInfo{ID: pkg.ID})
+	}
+
+	index, err := BuildReverseIndex(reg)
+	require.NoError(t, err)
+	require.Len(t, index.Skipped, 1)
+	assert.Equal(t, "broken", index.Skipped[0].ID)
+	assert.EqualError(t, index.Skipped[0].Err, "package broken not found")
+
+	for _, tc := range []struct {
+		provider, name, id string
+	}{
+		{"apt", "git", "git"},
+		{"brew", "git", "git"},
+		{"winget", "git.git", "git"},
+		{"apt", "libssl3t64:amd64", "openssl"},
+		{"brew", "hashicorp/tap/terraform", "terraform"},
+		{"brew", "visual-studio-code", "vscode"},
+		{"snap", "code", "vscode"},
+	} {
+		id, ok := index.Lookup(tc.provider, tc.name)
+		assert.True(t, ok, "%s %s", tc.provider, tc.name)
+		assert.Equal(t, tc.id, id)
+	}
+
+	_, ok := index.Lookup("apt", "gcc-12-base")
+	assert.False(t, ok)
+	_, ok = index.Lookup("snap", "git")
+	assert.False(t, ok)
+}
+
+func TestBuildReverseIndex_IndexError(t *testing.T) {
+	reg := NewMockRegistry()
+	reg.SetIndexError(fmt.Errorf("offline"))
+
+	_, err := BuildReverseIndex(reg)
+	assert.ErrorContains(t, err, "failed to load package index")
+}
@@ -0,0 +1,391 @@
+package render
+
+import (
+	"fmt"
+	"io"
+
+	"github.com/Litchi-group/unipm/internal/detector"
+	"github.com/Litchi-group/unipm/internal/errors"
+	"github.com/Litchi-group/unipm/internal/planner"
+	"github.com/Litchi-group/unipm/internal/provider"
+)
+
+// OS describes the system a plan is for
+type OS struct {
+	Platform string `json:"platform" yaml:"platform"`
+	Distro   string `json:"distro,omitempty" yaml:"distro,omitempty"`
+	Family   string `json:"family,omitempty" yaml:"family,omitempty"`
+	Codename string `json:"codename,omitempty" yaml:"codename,omitempty"`
+	Arch     string `json:"arch" yaml:"arch"`
+}
+
+// NewOS returns the document form of OS information
+func NewOS(info *detector.OSInfo) OS {
+	return OS{
+		Platform: info.Platform,
+		Distro:   info.Distro,
+		Family:   info.Family,
+		Codename: info.Codename,
+		Arch:     info.Arch,
+	}
+}
+
+// Step is a preparatory step of a plan
+type Step struct {
+	Key         string `json:"key" yaml:"key"`
+	Description string `json:"description" yaml:"description"`
+	Reason      string `json:"reason,omitempty" yaml:"reason,omitempty"`
+	Command     string `json:"command" yaml:"command"`
+	Status      string `json:"status,omitempty" yaml:"status,omitempty"` // Set in run results
+	Error       string `json:"error,omitempty" yaml:"error,omitempty"`
+}
+
+// Task is a package of a plan
+type Task struct {
+	Package   string `json:"package" yaml:"package"`
+	Version   string `json:"version,omitempty" yaml:"version,omitempty"` // Requested in devpack.yaml
+	Provider  string `json:"provider" yaml:"provider"`                   // Provider type (e.g., "brew_cask")
+	Name      string `json:"name" yaml:"name"`                           // Native package name or ID
+	Command   string `json:"command" yaml:"command"`                     // Command run for the action
+	Installed *bool  `json:"installed" yaml:"installed"`                 // null when unknown (offline plans)
+	Drift     string `json:"drift,omitempty" yaml:"drift,omitempty"`     // How an installed package differs from the spec
+	Script    bool   `json:"script" yaml:"script"`                       // Runs a shell script from the registry
+	Verified  bool   `json:"verified" yaml:"verified"`                   // Package definition checksum matched
+	Pinned    bool   `json:"pinned" yaml:"pinned"`                       // Package definition matched the checksum pinned in devpack.yaml
+	Status    string `json:"status,omitempty" yaml:"status,omitempty"`   // Set in run results
+	Error     string `json:"error,omitempty" yaml:"error,omitempty"`
+}
+
+// PlanDocument is the structured form of a plan
+type PlanDocument struct {
+	OS      OS     `json:"os" yaml:"os"`
+	Offline bool   `json:"offline" yaml:"offline"` // Planned for another system
+	Steps   []Step `json:"steps" yaml:"steps"`
+	Tasks   []Task `json:"tasks" yaml:"tasks"`
+}
+
+// NewPlanDocument returns the structured form of a plan
+func NewPlanDocument(plan *planner.Plan) *PlanDocument {
+	return &PlanDocument{
+		OS:      NewOS(plan.OSInfo),
+		Offline: plan.Offline,
+		Steps:   newSteps(plan),
+		Tasks:   newTasks(plan, planner.ActionInstall),
+	}
+}
+
+// newSteps returns the steps of a plan
+func newSteps(plan *planner.Plan) []Step {
+	steps := make([]Step, 0, len(plan.Steps))
+	for _, step := range plan.Steps {
+		steps = append(steps, Step{
+			Key:         step.Key,
+			Description: step.Description,
+			Reason:      step.Reason,
+			Command:     step.Command,
+		})
+	}
+	return steps
+}
+
+// newTasks returns the tasks of a plan, with the commands of an action
+func newTasks(plan *planner.Plan, action string) []Task {
+	tasks := make([]Task, 0, len(plan.Tasks))
+	for _, task := range plan.Tasks {
+		t := Task{
+			Package:  task.PackageID,
+			Version:  task.Version,
+			Provider: task.Spec.Type,
+			Name:     provider.NativeName(*task.Spec),
+			Command:  actionCommand(task, action),
+			Script:   task.RunsScript(),
+			Verified: task.Spec.Verified,
+			Pinned:   task.Spec.Pinned,
+		}
+		if !plan.Offline {
+			installed := task.Installed
+			t.Installed = &installed
+			t.Drift = task.Drift
+		}
+		tasks = append(tasks, t)
+	}
+	return tasks
+}
+
+// actionCommand returns the command an action runs for a task
+func actionCommand(task *planner.InstallTask, action string) string {
+	if action == planner.ActionRemove {
+		return task.Provider.RemoveCommand(*task.Spec)
+	}
+	return task.Provider.InstallCommand(*task.Spec)
+}
+
+// RunDocument is the result of running a plan with apply, update or remove
+type RunDocument struct {
+	Action  string             `json:"action" yaml:"action"`
+	OS      OS                 `json:"os" yaml:"os"`
+	DryRun  bool               `json:"dry_run" yaml:"dry_run"`
+	Steps   []Step             `json:"steps" yaml:"steps"`
+	Tasks   []Task             `json:"tasks" yaml:"tasks"`
+	Summary map[string]int     `json:"summary" yaml:"summary"`             // Number of tasks by status
+	Log     string             `json:"log,omitempty" yaml:"log,omitempty"` // Run log with the full output
+	Error   *errors.Structured `json:"error,omitempty" yaml:"error,omitempty"`
+}
+
+// Recorder is a planner.Reporter collecting the outcome of every step and
+// task into a RunDocument
+type Recorder struct {
+	doc   *RunDocument
+	plan  *planner.Plan
+	steps map[string]int // Step index by key
+}
+
+// NewRecorder returns a recorder for running an action on a plan. Until
+// reported, steps and tasks are not run.
+func NewRecorder(plan *planner.Plan, action string, dryRun bool) *Recorder {
+	r := &Recorder{
+		doc: &RunDocument{
+			Action: action,
+			OS:     NewOS(plan.OSInfo),
+			DryRun: dryRun,
+			Steps:  newSteps(plan),
+			Tasks:  newTasks(plan, action),
+		},
+		plan:  plan,
+		steps: make(map[string]int),
+	}
+
+	for i := range r.doc.Steps {
+		r.doc.Steps[i].Status = planner.StatusNotRun
+		r.steps[r.doc.Steps[i].Key] = i
+	}
+	for i := range r.doc.Tasks {
+		r.doc.Tasks[i].Status = planner.StatusNotRun
+	}
+
+	return r
+}
+
+// task returns the document entry of a task
+func (r *Recorder) task(task *planner.InstallTask) *Task {
+	for i, t := range r.plan.Tasks {
+		if t == task {
+			return &r.doc.Tasks[i]
+		}
+	}
+	return &Task{}
+}
+
+func (r *Recorder) StepStarted(provider.Step) {}
+
+func (r *Recorder) StepFinished(step provider.Step, status string, err error) {
+	if i, ok := r.steps[step.Key]; ok {
+		r.doc.Steps[i].Status = status
+		if err != nil {
+			r.doc.Steps[i].Error = err.Error()
+		}
+	}
+}
+
+func (r *Recorder) TaskStarted(*planner.InstallTask, string) {}
+
+func (r *Recorder) TaskFinished(task *planner.InstallTask, action, status string, err error) {
+	t := r.task(task)
+	t.Status = status
+	if err != nil {
+		t.Error = err.Error()
+	}
+}
+
+func (r *Recorder) Interrupted(plan *planner.Plan, current int, started bool) {
+	if started && current < len(r.doc.Tasks) {
+		r.doc.Tasks[current].Status = planner.StatusInterrupted
+	}
+}
+
+func (r *Recorder) Done(string, bool) {}
+
+// Document returns the result, with the error the run ended with, if any
+func (r *Recorder) Document(err error) *RunDocument {
+	r.doc.Summary = make(map[string]int)
+	for _, t := range r.doc.Tasks {
+		r.doc.Summary[t.Status]++
+	}
+	if err != nil {
+		r.doc.Error = errors.ToStructured(err)
+	}
+	return r.doc
+}
+
+// PrintPlan prints a plan for people
+func PrintPlan(w io.Writer, plan *planner.Plan) {
+	if plan.Offline {
+		fmt.Fprintf(w, "Plan for %s on %s:\n", plan.OSInfo.String(), plan.OSInfo.Arch)
+		fmt.Fprintln(w, "(planned for another system: provider availability and installed state are unknown)")
+		fmt.Fprintln(w)
+	} else {
+		fmt.Fprintf(w, "Plan for %s:\n\n", plan.OSInfo.String())
+	}
+
+	PrintSteps(w, plan)
+
+	for _, task := range plan.Tasks {
+		cmd := task.Provider.InstallCommand(*task.Spec)
+		status := ""
+		if task.Installed {
+			status = " (already installed)"
+		} else if task.Drift != "" {
+			status = fmt.Sprintf(" (installed but %s)", task.Drift)
+		}
+		if task.IgnoresVersion() {
+			status += fmt.Sprintf(" (%s cannot select version %s)", task.Spec.Type, task.Version)
+		}
+		if task.RunsScript() {
+			status += " ⚠️  SHELL SCRIPT"
+		}
+		fmt.Fprintf(w, "  %s → %s%s\n", task.Label(), cmd, status)
+	}
+
+	fmt.Fprintln(w)
+	PrintScriptWarning(w, plan)
+	if plan.Offline {
+		fmt.Fprintf(w, "To apply this plan, run 'unipm apply' on %s.\n", plan.OSInfo.String())
+		return
+	}
+	fmt.Fprintln(w, "To apply this plan, run 'unipm apply'.")
+}
+
+// PrintSteps prints the preparatory steps of a plan, if any
+func PrintSteps(w io.Writer, plan *planner.Plan) {
+	if len(plan.Steps) == 0 {
+		return
+	}
+
+	fmt.Fprintln(w, "  Preparation:")
+	for _, step := range plan.Steps {
+		reason := ""
+		if step.Reason != "" {
+			reason = fmt.Sprintf(" (%s)", step.Reason)
+		}
+		fmt.Fprintf(w, "  ↻ %s → %s%s\n", step.Description, step.Command, reason)
+	}
+	fmt.Fprintln(w)
+}
+
+// PrintScriptWarning prints a warning listing tasks that run registry shell scripts
+func PrintScriptWarning(w io.Writer, plan *planner.Plan) {
+	var scripts []*planner.InstallTask
+	for _, task := range plan.Tasks {
+		if task.RunsScript() && !task.Installed {
+			scripts = append(scripts, task)
+		}
+	}
+
+	if len(scripts) == 0 {
+		return
+	}
+
+	fmt.Fprintf(w, "⚠️  WARNING: %d package(s) install by running shell scripts from the registry:\n", len(scripts))
+	for _, task := range scripts {
+		if task.Spec.Pinned {
+			fmt.Fprintf(w, "     - %s (pinned in devpack.yaml)\n", task.PackageID)
+			continue
+		}
+		fmt.Fprintf(w, "     - %s (review with 'unipm info %s', then pin its checksum in devpack.yaml)\n", task.PackageID, task.PackageID)
+	}
+	fmt.Fprintln(w)
+}
+
+// actionText is how the text reporter describes an action
+type actionText struct {
+	started string // Printed before the task runs
+	done    string // Printed when it succeeded
+	skipped string // Printed when there was nothing to do
+	summary string // Counts of done and skipped tasks
+}
+
+var actionTexts = map[string]actionText{
+	planner.ActionInstall: {"Installing", "✓ Installed", "⊙ Already installed", "Done! %d installed, %d skipped."},
+	planner.ActionUpdate:  {"Updating", "✓ Updated", "⊙ Not installed (use 'unipm apply' to install)", "Done! %d updated, %d not installed."},
+	planner.ActionRemove:  {"Removing", "✓ Removed", "⊙ Not installed", "Done! %d removed, %d not installed."},
+}
+
+// TextReporter is a planner.Reporter printing progress for people
+type TextReporter struct {
+	w        io.Writer
+	done     int
+	skipped  int
+	statuses map[*planner.InstallTask]string // Status of each finished task
+}
+
+// NewTextReporter returns a reporter printing to w
+func NewTextReporter(w io.Writer) *TextReporter {
+	return &TextReporter{w: w, statuses: make(map[*planner.InstallTask]string)}
+}
+
+func (r *TextReporter) StepStarted(step provider.Step) {
+	fmt.Fprintf(r.w, "%s...\n", step.Description)
+}
+
+func (r *TextReporter) StepFinished(step provider.Step, status string, err error) {
+	switch status {
+	case planner.StatusDone:
+		fmt.Fprintf(r.w, "  ✓ Done\n")
+	case planner.StatusDryRun:
+		fmt.Fprintf(r.w, "  [dry-run] %s\n", step.Command)
+	}
+}
+
+func (r *TextReporter) TaskStarted(task *planner.InstallTask, action string) {
+	fmt.Fprintf(r.w, "%s %s...\n", actionTexts[action].started, task.Label())
+}
+
+func (r *TextReporter) TaskFinished(task *planner.InstallTask, action, status string, err error) {
+	r.statuses[task] = status
+
+	switch status {
+	case planner.StatusDone:
+		fmt.Fprintf(r.w, "  %s\n", actionTexts[action].done)
+		r.done++
+	case planner.StatusDryRun:
+		fmt.Fprintf(r.w, "  [dry-run] %s\n", actionCommand(task, action))
+		r.done++
+	case planner.StatusSkipped:
+		fmt.Fprintf(r.w, "  %s\n", actionTexts[action].skipped)
+		r.skipped++
+	}
+}
+
+func (r *TextReporter) Interrupted(plan *planner.Plan, current int, started bool) {
+	fmt.Fprintln(r.w)
+	fmt.Fprintln(r.w, "⚠️  Interrupted.")
+
+	for i, task := range plan.Tasks {
+		switch status := r.statuses[task]; {
+		case status == planner.StatusDone:
+			fmt.Fprintf(r.w, "  ✓ %s: completed\n", task.Label())
+		case status == planner.StatusSkipped:
+			fmt.Fprintf(r.w, "  ⊙ %s: skipped, nothing to do\n", task.Label())
+		case status == planner.StatusDryRun:
+			fmt.Fprintf(r.w, "  ⊙ %s: dry run, nothing changed\n", task.Label())
+		case status == planner.StatusFailed:
+			fmt.Fprintf(r.w, "  ✗ %s: failed\n", task.Label())
+		case i == current && started:
+			fmt.Fprintf(r.w, "  ✗ %s: interrupted (state unknown, re-run to verify)\n", task.Label())
+		default:
+			fmt.Fprintf(r.w, "  ⊙ %s: not run\n", task.Label())
+		}
+	}
+
+	fmt.Fprintln(r.w)
+}
+
+func (r *TextReporter) Done(action string, dryRun bool) {
+	fmt.Fprintln(r.w)
+
+	if dryRun && action == planner.ActionInstall {
+		fmt.Fprintf(r.w, "Dry run complete. Would install %d, skip %d.\n", r.done, r.skipped)
+		return
+	}
+	fmt.Fprintf(r.w, actionTexts[action].summary+"\n", r.done, r.skipped)
+}
@@ -0,0 +1,125 @@
+// Package render displays command results, either as text for people or
+// as JSON or YAML documents for scripts and tools
+package render
+
+import (
+	"encoding/json"
+	goerrors "errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/Litchi-group/unipm/internal/errors"
+	"gopkg.in/yaml.v3"
+)
+
+// Output formats
+const (
+	FormatText = "text" // Human-readable text (default)
+	FormatJSON = "json"
+	FormatYAML = "yaml"
+)
+
+// Formats lists the supported output formats
+var Formats = []string{FormatText, FormatJSON, FormatYAML}
+
+// format is the output format of this process
+var format = FormatText
+
+// SetFormat sets the output format
+func SetFormat(f string) error {
+	switch f {
+	case FormatText, FormatJSON, FormatYAML:
+		format = f
+		return nil
+	default:
+		return fmt.Errorf("invalid output format %q: expected %s", f, strings.Join(Formats, ", "))
+	}
+}
+
+// Format returns the output format
+func Format() string {
+	return format
+}
+
+// Structured reports whether results are printed as JSON or YAML. Text
+// meant for people then goes to stderr, so stdout holds one document.
+func Structured() bool {
+	return format != FormatText
+}
+
+// Messages returns where to print text meant for people: stdout, or stderr
+// when results are structured
+func Messages() io.Writer {
+	if Structured() {
+		return os.Stderr
+	}
+	return os.Stdout
+}
+
+// Encode writes v as a JSON or YAML document, in the output format
+func Encode(w io.Writer, v interface{}) error {
+	return EncodeAs(w, format, v)
+}
+
+// EncodeAs writes v as a YAML document if format is yaml, or else JSON
+func EncodeAs(w io.Writer, format string, v interface{}) error {
+	if format == FormatYAML {
+		enc := yaml.NewEncoder(w)
+		enc.SetIndent(2)
+		if err := enc.Encode(v); err != nil {
+			return err
+		}
+		return enc.Close()
+	}
+
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(v)
+}
+
+// Print writes v to stdout as a JSON or YAML document
+func Print(v interface{}) error {
+	if err := Encode(os.Stdout, v); err != nil {
+		return fmt.Errorf("failed to write output: %w", err)
+	}
+	return nil
+}
+
+// ErrorDocument is printed instead of a result when a command fails
+type ErrorDocument struct {
+	Error *errors.Structured `json:"error" yaml:"error"`
+}
+
+// PrintError writes a failed command's error to stdout as a document,
+// unless the command already reported it in its result
+func PrintError(err error) error {
+	var reported *ReportedError
+	if goerrors.As(err, &reported) {
+		return nil
+	}
+	return Print(ErrorDocument{Error: errors.ToStructured(err)})
+}
+
+// ReportedError marks an error already included in a printed result, so it
+// only sets the exit status
+type ReportedError struct {
+	Err error
+}
+
+func (e *ReportedError) Error() string {
+	return e.Err.Error()
+}
+
+func (e *ReportedError) Unwrap() error {
+	return e.Err
+}
+
+// Reported marks err as included in a printed result; nil stays nil
+func Reported(err error) error {
+	if err == nil {
+		return nil
+	}
+	return &ReportedError{Err: err}
+}
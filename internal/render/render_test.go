@@ -0,0 +1,117 @@
+package render
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/Litchi-group/unipm/internal/detector"
+	"github.com/Litchi-group/unipm/internal/errors"
+	"github.com/Litchi-group/unipm/internal/planner"
+	"github.com/Litchi-group/unipm/internal/provider"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func testPlan() *planner.Plan {
+	npm := provider.NewNpmProvider()
+	return &planner.Plan{
+		OSInfo: &detector.OSInfo{Platform: "linux", Distro: "ubuntu", Family: "debian", Arch: "amd64"},
+		Tasks: []*planner.InstallTask{
+			{PackageID: "typescript", Spec: &provider.ProviderSpec{Type: "npm", Name: "typescript"}, Provider: npm, Installed: true},
+			{PackageID: "eslint", Spec: &provider.ProviderSpec{Type: "npm", Name: "eslint"}, Provider: npm},
+		},
+	}
+}
+
+func TestExecute_TextReporter(t *testing.T) {
+	plan := testPlan()
+	var out bytes.Buffer
+	plan.Reporter = NewTextReporter(&out)
+
+	require.NoError(t, plan.Execute(context.Background(), true))
+
+	assert.Equal(t, "Installing typescript...\n  ⊙ Already installed\n"+
+		"Installing eslint...\n  [dry-run] npm install -g eslint\n"+
+		"\nDry run complete. Would install 1, skip 1.\n", out.String())
+}
+
+func TestExecute_Recorder(t *testing.T) {
+	plan := testPlan()
+	recorder := NewRecorder(plan, planner.ActionInstall, true)
+	plan.Reporter = recorder
+
+	require.NoError(t, plan.Execute(context.Background(), true))
+	doc := recorder.Document(nil)
+
+	require.Len(t, doc.Tasks, 2)
+	assert.Equal(t, planner.StatusSkipped, doc.Tasks[0].Status)
+	assert.Equal(t, planner.StatusDryRun, doc.Tasks[1].Status)
+	assert.Equal(t, "npm install -g eslint", doc.Tasks[1].Command)
+	assert.Equal(t, map[string]int{planner.StatusSkipped: 1, planner.StatusDryRun: 1}, doc.Summary)
+	assert.Nil(t, doc.Error)
+}
+
+func TestRecorder_Interrupted(t *testing.T) {
+	plan := testPlan()
+	recorder := NewRecorder(plan, planner.ActionUpdate, false)
+
+	recorder.TaskFinished(plan.Tasks[0], planner.ActionUpdate, planner.StatusDone, nil)
+	recorder.Interrupted(plan, 1, true)
+	doc := recorder.Document(context.Canceled)
+
+	assert.Equal(t, planner.StatusDone, doc.Tasks[0].Status)
+	assert.Equal(t, planner.StatusInterrupted, doc.Tasks[1].Status)
+	assert.Equal(t, errors.TypeGeneric, doc.Error.Type)
+}
+
+func TestEncode_Error(t *testing.T) {
+	installErr := errors.NewInstallError("git", "apt", "installation failed", nil)
+	installErr.Command = "apt-get install -y git"
+	installErr.ExitCode = 100
+	err := fmt.Errorf("apply: %w", installErr)
+
+	var out bytes.Buffer
+	require.NoError(t, EncodeAs(&out, FormatYAML, ErrorDocument{Error: errors.ToStructured(err)}))
+	assert.Equal(t, `error:
+  type: install
+  message: 'apply: failed to install ''git'' via apt: installation failed'
+  package: git
+  provider: apt
+  command: apt-get install -y git
+  exit_code: 100
+`, out.String())
+
+	out.Reset()
+	require.NoError(t, EncodeAs(&out, FormatJSON, ErrorDocument{Error: errors.ToStructured(errors.NewNotFoundError("nope"))}))
+	assert.JSONEq(t, `{"error": {"type": "not_found", "message": "package 'nope' not found in registry", "package": "nope"}}`, out.String())
+}
+
+func TestTextReporter_Interrupted(t *testing.T) {
+	plan := testPlan()
+	plan.Tasks = append(plan.Tasks,
+		&planner.InstallTask{PackageID: "prettier", Spec: &provider.ProviderSpec{Type: "npm", Name: "prettier"}, Provider: provider.NewNpmProvider()},
+		&planner.InstallTask{PackageID: "jest", Spec: &provider.ProviderSpec{Type: "npm", Name: "jest"}, Provider: provider.NewNpmProvider()},
+	)
+	var out bytes.Buffer
+	reporter := NewTextReporter(&out)
+
+	reporter.TaskFinished(plan.Tasks[0], planner.ActionInstall, planner.StatusSkipped, nil)
+	reporter.TaskFinished(plan.Tasks[1], planner.ActionInstall, planner.StatusDone, nil)
+	out.Reset()
+	reporter.Interrupted(plan, 2, true)
+
+	assert.Equal(t, "\n⚠️  Interrupted.\n"+
+		"  ⊙ typescript: skipped, nothing to do\n"+
+		"  ✓ eslint: completed\n"+
+		"  ✗ prettier: interrupted (state unknown, re-run to verify)\n"+
+		"  ⊙ jest: not run\n\n", out.String())
+
+	out.Reset()
+	dryRun := NewTextReporter(&out)
+	dryRun.TaskFinished(plan.Tasks[0], planner.ActionInstall, planner.StatusDryRun, nil)
+	out.Reset()
+	dryRun.Interrupted(plan, 1, false)
+	assert.Contains(t, out.String(), "  ⊙ typescript: dry run, nothing changed\n  ⊙ eslint: not run\n")
+}